@@ -38,6 +38,17 @@ const (
 	UpgradeConstraintPolicyIgnore UpgradeConstraintPolicy = "Ignore"
 )
 
+type UpgradeApproval string
+
+const (
+	// Upgrades are applied as soon as they are available.
+	UpgradeApprovalAutomatic UpgradeApproval = "Automatic"
+
+	// Upgrades are only applied once the version of the
+	// upgrade has been approved via the approvedVersion field.
+	UpgradeApprovalManual UpgradeApproval = "Manual"
+)
+
 // OperatorSpec defines the desired state of Operator
 type OperatorSpec struct {
 	//+kubebuilder:validation:MaxLength:=48
@@ -65,28 +76,112 @@ type OperatorSpec struct {
 	//
 	// Defines the policy for how to handle upgrade constraints
 	UpgradeConstraintPolicy UpgradeConstraintPolicy `json:"upgradeConstraintPolicy,omitempty"`
+
+	//+kubebuilder:validation:Enum:=Automatic;Manual
+	//+kubebuilder:default:=Automatic
+	//+kubebuilder:Optional
+	//
+	// Defines whether upgrades are applied automatically or need to be approved
+	UpgradeApproval UpgradeApproval `json:"upgradeApproval,omitempty"`
+
+	//+kubebuilder:Optional
+	// ApprovedVersion is the version of the package the operator is allowed to be upgraded to
+	// when UpgradeApproval is Manual. It usually matches the version of status.pendingUpgrade.
+	// Upgrades are limited to this version, even once newer versions become available.
+	ApprovedVersion string `json:"approvedVersion,omitempty"`
+
+	//+kubebuilder:Optional
+	// BundleImage is a bundle image to install directly, without resolving
+	// the package from the catalogs available in the cluster.
+	// It is meant for testing bundles which are not published in a catalog yet.
+	BundleImage string `json:"bundleImage,omitempty"`
+
+	//+kubebuilder:validation:Enum:=registry+v1;plain+v0
+	//+kubebuilder:Optional
+	// BundleMediaType is the media type of the bundle referenced by BundleImage.
+	// If not specified, the bundle is assumed to be a registry+v1 bundle.
+	BundleMediaType string `json:"bundleMediaType,omitempty"`
+
+	//+kubebuilder:Optional
+	// CatalogSelector restricts the catalogs the package can be sourced from.
+	// If not specified, bundles from all catalogs available in the cluster are considered.
+	CatalogSelector *CatalogSelector `json:"catalogSelector,omitempty"`
+}
+
+// CatalogSelector selects catalogs by name and/or by label.
+// A catalog is selected only if it satisfies every criteria that is specified.
+type CatalogSelector struct {
+	//+kubebuilder:Optional
+	// Names is a list of catalog names to source the package from.
+	Names []string `json:"names,omitempty"`
+
+	//+kubebuilder:Optional
+	// LabelSelector is a label query over catalogs to source the package from.
+	LabelSelector *metav1.LabelSelector `json:"labelSelector,omitempty"`
 }
 
 const (
 	// TODO(user): add more Types, here and into init()
 	TypeInstalled = "Installed"
 	TypeResolved  = "Resolved"
+	TypeDeleting  = "Deleting"
+
+	TypeUpgradeAvailable = "UpgradeAvailable"
+
+	// TypeDegraded is True when resolution had to skip catalogs
+	// the Operator could source its package from.
+	TypeDegraded = "Degraded"
+
+	// TypeDeprecated is True when the package, the channel or the bundle
+	// the Operator resolved to is deprecated. The deprecations are
+	// detailed by TypePackageDeprecated, TypeChannelDeprecated and
+	// TypeBundleDeprecated, which carry the messages of the catalog.
+	TypeDeprecated        = "Deprecated"
+	TypePackageDeprecated = "PackageDeprecated"
+	TypeChannelDeprecated = "ChannelDeprecated"
+	TypeBundleDeprecated  = "BundleDeprecated"
 
-	ReasonBundleLookupFailed        = "BundleLookupFailed"
-	ReasonInstallationFailed        = "InstallationFailed"
-	ReasonInstallationStatusUnknown = "InstallationStatusUnknown"
-	ReasonInstallationSucceeded     = "InstallationSucceeded"
-	ReasonInvalidSpec               = "InvalidSpec"
-	ReasonResolutionFailed          = "ResolutionFailed"
-	ReasonResolutionUnknown         = "ResolutionUnknown"
-	ReasonSuccess                   = "Success"
+	ReasonBundleLookupFailed                = "BundleLookupFailed"
+	ReasonCatalogsAvailable                 = "CatalogsAvailable"
+	ReasonCatalogsUnavailable               = "CatalogsUnavailable"
+	ReasonCatalogStatusUnknown              = "CatalogStatusUnknown"
+	ReasonDeletionNotRequested              = "DeletionNotRequested"
+	ReasonDeprecated                        = "Deprecated"
+	ReasonDeprecationStatusUnknown          = "DeprecationStatusUnknown"
+	ReasonInstallationFailed                = "InstallationFailed"
+	ReasonInstallationStatusUnknown         = "InstallationStatusUnknown"
+	ReasonInstallationSucceeded             = "InstallationSucceeded"
+	ReasonInvalidSpec                       = "InvalidSpec"
+	ReasonNoUpgradePending                  = "NoUpgradePending"
+	ReasonNotDeprecated                     = "NotDeprecated"
+	ReasonResolutionFailed                  = "ResolutionFailed"
+	ReasonResolutionUnknown                 = "ResolutionUnknown"
+	ReasonSuccess                           = "Success"
+	ReasonUpgradePending                    = "UpgradePending"
+	ReasonUpgradeStatusUnknown              = "UpgradeStatusUnknown"
+	ReasonWaitingForBundleDeploymentRemoval = "WaitingForBundleDeploymentRemoval"
 )
 
+// CleanupFinalizer is added to every Operator to make sure that the
+// BundleDeployment created for it is removed before the Operator is deleted.
+const CleanupFinalizer = "operators.operatorframework.io/cleanup"
+
+// BundleImageAnnotation is set on the BundleDeployments installing the .spec.bundleImage
+// of their Operator, whose bundle may not be part of any catalog.
+const BundleImageAnnotation = "operators.operatorframework.io/bundle-image"
+
 func init() {
 	// TODO(user): add Types from above
 	conditionsets.ConditionTypes = append(conditionsets.ConditionTypes,
 		TypeInstalled,
 		TypeResolved,
+		TypeDeleting,
+		TypeUpgradeAvailable,
+		TypeDegraded,
+		TypeDeprecated,
+		TypePackageDeprecated,
+		TypeChannelDeprecated,
+		TypeBundleDeprecated,
 	)
 	// TODO(user): add Reasons from above
 	conditionsets.ConditionReasons = append(conditionsets.ConditionReasons,
@@ -98,6 +193,17 @@ func init() {
 		ReasonInstallationStatusUnknown,
 		ReasonInvalidSpec,
 		ReasonSuccess,
+		ReasonDeletionNotRequested,
+		ReasonWaitingForBundleDeploymentRemoval,
+		ReasonNoUpgradePending,
+		ReasonUpgradePending,
+		ReasonUpgradeStatusUnknown,
+		ReasonCatalogsAvailable,
+		ReasonCatalogsUnavailable,
+		ReasonCatalogStatusUnknown,
+		ReasonDeprecated,
+		ReasonNotDeprecated,
+		ReasonDeprecationStatusUnknown,
 	)
 }
 
@@ -107,6 +213,14 @@ type OperatorStatus struct {
 	InstalledBundleResource string `json:"installedBundleResource,omitempty"`
 	// +optional
 	ResolvedBundleResource string `json:"resolvedBundleResource,omitempty"`
+	// PendingUpgrade is the bundle the operator would be upgraded to
+	// once its version is approved. Only set when UpgradeApproval is Manual.
+	// +optional
+	PendingUpgrade *BundleMetadata `json:"pendingUpgrade,omitempty"`
+	// AvailableUpgrades lists the bundles the installed bundle can be upgraded to
+	// according to the upgrade graph, regardless of the constraints set in the spec.
+	// +optional
+	AvailableUpgrades []AvailableUpgrade `json:"availableUpgrades,omitempty"`
 
 	// +patchMergeKey=type
 	// +patchStrategy=merge
@@ -115,6 +229,23 @@ type OperatorStatus struct {
 	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type" protobuf:"bytes,1,rep,name=conditions"`
 }
 
+// BundleMetadata identifies a bundle of a package
+type BundleMetadata struct {
+	// Name is the name of the bundle
+	Name string `json:"name"`
+	// Version is the version of the bundle
+	Version string `json:"version"`
+}
+
+// AvailableUpgrade describes a bundle the installed bundle can be upgraded to
+type AvailableUpgrade struct {
+	BundleMetadata `json:",inline"`
+	// Channels are the channels of the package the bundle belongs to
+	Channels []string `json:"channels,omitempty"`
+	// Catalog is the name of the catalog which provides the bundle
+	Catalog string `json:"catalog"`
+}
+
 //+kubebuilder:object:root=true
 //+kubebuilder:resource:scope=Cluster
 //+kubebuilder:subresource:status
@@ -18,7 +18,6 @@ package main
 
 import (
 	"flag"
-	"net/http"
 	"os"
 	"time"
 
@@ -26,6 +25,7 @@ import (
 	"go.uber.org/zap/zapcore"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/discovery"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -33,13 +33,14 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
 	catalogd "github.com/operator-framework/catalogd/api/core/v1alpha1"
-	"github.com/operator-framework/deppy/pkg/deppy/solver"
 	rukpakv1alpha1 "github.com/operator-framework/rukpak/api/v1alpha1"
 
 	operatorsv1alpha1 "github.com/operator-framework/operator-controller/api/v1alpha1"
 	"github.com/operator-framework/operator-controller/internal/catalogmetadata/cache"
 	catalogclient "github.com/operator-framework/operator-controller/internal/catalogmetadata/client"
+	"github.com/operator-framework/operator-controller/internal/clusterversion"
 	"github.com/operator-framework/operator-controller/internal/controllers"
+	"github.com/operator-framework/operator-controller/internal/httpclient"
 	"github.com/operator-framework/operator-controller/pkg/features"
 )
 
@@ -64,6 +65,8 @@ func main() {
 		enableLeaderElection bool
 		probeAddr            string
 		cachePath            string
+		cacheMaxSize         int64
+		catalogdOptions      httpclient.Options
 	)
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
@@ -71,6 +74,22 @@ func main() {
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
 	flag.StringVar(&cachePath, "cache-path", "/var/cache", "The local directory path used for filesystem based caching")
+	flag.Int64Var(&cacheMaxSize, "cache-max-size", 0,
+		"The maximum size in bytes of the catalog contents cached in the cache path. "+
+			"The contents of the least recently used catalogs are evicted first. 0 means no limit.")
+	flag.StringVar(&catalogdOptions.CAFile, "catalogd-ca-file", "",
+		"The path of a PEM bundle of certificate authorities trusted, in addition to the system ones, "+
+			"when downloading catalog contents from catalogd. It is reloaded when it changes.")
+	flag.StringVar(&catalogdOptions.CertFile, "catalogd-cert-file", "",
+		"The path of the PEM encoded client certificate presented to catalogd. It is reloaded when it changes.")
+	flag.StringVar(&catalogdOptions.KeyFile, "catalogd-key-file", "",
+		"The path of the PEM encoded key of the client certificate presented to catalogd. It is reloaded when it changes.")
+	flag.DurationVar(&catalogdOptions.Timeout, "catalogd-timeout", 10*time.Second,
+		"The time limit of each attempt to download catalog contents from catalogd. 0 means no limit.")
+	flag.IntVar(&catalogdOptions.Retries, "catalogd-retries", 3,
+		"The number of times failed downloads of catalog contents from catalogd are retried.")
+	flag.DurationVar(&catalogdOptions.RetryBackoff, "catalogd-retry-backoff", time.Second,
+		"The time waited before retrying a failed download of catalog contents from catalogd, doubled after each retry.")
 	opts := zap.Options{
 		Development: true,
 	}
@@ -107,14 +126,24 @@ func main() {
 	}
 
 	cl := mgr.GetClient()
-	catalogClient := catalogclient.New(cl, cache.NewFilesystemCache(cachePath, &http.Client{Timeout: 10 * time.Second}))
+	catalogdClient, err := httpclient.New(catalogdOptions)
+	if err != nil {
+		setupLog.Error(err, "unable to create catalogd client")
+		os.Exit(1)
+	}
+	fetcher, err := cache.NewFilesystemCache(cachePath, catalogdClient, cache.WithMaxSize(cacheMaxSize))
+	if err != nil {
+		setupLog.Error(err, "unable to create catalog cache")
+		os.Exit(1)
+	}
+	catalogClient := catalogclient.New(cl, fetcher)
+	clusterVersion := clusterversion.NewDiscoveryProvider(discovery.NewDiscoveryClientForConfigOrDie(mgr.GetConfig()))
 
 	if err = (&controllers.OperatorReconciler{
-		Client: cl,
-		Scheme: mgr.GetScheme(),
-		Resolver: solver.NewDeppySolver(
-			controllers.NewVariableSource(cl, catalogClient),
-		),
+		Client:   cl,
+		Scheme:   mgr.GetScheme(),
+		Resolver: controllers.NewIncrementalResolver(cl, catalogClient, clusterVersion),
+		Recorder: mgr.GetEventRecorderFor("operator-controller"),
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Operator")
 		os.Exit(1)
@@ -18,6 +18,8 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 
 	"github.com/operator-framework/operator-registry/alpha/action"
 
@@ -26,8 +28,8 @@ import (
 )
 
 type indexRefClient struct {
-	renderer     action.Render
-	bundlesCache []*catalogmetadata.Bundle
+	renderer   action.Render
+	indexCache *catalogmetadata.BundleIndex
 }
 
 func newIndexRefClient(indexRef string) *indexRefClient {
@@ -40,17 +42,33 @@ func newIndexRefClient(indexRef string) *indexRefClient {
 }
 
 func (c *indexRefClient) Bundles(ctx context.Context) ([]*catalogmetadata.Bundle, error) {
-	if c.bundlesCache == nil {
+	index, err := c.BundleIndex(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return index.All(), nil
+}
+
+func (c *indexRefClient) BundleIndex(ctx context.Context) (*catalogmetadata.BundleIndex, error) {
+	if c.indexCache == nil {
 		cfg, err := c.renderer.Run(ctx)
 		if err != nil {
 			return nil, err
 		}
 
 		var (
-			channels []*catalogmetadata.Channel
-			bundles  []*catalogmetadata.Bundle
+			packages     []*catalogmetadata.Package
+			channels     []*catalogmetadata.Channel
+			bundles      []*catalogmetadata.Bundle
+			deprecations []*catalogmetadata.Deprecation
 		)
 
+		for i := range cfg.Packages {
+			packages = append(packages, &catalogmetadata.Package{
+				Package: cfg.Packages[i],
+			})
+		}
+
 		for i := range cfg.Channels {
 			channels = append(channels, &catalogmetadata.Channel{
 				Channel: cfg.Channels[i],
@@ -63,16 +81,27 @@ func (c *indexRefClient) Bundles(ctx context.Context) ([]*catalogmetadata.Bundle
 			})
 		}
 
+		for i := range cfg.Others {
+			if cfg.Others[i].Schema != catalogmetadata.SchemaDeprecation {
+				continue
+			}
+			var deprecation catalogmetadata.Deprecation
+			if err := json.Unmarshal(cfg.Others[i].Blob, &deprecation); err != nil {
+				return nil, fmt.Errorf("error unmarshalling deprecation from catalog metadata: %s", err)
+			}
+			deprecations = append(deprecations, &deprecation)
+		}
+
 		// TODO: update fake catalog name string to be catalog name once we support multiple catalogs in CLI
 		catalogName := "offline-catalog"
 
-		bundles, err = client.PopulateExtraFields(catalogName, channels, bundles)
+		bundles, err = client.PopulateExtraFields(catalogName, packages, channels, bundles, deprecations)
 		if err != nil {
 			return nil, err
 		}
 
-		c.bundlesCache = bundles
+		c.indexCache = catalogmetadata.NewBundleIndex(bundles)
 	}
 
-	return c.bundlesCache, nil
+	return c.indexCache, nil
 }
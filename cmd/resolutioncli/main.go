@@ -18,10 +18,12 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
 
+	"github.com/operator-framework/deppy/pkg/deppy"
 	"github.com/operator-framework/deppy/pkg/deppy/solver"
 	rukpakv1alpha1 "github.com/operator-framework/rukpak/api/v1alpha1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -29,13 +31,16 @@ import (
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	catalogd "github.com/operator-framework/catalogd/api/core/v1alpha1"
 
 	operatorsv1alpha1 "github.com/operator-framework/operator-controller/api/v1alpha1"
 	"github.com/operator-framework/operator-controller/internal/catalogmetadata"
+	"github.com/operator-framework/operator-controller/internal/clusterversion"
 	"github.com/operator-framework/operator-controller/internal/controllers"
+	"github.com/operator-framework/operator-controller/internal/resolution/explain"
 	olmvariables "github.com/operator-framework/operator-controller/internal/resolution/variables"
 	"github.com/operator-framework/operator-controller/internal/resolution/variablesources"
 )
@@ -50,6 +55,9 @@ const (
 	flagNamePackageChannel = "package-channel"
 	flagNameIndexRef       = "index-ref"
 	flagNameInputDir       = "input-dir"
+
+	flagNameKubernetesVersion = "kubernetes-version"
+	flagNameOpenShiftVersion  = "openshift-version"
 )
 
 var (
@@ -75,12 +83,16 @@ func main() {
 	var packageChannel string
 	var indexRef string
 	var inputDir string
+	var kubernetesVersion string
+	var openShiftVersion string
 	flag.StringVar(&packageName, flagNamePackageName, "", "Name of the package to resolve")
 	flag.StringVar(&packageVersion, flagNamePackageVersion, "", "Version of the package")
 	flag.StringVar(&packageChannel, flagNamePackageChannel, "", "Channel of the package")
 	// TODO: Consider adding support of multiple refs
 	flag.StringVar(&indexRef, flagNameIndexRef, "", "Index reference (FBC image or dir)")
 	flag.StringVar(&inputDir, flagNameInputDir, "", "Directory containing Kubernetes manifests (such as Operator) to be used as an input for resolution")
+	flag.StringVar(&kubernetesVersion, flagNameKubernetesVersion, "", "Kubernetes version of the cluster to resolve for, bundles are not checked against it when empty")
+	flag.StringVar(&openShiftVersion, flagNameOpenShiftVersion, "", "OpenShift version of the cluster to resolve for, bundles are not checked against it when empty")
 	flag.Parse()
 
 	if err := validateFlags(packageName, indexRef); err != nil {
@@ -89,7 +101,14 @@ func main() {
 		os.Exit(1)
 	}
 
-	err := run(ctx, packageName, packageVersion, packageChannel, indexRef, inputDir)
+	clusterVersion, err := clusterversion.Parse(kubernetesVersion, openShiftVersion)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	err = run(ctx, packageName, packageVersion, packageChannel, indexRef, inputDir, clusterVersion)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
@@ -108,7 +127,7 @@ func validateFlags(packageName, indexRef string) error {
 	return nil
 }
 
-func run(ctx context.Context, packageName, packageVersion, packageChannel, indexRef, inputDir string) error {
+func run(ctx context.Context, packageName, packageVersion, packageChannel, indexRef, inputDir string, clusterVersion *clusterversion.Version) error {
 	clientBuilder := fake.NewClientBuilder().WithScheme(scheme)
 
 	if inputDir != "" {
@@ -126,11 +145,11 @@ func run(ctx context.Context, packageName, packageVersion, packageChannel, index
 	resolver := solver.NewDeppySolver(
 		append(
 			variablesources.NestedVariableSource{newPackageVariableSource(catalogClient, packageName, packageVersion, packageChannel)},
-			controllers.NewVariableSource(cl, catalogClient)...,
+			controllers.NewVariableSource(cl, catalogClient, clusterversion.Static{Version: clusterVersion})...,
 		),
 	)
 
-	bundleImage, err := resolve(ctx, resolver, packageName)
+	bundleImage, err := resolve(ctx, cl, resolver, packageName)
 	if err != nil {
 		return err
 	}
@@ -139,12 +158,21 @@ func run(ctx context.Context, packageName, packageVersion, packageChannel, index
 	return nil
 }
 
-func resolve(ctx context.Context, resolver *solver.DeppySolver, packageName string) (string, error) {
-	solution, err := resolver.Solve(ctx)
+func resolve(ctx context.Context, cl client.Client, resolver *solver.DeppySolver, packageName string) (string, error) {
+	solution, err := resolver.Solve(ctx, solver.AddAllVariablesToSolution())
 	if err != nil {
 		return "", err
 	}
 
+	unsat := deppy.NotSatisfiable{}
+	if errors.As(solution.Error(), &unsat) && len(unsat) > 0 {
+		operators := &operatorsv1alpha1.OperatorList{}
+		if err := cl.List(ctx, operators); err != nil {
+			return "", err
+		}
+		return "", explain.NotSatisfiable(unsat, solution.AllVariables(), operators.Items)
+	}
+
 	bundle, err := bundleFromSolution(solution, packageName)
 	if err != nil {
 		return "", err
@@ -2,19 +2,54 @@ package cache
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"net/http"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	catalogd "github.com/operator-framework/catalogd/api/core/v1alpha1"
 
 	"github.com/operator-framework/operator-controller/internal/catalogmetadata/client"
+	"github.com/operator-framework/operator-controller/internal/metrics"
 )
 
 var _ client.Fetcher = &filesystemCache{}
+var _ client.Pruner = &filesystemCache{}
+
+const (
+	dataFileName     = "data.json"
+	metadataFileName = "metadata.json"
+	// tmpSuffix is appended to the names of the files being written,
+	// until they are complete and renamed.
+	tmpSuffix = ".tmp"
+	// catalogsDirName is the directory of the cache path the contents are
+	// cached in, as the cache path may be shared with other programs.
+	catalogsDirName = "catalogs"
+)
+
+// Option configures the filesystem cache.
+type Option func(*filesystemCache)
+
+// WithMaxSize limits the total size in bytes of the cached catalog contents.
+// When the limit is exceeded, the contents of the least recently used catalogs
+// are evicted first. A size of 0 or less means no limit.
+func WithMaxSize(maxSize int64) Option {
+	return func(fsc *filesystemCache) {
+		fsc.maxSize = maxSize
+	}
+}
 
 // NewFilesystemCache returns a client.Fetcher implementation that uses a
 // local filesystem to cache Catalog contents. When fetching the Catalog contents
@@ -24,21 +59,44 @@ var _ client.Fetcher = &filesystemCache{}
 //   - IF cached it will verify the cache is up to date. If it is up to date it will return
 //     the cached contents, if not it will fetch the new contents from the catalogd HTTP
 //     server and update the cached contents.
-func NewFilesystemCache(cachePath string, client *http.Client) client.Fetcher {
-	return &filesystemCache{
-		cachePath:              cachePath,
-		mutex:                  sync.RWMutex{},
+//
+// The contents are cached in the catalogs directory of cachePath. The contents
+// cached there by a previous run are reused, unless they do not match the digest
+// recorded along with them, in which case they are removed.
+func NewFilesystemCache(cachePath string, client *http.Client, options ...Option) (client.Fetcher, error) {
+	fsc := &filesystemCache{
+		cachePath:              filepath.Join(cachePath, catalogsDirName),
 		client:                 client,
 		cacheDataByCatalogName: map[string]cacheData{},
 	}
+	for _, option := range options {
+		option(fsc)
+	}
+	if err := fsc.load(); err != nil {
+		return nil, err
+	}
+	return fsc, nil
 }
 
 // cacheData holds information about a catalog
 // other than it's contents that is used for
 // making decisions on when to attempt to refresh
-// the cache.
+// the cache. It is persisted next to the contents
+// so that they survive restarts.
 type cacheData struct {
-	ResolvedRef string
+	ResolvedRef string `json:"resolvedRef"`
+	// Digest is the sha256 digest of the cached contents, computed as they are
+	// downloaded. catalogd does not publish the digest of the contents it serves,
+	// so it cannot tell whether they were downloaded correctly, only whether the
+	// cached contents were altered since, which is checked when they are loaded.
+	Digest string `json:"digest"`
+	// ETag and LastModified are the validators of the cached contents
+	// returned by the catalogd HTTP server, if any.
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
+
+	size     int64
+	lastUsed time.Time
 }
 
 // FilesystemCache is a cache that
@@ -47,9 +105,10 @@ type cacheData struct {
 // contents if the catalog does not already
 // exist in the cache.
 type filesystemCache struct {
-	mutex                  sync.RWMutex
+	mutex                  sync.Mutex
 	cachePath              string
 	client                 *http.Client
+	maxSize                int64
 	cacheDataByCatalogName map[string]cacheData
 }
 
@@ -79,33 +138,53 @@ func (fsc *filesystemCache) FetchCatalogContents(ctx context.Context, catalog *c
 		return nil, fmt.Errorf("error: catalog %q has a nil status.resolvedSource.image value", catalog.Name)
 	}
 
-	cacheDir := filepath.Join(fsc.cachePath, catalog.Name)
-	cacheFilePath := filepath.Join(cacheDir, "data.json")
-
-	fsc.mutex.RLock()
-	if data, ok := fsc.cacheDataByCatalogName[catalog.Name]; ok {
-		if catalog.Status.ResolvedSource.Image.Ref == data.ResolvedRef {
-			fsc.mutex.RUnlock()
-			return os.Open(cacheFilePath)
-		}
+	if file := fsc.open(catalog); file != nil {
+		metrics.CatalogCacheHits.WithLabelValues(catalog.Name).Inc()
+		return file, nil
 	}
-	fsc.mutex.RUnlock()
+	metrics.CatalogCacheMisses.WithLabelValues(catalog.Name).Inc()
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, catalog.Status.ContentURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("error forming request: %s", err)
 	}
 
+	// the catalog may have unpacked the same contents again, in which case
+	// the contents cached for its previous reference can be kept.
+	fsc.mutex.Lock()
+	previous, cached := fsc.cacheDataByCatalogName[catalog.Name]
+	fsc.mutex.Unlock()
+	if cached {
+		if previous.ETag != "" {
+			req.Header.Set("If-None-Match", previous.ETag)
+		}
+		if previous.LastModified != "" {
+			req.Header.Set("If-Modified-Since", previous.LastModified)
+		}
+	}
+
 	resp, err := fsc.client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("error performing request: %s", err)
 	}
 	defer resp.Body.Close()
 
+	if cached && resp.StatusCode == http.StatusNotModified {
+		return fsc.revalidate(ctx, catalog, previous)
+	}
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("error: received unexpected response status code %d", resp.StatusCode)
 	}
 
+	// the contents are downloaded without holding the lock, and only
+	// moved into place once complete, so that an interrupted download
+	// never leaves truncated contents behind.
+	data, tmpPath, err := fsc.download(catalog, resp)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmpPath)
+
 	fsc.mutex.Lock()
 	defer fsc.mutex.Unlock()
 
@@ -117,34 +196,286 @@ func (fsc *filesystemCache) FetchCatalogContents(ctx context.Context, catalog *c
 	// the cached contents
 	if data, ok := fsc.cacheDataByCatalogName[catalog.Name]; ok {
 		if data.ResolvedRef == catalog.Status.ResolvedSource.Image.Ref {
-			return os.Open(cacheFilePath)
+			data.lastUsed = time.Now()
+			fsc.cacheDataByCatalogName[catalog.Name] = data
+			return os.Open(filepath.Join(fsc.cachePath, catalog.Name, dataFileName))
 		}
 	}
 
-	if err = os.MkdirAll(cacheDir, os.ModePerm); err != nil {
+	// the cache directory of the catalog is created with the lock held,
+	// as it may have been pruned or evicted during the download.
+	cacheDir := filepath.Join(fsc.cachePath, catalog.Name)
+	if err := os.MkdirAll(cacheDir, os.ModePerm); err != nil {
 		return nil, fmt.Errorf("error creating cache directory for Catalog %q: %s", catalog.Name, err)
 	}
+	if err := os.Rename(tmpPath, filepath.Join(cacheDir, dataFileName)); err != nil {
+		return nil, fmt.Errorf("error moving contents to cache file for Catalog %q: %s", catalog.Name, err)
+	}
+	if err := fsc.store(catalog.Name, *data); err != nil {
+		return nil, err
+	}
+	fsc.evict(ctx, catalog.Name)
+
+	// every caller reads the contents through its own file
+	return os.Open(filepath.Join(fsc.cachePath, catalog.Name, dataFileName))
+}
 
-	file, err := os.Create(cacheFilePath)
+// download writes the contents of the response to a temporary file at the root of
+// the cache directory, out of the reach of Prune and evict, and checks that they
+// were received in full, as far as the Content-Length of the response tells.
+// It returns the metadata of the contents along with the path of the file,
+// which the caller is in charge of, unless an error is returned.
+func (fsc *filesystemCache) download(catalog *catalogd.Catalog, resp *http.Response) (_ *cacheData, _ string, err error) {
+	if err := os.MkdirAll(fsc.cachePath, os.ModePerm); err != nil {
+		return nil, "", fmt.Errorf("error creating cache directory: %s", err)
+	}
+
+	file, err := os.CreateTemp(fsc.cachePath, catalog.Name+"-"+dataFileName+tmpSuffix+"*")
 	if err != nil {
-		return nil, fmt.Errorf("error creating cache file for Catalog %q: %s", catalog.Name, err)
+		return nil, "", fmt.Errorf("error creating cache file for Catalog %q: %s", catalog.Name, err)
 	}
+	defer file.Close()
+	tmpPath := file.Name()
+	defer func() {
+		if err != nil {
+			os.Remove(tmpPath)
+		}
+	}()
 
-	if _, err := io.Copy(file, resp.Body); err != nil {
-		return nil, fmt.Errorf("error writing contents to cache file for Catalog %q: %s", catalog.Name, err)
+	hash := sha256.New()
+	written, err := io.Copy(io.MultiWriter(file, hash), resp.Body)
+	metrics.CatalogDownloadBytes.WithLabelValues(catalog.Name).Add(float64(written))
+	if err != nil {
+		return nil, "", fmt.Errorf("error writing contents to cache file for Catalog %q: %s", catalog.Name, err)
+	}
+	if resp.ContentLength >= 0 && written != resp.ContentLength {
+		return nil, "", fmt.Errorf("error writing contents to cache file for Catalog %q: received %d bytes out of %d", catalog.Name, written, resp.ContentLength)
 	}
 
 	if err = file.Sync(); err != nil {
-		return nil, fmt.Errorf("error syncing contents to cache file for Catalog %q: %s", catalog.Name, err)
+		return nil, "", fmt.Errorf("error syncing contents to cache file for Catalog %q: %s", catalog.Name, err)
+	}
+
+	return &cacheData{
+		ResolvedRef:  catalog.Status.ResolvedSource.Image.Ref,
+		Digest:       fmt.Sprintf("sha256:%x", hash.Sum(nil)),
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		size:         written,
+		lastUsed:     time.Now(),
+	}, tmpPath, nil
+}
+
+// revalidate keeps the cached contents of a catalog whose reference changed,
+// the catalogd HTTP server having reported that the contents did not change.
+func (fsc *filesystemCache) revalidate(ctx context.Context, catalog *catalogd.Catalog, previous cacheData) (io.ReadCloser, error) {
+	fsc.mutex.Lock()
+	defer fsc.mutex.Unlock()
+
+	data, ok := fsc.cacheDataByCatalogName[catalog.Name]
+	if !ok || data.Digest != previous.Digest {
+		return nil, fmt.Errorf("error: cached contents of Catalog %q changed while revalidating them", catalog.Name)
+	}
+	data.ResolvedRef = catalog.Status.ResolvedSource.Image.Ref
+	data.lastUsed = time.Now()
+	if err := fsc.store(catalog.Name, data); err != nil {
+		return nil, err
+	}
+	fsc.evict(ctx, catalog.Name)
+
+	return os.Open(filepath.Join(fsc.cachePath, catalog.Name, dataFileName))
+}
+
+// store records the metadata of the cached contents of a catalog,
+// both in memory and next to the contents.
+// It must be called with the lock held.
+func (fsc *filesystemCache) store(catalogName string, data cacheData) error {
+	metadata, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("error marshalling cache metadata for Catalog %q: %s", catalogName, err)
+	}
+	if err := writeFile(filepath.Join(fsc.cachePath, catalogName, metadataFileName), metadata); err != nil {
+		return fmt.Errorf("error writing cache metadata for Catalog %q: %s", catalogName, err)
+	}
+	fsc.cacheDataByCatalogName[catalogName] = data
+	return nil
+}
+
+// writeFile writes data to a temporary file, which is then renamed
+// to path so that path never holds partial contents.
+func writeFile(path string, data []byte) error {
+	file, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+tmpSuffix+"*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), path)
+}
+
+// open returns the cached contents of the catalog if they are
+// up to date, or nil if they need to be fetched.
+func (fsc *filesystemCache) open(catalog *catalogd.Catalog) *os.File {
+	fsc.mutex.Lock()
+	defer fsc.mutex.Unlock()
+
+	data, ok := fsc.cacheDataByCatalogName[catalog.Name]
+	if !ok || data.ResolvedRef != catalog.Status.ResolvedSource.Image.Ref {
+		return nil
+	}
+	file, err := os.Open(filepath.Join(fsc.cachePath, catalog.Name, dataFileName))
+	if err != nil {
+		// the contents went missing, fetch them again
+		delete(fsc.cacheDataByCatalogName, catalog.Name)
+		return nil
+	}
+	data.lastUsed = time.Now()
+	fsc.cacheDataByCatalogName[catalog.Name] = data
+	return file
+}
+
+// Prune implements the client.Pruner interface and removes the
+// cached contents of the catalogs other than the given ones.
+func (fsc *filesystemCache) Prune(ctx context.Context, catalogNames sets.Set[string]) {
+	fsc.mutex.Lock()
+	defer fsc.mutex.Unlock()
+
+	for catalogName := range fsc.cacheDataByCatalogName {
+		if !catalogNames.Has(catalogName) {
+			fsc.remove(ctx, catalogName)
+		}
+	}
+}
+
+// evict removes the cached contents of the least recently used catalogs
+// until the cache fits in its maximum size. The contents of the catalog
+// to keep, which were just fetched, are never evicted.
+// It must be called with the lock held.
+func (fsc *filesystemCache) evict(ctx context.Context, keep string) {
+	if fsc.maxSize <= 0 {
+		return
+	}
+
+	var size int64
+	catalogNames := make([]string, 0, len(fsc.cacheDataByCatalogName))
+	for catalogName, data := range fsc.cacheDataByCatalogName {
+		size += data.size
+		catalogNames = append(catalogNames, catalogName)
+	}
+	sort.Slice(catalogNames, func(i, j int) bool {
+		return fsc.cacheDataByCatalogName[catalogNames[i]].lastUsed.Before(fsc.cacheDataByCatalogName[catalogNames[j]].lastUsed)
+	})
+	for _, catalogName := range catalogNames {
+		if size <= fsc.maxSize {
+			return
+		}
+		if catalogName == keep {
+			continue
+		}
+		size -= fsc.cacheDataByCatalogName[catalogName].size
+		fsc.remove(ctx, catalogName)
 	}
+}
 
-	if _, err = file.Seek(0, 0); err != nil {
-		return nil, fmt.Errorf("error resetting offset for cache file reader for Catalog %q: %s", catalog.Name, err)
+// remove removes the cached contents of a catalog.
+// It must be called with the lock held.
+func (fsc *filesystemCache) remove(ctx context.Context, catalogName string) {
+	delete(fsc.cacheDataByCatalogName, catalogName)
+	// readers which already opened the contents can still read them
+	if err := os.RemoveAll(filepath.Join(fsc.cachePath, catalogName)); err != nil {
+		log.FromContext(ctx).Error(err, "removing cached catalog contents", "catalog", catalogName)
 	}
+}
 
-	fsc.cacheDataByCatalogName[catalog.Name] = cacheData{
-		ResolvedRef: catalog.Status.ResolvedSource.Image.Ref,
+// load reads the metadata of the contents cached by a previous run.
+// Cached contents which do not match their digest cannot be trusted
+// and are removed. Only the files named as the cache names them, and
+// the directories holding cache metadata, are ever removed.
+func (fsc *filesystemCache) load() error {
+	entries, err := os.ReadDir(fsc.cachePath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error reading cache directory %q: %s", fsc.cachePath, err)
 	}
 
-	return file, nil
+	for _, entry := range entries {
+		// downloads left behind by interrupted runs
+		if !entry.IsDir() {
+			if strings.Contains(entry.Name(), "-"+dataFileName+tmpSuffix) {
+				if err := os.Remove(filepath.Join(fsc.cachePath, entry.Name())); err != nil {
+					return fmt.Errorf("error removing incomplete download %q: %s", entry.Name(), err)
+				}
+			}
+			continue
+		}
+		// leave alone anything that was not cached by this cache
+		cacheDir := filepath.Join(fsc.cachePath, entry.Name())
+		if _, err := os.Stat(filepath.Join(cacheDir, metadataFileName)); err != nil {
+			continue
+		}
+		// metadata left behind by interrupted writes
+		tmpPaths, err := filepath.Glob(filepath.Join(cacheDir, metadataFileName+tmpSuffix+"*"))
+		if err != nil {
+			return err
+		}
+		for _, tmpPath := range tmpPaths {
+			if err := os.Remove(tmpPath); err != nil {
+				return fmt.Errorf("error removing incomplete cache file %q: %s", tmpPath, err)
+			}
+		}
+		data, err := loadCacheData(cacheDir)
+		if err != nil {
+			if err := os.RemoveAll(cacheDir); err != nil {
+				return fmt.Errorf("error removing invalid cache directory %q: %s", cacheDir, err)
+			}
+			continue
+		}
+		fsc.cacheDataByCatalogName[entry.Name()] = *data
+	}
+	return nil
+}
+
+func loadCacheData(cacheDir string) (*cacheData, error) {
+	metadataPath := filepath.Join(cacheDir, metadataFileName)
+	metadata, err := os.ReadFile(metadataPath)
+	if err != nil {
+		return nil, err
+	}
+	var data cacheData
+	if err := json.Unmarshal(metadata, &data); err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(filepath.Join(cacheDir, dataFileName))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	hash := sha256.New()
+	if data.size, err = io.Copy(hash, file); err != nil {
+		return nil, err
+	}
+	if digest := fmt.Sprintf("sha256:%x", hash.Sum(nil)); digest != data.Digest {
+		return nil, fmt.Errorf("digest %q does not match the recorded digest %q", digest, data.Digest)
+	}
+
+	// the contents were last used when they were last fetched, as far as we know
+	info, err := os.Stat(metadataPath)
+	if err != nil {
+		return nil, err
+	}
+	data.lastUsed = info.ModTime()
+	return &data, nil
 }
@@ -12,11 +12,14 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
 
 	catalogd "github.com/operator-framework/catalogd/api/core/v1alpha1"
 
 	"github.com/operator-framework/operator-controller/internal/catalogmetadata/cache"
+	"github.com/operator-framework/operator-controller/internal/catalogmetadata/client"
 )
 
 const (
@@ -202,12 +205,13 @@ func TestCache(t *testing.T) {
 				tt.tripper.content = tt.contents
 				httpClient := http.DefaultClient
 				httpClient.Transport = tt.tripper
-				c := cache.NewFilesystemCache(cacheDir, httpClient)
+				c, err := cache.NewFilesystemCache(cacheDir, httpClient)
+				require.NoError(t, err)
 
 				rc, err := c.FetchCatalogContents(ctx, tt.catalog)
 				if !tt.wantErr {
 					assert.NoError(t, err)
-					filePath := filepath.Join(cacheDir, tt.catalog.Name, "data.json")
+					filePath := filepath.Join(cacheDir, "catalogs", tt.catalog.Name, "data.json")
 					assert.FileExists(t, filePath)
 					fileContents, err := os.ReadFile(filePath)
 					assert.NoError(t, err)
@@ -243,15 +247,135 @@ func TestCache(t *testing.T) {
 	})
 }
 
+func TestCacheInterruptedDownload(t *testing.T) {
+	cacheDir := t.TempDir()
+	contents := []byte(strings.Join([]string{package1, bundle1, stableChannel}, "\n"))
+	tripper := &MockTripper{content: contents}
+	c, err := cache.NewFilesystemCache(cacheDir, &http.Client{Transport: tripper})
+	require.NoError(t, err)
+
+	catalog := newTestCatalog("test-catalog", "fake/catalog@sha256:fakesha")
+	fetch(t, c, catalog)
+
+	tripper.truncated = true
+	tripper.content = append(tripper.content, []byte(`{"schema": "olm.package", "name": "foobar"}`)...)
+	catalog.Status.ResolvedSource.Image.Ref = "fake/catalog@sha256:shafake"
+	_, err = c.FetchCatalogContents(context.Background(), catalog)
+	assert.ErrorContains(t, err, "received 220 bytes out of 440")
+
+	// the previous contents are left untouched, without partial files
+	cacheFiles, err := filepath.Glob(filepath.Join(cacheDir, "catalogs", "*", "*"))
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{
+		filepath.Join(cacheDir, "catalogs", "test-catalog", "data.json"),
+		filepath.Join(cacheDir, "catalogs", "test-catalog", "metadata.json"),
+	}, cacheFiles)
+	cacheFiles, err = filepath.Glob(filepath.Join(cacheDir, "catalogs", "*"+".tmp*"))
+	require.NoError(t, err)
+	assert.Empty(t, cacheFiles)
+	fileContents, err := os.ReadFile(filepath.Join(cacheDir, "catalogs", "test-catalog", "data.json"))
+	require.NoError(t, err)
+	assert.Equal(t, contents, fileContents)
+
+	t.Run("partial files are removed on restart", func(t *testing.T) {
+		partialPath := filepath.Join(cacheDir, "catalogs", "test-catalog", "metadata.json.tmp1234")
+		require.NoError(t, os.WriteFile(partialPath, []byte("partial"), 0600))
+		partialDownloadPath := filepath.Join(cacheDir, "catalogs", "test-catalog-data.json.tmp1234")
+		require.NoError(t, os.WriteFile(partialDownloadPath, []byte("partial"), 0600))
+
+		_, err := cache.NewFilesystemCache(cacheDir, &http.Client{Transport: tripper})
+		require.NoError(t, err)
+		assert.NoFileExists(t, partialPath)
+		assert.NoFileExists(t, partialDownloadPath)
+		assert.FileExists(t, filepath.Join(cacheDir, "catalogs", "test-catalog", "data.json"))
+	})
+}
+
+func TestCacheReadHandles(t *testing.T) {
+	contents := []byte(strings.Join([]string{package1, bundle1, stableChannel}, "\n"))
+	c, err := cache.NewFilesystemCache(t.TempDir(), &http.Client{Transport: &MockTripper{content: contents}})
+	require.NoError(t, err)
+	catalog := newTestCatalog("test-catalog", "fake/catalog@sha256:fakesha")
+
+	rc1, err := c.FetchCatalogContents(context.Background(), catalog)
+	require.NoError(t, err)
+	rc2, err := c.FetchCatalogContents(context.Background(), catalog)
+	require.NoError(t, err)
+	defer rc2.Close()
+
+	// reading and closing one handle does not affect the other
+	data, err := io.ReadAll(rc1)
+	require.NoError(t, err)
+	assert.Equal(t, contents, data)
+	require.NoError(t, rc1.Close())
+	data, err = io.ReadAll(rc2)
+	require.NoError(t, err)
+	assert.Equal(t, contents, data)
+}
+
+func TestCacheConditionalRequests(t *testing.T) {
+	cacheDir := t.TempDir()
+	contents := []byte(strings.Join([]string{package1, bundle1, stableChannel}, "\n"))
+	tripper := &MockTripper{content: contents, etag: `"v1"`}
+	c, err := cache.NewFilesystemCache(cacheDir, &http.Client{Transport: tripper})
+	require.NoError(t, err)
+
+	catalog := newTestCatalog("test-catalog", "fake/catalog@sha256:fakesha")
+	assert.Equal(t, contents, fetch(t, c, catalog))
+
+	// the catalog unpacked the same contents under a new reference
+	catalog.Status.ResolvedSource.Image.Ref = "fake/catalog@sha256:shafake"
+	tripper.content = []byte("not expected to be downloaded")
+	assert.Equal(t, contents, fetch(t, c, catalog))
+	assert.Equal(t, 2, tripper.requests)
+
+	t.Run("the new reference survives restarts", func(t *testing.T) {
+		restarted, err := cache.NewFilesystemCache(cacheDir, &http.Client{Transport: tripper})
+		require.NoError(t, err)
+		assert.Equal(t, contents, fetch(t, restarted, catalog))
+		assert.Equal(t, 2, tripper.requests)
+	})
+
+	t.Run("changed contents are downloaded", func(t *testing.T) {
+		catalog.Status.ResolvedSource.Image.Ref = "fake/catalog@sha256:newsha"
+		tripper.etag = `"v2"`
+		assert.Equal(t, tripper.content, fetch(t, c, catalog))
+		assert.Equal(t, 3, tripper.requests)
+	})
+}
+
 var _ http.RoundTripper = &MockTripper{}
 
 type MockTripper struct {
 	content     []byte
 	shouldError bool
 	serverError bool
+	// etag is returned along with the content, which is not
+	// returned again to the requests matching it.
+	etag string
+	// truncated drops the second half of the content,
+	// as if the connection was interrupted.
+	truncated bool
+	// onRead is called when the content starts being read.
+	onRead   func()
+	requests int
+}
+
+type onReadReader struct {
+	io.Reader
+	onRead func()
 }
 
-func (mt *MockTripper) RoundTrip(_ *http.Request) (*http.Response, error) {
+func (r *onReadReader) Read(p []byte) (int, error) {
+	if r.onRead != nil {
+		r.onRead()
+		r.onRead = nil
+	}
+	return r.Reader.Read(p)
+}
+
+func (mt *MockTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	mt.requests++
 	if mt.shouldError {
 		return nil, errors.New("mock tripper error")
 	}
@@ -263,8 +387,179 @@ func (mt *MockTripper) RoundTrip(_ *http.Request) (*http.Response, error) {
 		}, nil
 	}
 
+	if mt.etag != "" && req.Header.Get("If-None-Match") == mt.etag {
+		return &http.Response{
+			StatusCode: http.StatusNotModified,
+			Body:       http.NoBody,
+		}, nil
+	}
+
+	body := mt.content
+	if mt.truncated {
+		body = body[:len(body)/2]
+	}
+	header := http.Header{}
+	if mt.etag != "" {
+		header.Set("ETag", mt.etag)
+	}
 	return &http.Response{
-		StatusCode: http.StatusOK,
-		Body:       io.NopCloser(bytes.NewReader(mt.content)),
+		StatusCode:    http.StatusOK,
+		Header:        header,
+		Body:          io.NopCloser(&onReadReader{Reader: bytes.NewReader(body), onRead: mt.onRead}),
+		ContentLength: int64(len(mt.content)),
 	}, nil
 }
+
+func newTestCatalog(name, ref string) *catalogd.Catalog {
+	return &catalogd.Catalog{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status: catalogd.CatalogStatus{
+			ResolvedSource: &catalogd.CatalogSource{
+				Type:  catalogd.SourceTypeImage,
+				Image: &catalogd.ImageSource{Ref: ref},
+			},
+		},
+	}
+}
+
+func fetch(t *testing.T, c client.Fetcher, catalog *catalogd.Catalog) []byte {
+	t.Helper()
+	rc, err := c.FetchCatalogContents(context.Background(), catalog)
+	require.NoError(t, err)
+	defer rc.Close()
+	data, err := io.ReadAll(rc)
+	require.NoError(t, err)
+	return data
+}
+
+func TestCachePersistence(t *testing.T) {
+	cacheDir := t.TempDir()
+	contents := []byte(strings.Join([]string{package1, bundle1, stableChannel}, "\n"))
+	tripper := &MockTripper{content: contents}
+	httpClient := &http.Client{Transport: tripper}
+	catalog := newTestCatalog("test-catalog", "fake/catalog@sha256:fakesha")
+
+	c, err := cache.NewFilesystemCache(cacheDir, httpClient)
+	require.NoError(t, err)
+	assert.Equal(t, contents, fetch(t, c, catalog))
+	assert.FileExists(t, filepath.Join(cacheDir, "catalogs", "test-catalog", "metadata.json"))
+
+	t.Run("contents are reused after a restart", func(t *testing.T) {
+		tripper.shouldError = true
+		defer func() { tripper.shouldError = false }()
+
+		restarted, err := cache.NewFilesystemCache(cacheDir, httpClient)
+		require.NoError(t, err)
+		assert.Equal(t, contents, fetch(t, restarted, catalog))
+	})
+
+	t.Run("contents which do not match their digest are removed on restart", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(filepath.Join(cacheDir, "catalogs", "test-catalog", "data.json"), []byte("corrupted"), 0600))
+
+		restarted, err := cache.NewFilesystemCache(cacheDir, httpClient)
+		require.NoError(t, err)
+		assert.NoDirExists(t, filepath.Join(cacheDir, "catalogs", "test-catalog"))
+		assert.Equal(t, contents, fetch(t, restarted, catalog))
+	})
+
+	t.Run("contents without metadata are not reused on restart", func(t *testing.T) {
+		require.NoError(t, os.Remove(filepath.Join(cacheDir, "catalogs", "test-catalog", "metadata.json")))
+		requests := tripper.requests
+
+		restarted, err := cache.NewFilesystemCache(cacheDir, httpClient)
+		require.NoError(t, err)
+		assert.Equal(t, contents, fetch(t, restarted, catalog))
+		assert.Equal(t, requests+1, tripper.requests)
+	})
+
+	t.Run("files which were not created by the cache are left alone", func(t *testing.T) {
+		notCached := []string{
+			filepath.Join(cacheDir, "data.json.tmp1234"),
+			filepath.Join(cacheDir, "other", "data.json"),
+			filepath.Join(cacheDir, "catalogs", "other.tmp1234"),
+			filepath.Join(cacheDir, "catalogs", "not-a-catalog", "data.json.tmp1234"),
+		}
+		for _, path := range notCached {
+			require.NoError(t, os.MkdirAll(filepath.Dir(path), 0700))
+			require.NoError(t, os.WriteFile(path, []byte("not cached"), 0600))
+		}
+
+		_, err := cache.NewFilesystemCache(cacheDir, httpClient)
+		require.NoError(t, err)
+		for _, path := range notCached {
+			assert.FileExists(t, path)
+		}
+	})
+}
+
+func TestCachePrune(t *testing.T) {
+	cacheDir := t.TempDir()
+	httpClient := &http.Client{Transport: &MockTripper{content: []byte(package1)}}
+	c, err := cache.NewFilesystemCache(cacheDir, httpClient)
+	require.NoError(t, err)
+
+	fetch(t, c, newTestCatalog("catalog-1", "fake/catalog-1@sha256:fakesha"))
+	fetch(t, c, newTestCatalog("catalog-2", "fake/catalog-2@sha256:fakesha"))
+
+	pruner, ok := c.(client.Pruner)
+	require.True(t, ok)
+	pruner.Prune(context.Background(), sets.New("catalog-2"))
+	assert.NoDirExists(t, filepath.Join(cacheDir, "catalogs", "catalog-1"))
+	assert.DirExists(t, filepath.Join(cacheDir, "catalogs", "catalog-2"))
+}
+
+func TestCachePruneDuringDownload(t *testing.T) {
+	cacheDir := t.TempDir()
+	tripper := &MockTripper{content: []byte(package1)}
+	c, err := cache.NewFilesystemCache(cacheDir, &http.Client{Transport: tripper})
+	require.NoError(t, err)
+	pruner, ok := c.(client.Pruner)
+	require.True(t, ok)
+
+	catalog := newTestCatalog("test-catalog", "fake/catalog@sha256:fakesha")
+	fetch(t, c, catalog)
+
+	// the catalog goes away and comes back while its new contents are downloaded
+	tripper.content = []byte(strings.Join([]string{package1, bundle1}, "\n"))
+	tripper.onRead = func() {
+		pruner.Prune(context.Background(), sets.New[string]())
+	}
+	catalog.Status.ResolvedSource.Image.Ref = "fake/catalog@sha256:shafake"
+	assert.Equal(t, tripper.content, fetch(t, c, catalog))
+
+	fileContents, err := os.ReadFile(filepath.Join(cacheDir, "catalogs", "test-catalog", "data.json"))
+	require.NoError(t, err)
+	assert.Equal(t, tripper.content, fileContents)
+}
+
+func TestCacheMaxSize(t *testing.T) {
+	cacheDir := t.TempDir()
+	tripper := &MockTripper{content: []byte(package1)}
+	httpClient := &http.Client{Transport: tripper}
+	// room for the contents of two catalogs
+	c, err := cache.NewFilesystemCache(cacheDir, httpClient, cache.WithMaxSize(int64(2*len(package1))))
+	require.NoError(t, err)
+
+	catalog1 := newTestCatalog("catalog-1", "fake/catalog-1@sha256:fakesha")
+	catalog2 := newTestCatalog("catalog-2", "fake/catalog-2@sha256:fakesha")
+	catalog3 := newTestCatalog("catalog-3", "fake/catalog-3@sha256:fakesha")
+	fetch(t, c, catalog1)
+	fetch(t, c, catalog2)
+	// catalog-1 is now used more recently than catalog-2
+	fetch(t, c, catalog1)
+	fetch(t, c, catalog3)
+
+	assert.DirExists(t, filepath.Join(cacheDir, "catalogs", "catalog-1"))
+	assert.NoDirExists(t, filepath.Join(cacheDir, "catalogs", "catalog-2"))
+	assert.DirExists(t, filepath.Join(cacheDir, "catalogs", "catalog-3"))
+
+	t.Run("contents larger than the maximum size evict every other contents", func(t *testing.T) {
+		tripper.content = []byte(strings.Join([]string{package1, bundle1, stableChannel}, "\n"))
+		catalog4 := newTestCatalog("catalog-4", "fake/catalog-4@sha256:fakesha")
+		assert.Equal(t, tripper.content, fetch(t, c, catalog4))
+
+		assert.NoDirExists(t, filepath.Join(cacheDir, "catalogs", "catalog-1"))
+		assert.NoDirExists(t, filepath.Join(cacheDir, "catalogs", "catalog-3"))
+		assert.DirExists(t, filepath.Join(cacheDir, "catalogs", "catalog-4"))
+	})
+}
@@ -5,9 +5,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"strconv"
+	"sync"
+	"time"
 
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	catalogd "github.com/operator-framework/catalogd/api/core/v1alpha1"
@@ -16,6 +21,16 @@ import (
 	"github.com/operator-framework/operator-controller/internal/catalogmetadata"
 )
 
+// CatalogPriorityAnnotation is the annotation used to set the priority of
+// a Catalog. When the same bundle version is available from several catalogs,
+// the one from the catalog with the highest priority is preferred.
+// Catalogs without the annotation have a priority of 0, and catalogs with
+// an invalid priority are reported as unavailable.
+//
+// The priority is an annotation rather than a field of the Catalog spec
+// because the Catalog API is owned by catalogd, which does not use it.
+const CatalogPriorityAnnotation = "operators.operatorframework.io/priority"
+
 // Fetcher is an interface to facilitate fetching
 // catalog contents from catalogd.
 type Fetcher interface {
@@ -26,13 +41,48 @@ type Fetcher interface {
 	FetchCatalogContents(ctx context.Context, catalog *catalogd.Catalog) (io.ReadCloser, error)
 }
 
-func New(cl client.Client, fetcher Fetcher) *Client {
-	return &Client{
-		cl:      cl,
-		fetcher: fetcher,
+// Pruner can be implemented by the Fetchers which keep catalog contents
+// around, to drop the contents of the catalogs which were deleted.
+type Pruner interface {
+	// Prune drops the contents of the catalogs other than the given ones.
+	Prune(ctx context.Context, catalogNames sets.Set[string])
+}
+
+const (
+	// defaultUnavailableCatalogBackoff is how long to wait before fetching the contents
+	// of an unavailable catalog again, doubled after each failure.
+	defaultUnavailableCatalogBackoff = 10 * time.Second
+	// defaultMaxUnavailableCatalogBackoff caps defaultUnavailableCatalogBackoff.
+	defaultMaxUnavailableCatalogBackoff = 5 * time.Minute
+)
+
+// Option configures the Client.
+type Option func(*Client)
+
+// WithUnavailableCatalogBackoff sets how long to wait before fetching the contents
+// of an unavailable catalog again. The wait doubles after each failure, up to max.
+func WithUnavailableCatalogBackoff(initial, max time.Duration) Option {
+	return func(c *Client) {
+		c.unavailableBackoff, c.maxUnavailableBackoff = initial, max
 	}
 }
 
+func New(cl client.Client, fetcher Fetcher, options ...Option) *Client {
+	c := &Client{
+		cl:                    cl,
+		fetcher:               fetcher,
+		unavailableBackoff:    defaultUnavailableCatalogBackoff,
+		maxUnavailableBackoff: defaultMaxUnavailableCatalogBackoff,
+		catalogs:              map[string]*catalogContent{},
+		failures:              map[string]*fetchFailure{},
+		fetching:              map[contentKey]*fetchCall{},
+	}
+	for _, option := range options {
+		option(c)
+	}
+	return c
+}
+
 // Client is reading catalog metadata
 type Client struct {
 	// Note that eventually we will be reading from catalogd http API
@@ -41,75 +91,445 @@ type Client struct {
 
 	// fetcher is the Fetcher to use for fetching catalog contents
 	fetcher Fetcher
+
+	unavailableBackoff    time.Duration
+	maxUnavailableBackoff time.Duration
+
+	// mu guards the fields below. It is never held while fetching catalog contents.
+	mu sync.Mutex
+	// catalogs holds the parsed contents of the catalogs, by catalog name
+	catalogs map[string]*catalogContent
+	// failures holds the last failed fetch of the unavailable catalogs, by catalog name
+	failures map[string]*fetchFailure
+	// fetching holds the fetches in progress, shared by concurrent callers
+	fetching map[contentKey]*fetchCall
+	index    *catalogmetadata.BundleIndex
+}
+
+// contentKey identifies the parsed content of a catalog.
+type contentKey struct {
+	catalogName string
+	resolvedRef string
+	priority    int32
+	labels      string
+}
+
+func newContentKey(catalog *catalogd.Catalog, priority int32) contentKey {
+	return contentKey{
+		catalogName: catalog.Name,
+		resolvedRef: resolvedRef(catalog),
+		priority:    priority,
+		labels:      labels.Set(catalog.Labels).String(),
+	}
 }
 
+// fetchCall is a fetch of catalog contents in progress.
+type fetchCall struct {
+	done    chan struct{}
+	content *catalogContent
+	err     error
+	// canceled is set when the context of the caller fetching the contents was canceled
+	canceled bool
+}
+
+// fetchFailure is a failed fetch of the contents of a catalog. The contents are not
+// fetched again before retryAt, unless the catalog unpacks new contents.
+type fetchFailure struct {
+	resolvedRef string
+	err         error
+	backoff     time.Duration
+	retryAt     time.Time
+}
+
+// catalogContent is the parsed content of a catalog. It stays valid until the
+// catalog unpacks new content, or until the catalog metadata recorded on its
+// bundles changes.
+type catalogContent struct {
+	resolvedRef string
+	labels      map[string]string
+	priority    int32
+	packages    []*catalogmetadata.Package
+	channels    []*catalogmetadata.Channel
+	bundles     []*catalogmetadata.Bundle
+}
+
+func (c *catalogContent) isValidFor(catalog *catalogd.Catalog, priority int32) bool {
+	return c.resolvedRef != "" && c.resolvedRef == resolvedRef(catalog) &&
+		c.priority == priority && labels.Equals(c.labels, catalog.Labels)
+}
+
+// Bundles returns the bundles of every unpacked catalog.
+// Unlike BundleIndex, it fails when the content of a catalog cannot be fetched.
 func (c *Client) Bundles(ctx context.Context) ([]*catalogmetadata.Bundle, error) {
-	var allBundles []*catalogmetadata.Bundle
+	index, err := c.BundleIndex(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if unavailableCatalogs := index.UnavailableCatalogs(); len(unavailableCatalogs) > 0 {
+		return nil, &catalogmetadata.UnavailableCatalogsError{Catalogs: unavailableCatalogs}
+	}
+	return index.All(), nil
+}
 
+// Packages returns the packages of every unpacked catalog.
+// Like Bundles, it fails when the content of a catalog cannot be fetched.
+func (c *Client) Packages(ctx context.Context) ([]*catalogmetadata.Package, error) {
+	contents, err := c.catalogContents(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var packages []*catalogmetadata.Package
+	for _, content := range contents {
+		packages = append(packages, content.packages...)
+	}
+	return packages, nil
+}
+
+// Channels returns the channels of every unpacked catalog.
+// Like Bundles, it fails when the content of a catalog cannot be fetched.
+func (c *Client) Channels(ctx context.Context) ([]*catalogmetadata.Channel, error) {
+	contents, err := c.catalogContents(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var channels []*catalogmetadata.Channel
+	for _, content := range contents {
+		channels = append(channels, content.channels...)
+	}
+	return channels, nil
+}
+
+// catalogContents returns the parsed contents of every unpacked catalog,
+// in catalog name order.
+func (c *Client) catalogContents(ctx context.Context) ([]*catalogContent, error) {
+	index, err := c.BundleIndex(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if unavailableCatalogs := index.UnavailableCatalogs(); len(unavailableCatalogs) > 0 {
+		return nil, &catalogmetadata.UnavailableCatalogsError{Catalogs: unavailableCatalogs}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	catalogNames := sets.List(sets.KeySet(c.catalogs))
+	contents := make([]*catalogContent, 0, len(catalogNames))
+	for _, catalogName := range catalogNames {
+		contents = append(contents, c.catalogs[catalogName])
+	}
+	return contents, nil
+}
+
+// BundleIndex returns the bundles of every unpacked catalog, indexed for queries.
+// Catalog contents are only fetched and parsed again when the catalog
+// has unpacked new content since the previous call.
+//
+// Catalogs whose content cannot be fetched or parsed, or whose priority
+// annotation is invalid, are skipped on a best-effort basis and recorded as
+// unavailable on the index, so that resolution can carry on with the other
+// catalogs. They are fetched again once their backoff expires, or as soon as
+// they unpack new content.
+func (c *Client) BundleIndex(ctx context.Context) (*catalogmetadata.BundleIndex, error) {
 	var catalogList catalogd.CatalogList
 	if err := c.cl.List(ctx, &catalogList); err != nil {
 		return nil, err
 	}
-	for _, catalog := range catalogList.Items {
+
+	type pendingFetch struct {
+		catalog  *catalogd.Catalog
+		priority int32
+		content  *catalogContent
+		err      error
+	}
+	var pendingFetches []*pendingFetch
+	var unavailableCatalogs []catalogmetadata.UnavailableCatalog
+	existing := sets.New[string]()
+	unpacked := sets.New[string]()
+	now := time.Now()
+	changed := false
+
+	c.mu.Lock()
+	for i := range catalogList.Items {
+		catalog := &catalogList.Items[i]
+		existing.Insert(catalog.Name)
 		// if the catalog has not been successfully unpacked, skip it
 		if !meta.IsStatusConditionPresentAndEqual(catalog.Status.Conditions, catalogd.TypeUnpacked, metav1.ConditionTrue) {
 			continue
 		}
-		channels := []*catalogmetadata.Channel{}
-		bundles := []*catalogmetadata.Bundle{}
-
-		rc, err := c.fetcher.FetchCatalogContents(ctx, catalog.DeepCopy())
+		unpacked.Insert(catalog.Name)
+		priority, err := catalogPriority(catalog)
 		if err != nil {
-			return nil, fmt.Errorf("error fetching catalog contents: %s", err)
+			if _, ok := c.catalogs[catalog.Name]; ok {
+				delete(c.catalogs, catalog.Name)
+				changed = true
+			}
+			unavailableCatalogs = append(unavailableCatalogs, catalogmetadata.UnavailableCatalog{
+				Name:   catalog.Name,
+				Labels: catalog.Labels,
+				Err:    err,
+			})
+			continue
+		}
+
+		if content, ok := c.catalogs[catalog.Name]; ok && content.isValidFor(catalog, priority) {
+			continue
+		}
+		if failure, ok := c.failures[catalog.Name]; ok && failure.resolvedRef == resolvedRef(catalog) && now.Before(failure.retryAt) {
+			unavailableCatalogs = append(unavailableCatalogs, catalogmetadata.UnavailableCatalog{
+				Name:   catalog.Name,
+				Labels: catalog.Labels,
+				Err:    failure.err,
+			})
+			continue
 		}
-		defer rc.Close()
+		pendingFetches = append(pendingFetches, &pendingFetch{catalog: catalog, priority: priority})
+	}
+	c.mu.Unlock()
+
+	// fetch the catalogs concurrently, without holding the lock, so that
+	// slow or unreachable catalogs only delay the callers which need them
+	var wg sync.WaitGroup
+	for _, pending := range pendingFetches {
+		wg.Add(1)
+		go func(pending *pendingFetch) {
+			defer wg.Done()
+			pending.content, pending.err = c.fetch(ctx, pending.catalog, pending.priority)
+		}(pending)
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 
-		err = declcfg.WalkMetasReader(rc, func(meta *declcfg.Meta, err error) error {
-			if err != nil {
-				return fmt.Errorf("error was provided to the WalkMetasReaderFunc: %s", err)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	changed = changed || c.index == nil
+	for _, pending := range pendingFetches {
+		catalog := pending.catalog
+		if pending.err != nil {
+			if _, ok := c.catalogs[catalog.Name]; ok {
+				delete(c.catalogs, catalog.Name)
+				changed = true
 			}
-			switch meta.Schema {
-			case declcfg.SchemaChannel:
-				var content catalogmetadata.Channel
-				if err := json.Unmarshal(meta.Blob, &content); err != nil {
-					return fmt.Errorf("error unmarshalling channel from catalog metadata: %s", err)
-				}
-				channels = append(channels, &content)
-			case declcfg.SchemaBundle:
-				var content catalogmetadata.Bundle
-				if err := json.Unmarshal(meta.Blob, &content); err != nil {
-					return fmt.Errorf("error unmarshalling bundle from catalog metadata: %s", err)
-				}
-				bundles = append(bundles, &content)
+			c.recordFailure(catalog, pending.err, now)
+			unavailableCatalogs = append(unavailableCatalogs, catalogmetadata.UnavailableCatalog{
+				Name:   catalog.Name,
+				Labels: catalog.Labels,
+				Err:    pending.err,
+			})
+			continue
+		}
+		delete(c.failures, catalog.Name)
+		if c.catalogs[catalog.Name] != pending.content {
+			c.catalogs[catalog.Name] = pending.content
+			changed = true
+		}
+	}
+
+	// forget about the catalogs which are gone or no longer unpacked
+	for catalogName := range c.catalogs {
+		if !unpacked.Has(catalogName) {
+			delete(c.catalogs, catalogName)
+			changed = true
+		}
+	}
+	for catalogName := range c.failures {
+		if !unpacked.Has(catalogName) {
+			delete(c.failures, catalogName)
+		}
+	}
+
+	// the catalogs which are not unpacked at the moment keep their contents,
+	// they are likely to unpack the same contents again.
+	if pruner, ok := c.fetcher.(Pruner); ok && changed {
+		pruner.Prune(ctx, existing)
+	}
+
+	if changed || len(unavailableCatalogs) > 0 || len(c.index.UnavailableCatalogs()) > 0 {
+		var allBundles []*catalogmetadata.Bundle
+		for i := range catalogList.Items {
+			if content, ok := c.catalogs[catalogList.Items[i].Name]; ok {
+				allBundles = append(allBundles, content.bundles...)
 			}
+		}
+		c.index = catalogmetadata.NewBundleIndex(allBundles, unavailableCatalogs...)
+	}
+	return c.index, nil
+}
 
-			return nil
-		})
-		if err != nil {
-			return nil, fmt.Errorf("error processing response: %s", err)
+// fetch fetches and parses the contents of the catalog. Concurrent fetches
+// of the same contents share a single download.
+func (c *Client) fetch(ctx context.Context, catalog *catalogd.Catalog, priority int32) (*catalogContent, error) {
+	key := newContentKey(catalog, priority)
+	for {
+		c.mu.Lock()
+		call, ok := c.fetching[key]
+		if !ok {
+			call = &fetchCall{done: make(chan struct{})}
+			c.fetching[key] = call
 		}
+		c.mu.Unlock()
 
-		bundles, err = PopulateExtraFields(catalog.Name, channels, bundles)
+		if !ok {
+			c.doFetch(ctx, key, call, catalog, priority)
+			return call.content, call.err
+		}
+
+		select {
+		case <-call.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		// the caller which fetched the contents gave up, try again
+		if call.canceled {
+			continue
+		}
+		return call.content, call.err
+	}
+}
+
+func (c *Client) doFetch(ctx context.Context, key contentKey, call *fetchCall, catalog *catalogd.Catalog, priority int32) {
+	defer func() {
+		call.canceled = ctx.Err() != nil
+		c.mu.Lock()
+		delete(c.fetching, key)
+		c.mu.Unlock()
+		close(call.done)
+	}()
+
+	rc, err := c.fetcher.FetchCatalogContents(ctx, catalog.DeepCopy())
+	if err != nil {
+		call.err = fmt.Errorf("error fetching catalog contents: %s", err)
+		return
+	}
+	call.content, call.err = parseCatalogContent(rc, catalog, priority)
+}
+
+// recordFailure records a failed fetch of the contents of the catalog, doubling
+// the backoff of the previous failure if the catalog contents are the same.
+func (c *Client) recordFailure(catalog *catalogd.Catalog, err error, now time.Time) {
+	backoff := c.unavailableBackoff
+	if previous, ok := c.failures[catalog.Name]; ok && previous.resolvedRef == resolvedRef(catalog) {
+		backoff = previous.backoff * 2
+		if backoff > c.maxUnavailableBackoff {
+			backoff = c.maxUnavailableBackoff
+		}
+	}
+	c.failures[catalog.Name] = &fetchFailure{
+		resolvedRef: resolvedRef(catalog),
+		err:         err,
+		backoff:     backoff,
+		retryAt:     now.Add(backoff),
+	}
+}
+
+func parseCatalogContent(rc io.ReadCloser, catalog *catalogd.Catalog, priority int32) (*catalogContent, error) {
+	defer rc.Close()
+
+	packages := []*catalogmetadata.Package{}
+	channels := []*catalogmetadata.Channel{}
+	bundles := []*catalogmetadata.Bundle{}
+	deprecations := []*catalogmetadata.Deprecation{}
+	err := declcfg.WalkMetasReader(rc, func(meta *declcfg.Meta, err error) error {
 		if err != nil {
-			return nil, err
+			return fmt.Errorf("error was provided to the WalkMetasReaderFunc: %s", err)
+		}
+		switch meta.Schema {
+		case declcfg.SchemaPackage:
+			var content catalogmetadata.Package
+			if err := json.Unmarshal(meta.Blob, &content); err != nil {
+				return fmt.Errorf("error unmarshalling package from catalog metadata: %s", err)
+			}
+			packages = append(packages, &content)
+		case declcfg.SchemaChannel:
+			var content catalogmetadata.Channel
+			if err := json.Unmarshal(meta.Blob, &content); err != nil {
+				return fmt.Errorf("error unmarshalling channel from catalog metadata: %s", err)
+			}
+			channels = append(channels, &content)
+		case declcfg.SchemaBundle:
+			var content catalogmetadata.Bundle
+			if err := json.Unmarshal(meta.Blob, &content); err != nil {
+				return fmt.Errorf("error unmarshalling bundle from catalog metadata: %s", err)
+			}
+			bundles = append(bundles, &content)
+		case catalogmetadata.SchemaDeprecation:
+			var content catalogmetadata.Deprecation
+			if err := json.Unmarshal(meta.Blob, &content); err != nil {
+				return fmt.Errorf("error unmarshalling deprecation from catalog metadata: %s", err)
+			}
+			deprecations = append(deprecations, &content)
 		}
 
-		allBundles = append(allBundles, bundles...)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error processing response: %s", err)
+	}
+
+	bundles, err = PopulateExtraFields(catalog.Name, packages, channels, bundles, deprecations)
+	if err != nil {
+		return nil, err
+	}
+	for i := range bundles {
+		bundles[i].CatalogLabels = catalog.Labels
+		bundles[i].CatalogPriority = priority
+	}
+
+	return &catalogContent{
+		resolvedRef: resolvedRef(catalog),
+		labels:      catalog.Labels,
+		priority:    priority,
+		packages:    packages,
+		channels:    channels,
+		bundles:     bundles,
+	}, nil
+}
+
+// resolvedRef returns the reference of the content unpacked for the catalog,
+// or an empty string if it is not known.
+func resolvedRef(catalog *catalogd.Catalog) string {
+	if catalog.Status.ResolvedSource == nil || catalog.Status.ResolvedSource.Image == nil {
+		return ""
 	}
+	return catalog.Status.ResolvedSource.Image.Ref
+}
 
-	return allBundles, nil
+func catalogPriority(catalog *catalogd.Catalog) (int32, error) {
+	value, ok := catalog.Annotations[CatalogPriorityAnnotation]
+	if !ok {
+		return 0, nil
+	}
+	priority, err := strconv.ParseInt(value, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid priority %q for catalog %q: %s", value, catalog.Name, err)
+	}
+	return int32(priority), nil
 }
 
-func PopulateExtraFields(catalogName string, channels []*catalogmetadata.Channel, bundles []*catalogmetadata.Bundle) ([]*catalogmetadata.Bundle, error) {
+func PopulateExtraFields(catalogName string, packages []*catalogmetadata.Package, channels []*catalogmetadata.Channel, bundles []*catalogmetadata.Bundle, deprecations []*catalogmetadata.Deprecation) ([]*catalogmetadata.Bundle, error) {
+	packagesMap := map[string]*catalogmetadata.Package{}
+	for i := range packages {
+		packagesMap[packages[i].Name] = packages[i]
+
+		packages[i].CatalogName = catalogName
+	}
+
 	bundlesMap := map[string]*catalogmetadata.Bundle{}
+	bundlesByPackage := map[string][]*catalogmetadata.Bundle{}
 	for i := range bundles {
 		bundleKey := fmt.Sprintf("%s-%s", bundles[i].Package, bundles[i].Name)
 		bundlesMap[bundleKey] = bundles[i]
+		bundlesByPackage[bundles[i].Package] = append(bundlesByPackage[bundles[i].Package], bundles[i])
 
 		bundles[i].CatalogName = catalogName
+		bundles[i].CatalogPackage = packagesMap[bundles[i].Package]
 	}
 
 	for _, ch := range channels {
+		ch.CatalogName = catalogName
 		for _, chEntry := range ch.Entries {
 			bundleKey := fmt.Sprintf("%s-%s", ch.Package, chEntry.Name)
 			bundle, ok := bundlesMap[bundleKey]
@@ -121,5 +541,37 @@ func PopulateExtraFields(catalogName string, channels []*catalogmetadata.Channel
 		}
 	}
 
+	// Deprecations are attached to every bundle they apply to, so that
+	// the bundles can be told apart without looking up the catalog again.
+	// Entries referencing packages, channels or bundles missing from
+	// the catalog are ignored.
+	for _, deprecation := range deprecations {
+		for _, entry := range deprecation.Entries {
+			for _, bundle := range bundlesByPackage[deprecation.Package] {
+				if deprecationApplies(entry.Reference, bundle) {
+					bundle.Deprecations = append(bundle.Deprecations, entry)
+				}
+			}
+		}
+	}
+
 	return bundles, nil
 }
+
+func deprecationApplies(reference catalogmetadata.PackageScopedReference, bundle *catalogmetadata.Bundle) bool {
+	switch reference.Schema {
+	case declcfg.SchemaPackage:
+		return true
+	case declcfg.SchemaChannel:
+		for _, ch := range bundle.InChannels {
+			if ch.Name == reference.Name {
+				return true
+			}
+		}
+		return false
+	case declcfg.SchemaBundle:
+		return bundle.Name == reference.Name
+	default:
+		return false
+	}
+}
@@ -7,12 +7,18 @@ import (
 	"errors"
 	"io"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/exp/slices"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
@@ -50,7 +56,7 @@ func TestClient(t *testing.T) {
 				name:        "cache error",
 				fakeCatalog: defaultFakeCatalog,
 				fetcher:     &MockFetcher{shouldError: true},
-				wantErr:     "error fetching catalog contents: mock cache error",
+				wantErr:     `skipped unavailable catalogs: catalog "catalog-1" (error fetching catalog contents: mock cache error), catalog "catalog-2" (error fetching catalog contents: mock cache error)`,
 			},
 			{
 				name: "channel has a ref to a missing bundle",
@@ -70,7 +76,19 @@ func TestClient(t *testing.T) {
 
 					return objs, nil, catalogContentMap
 				},
-				wantErr: `bundle "fake1.v9.9.9" not found in catalog "catalog-1" (package "fake1", channel "channel-with-missing-bundle")`,
+				wantErr: `skipped unavailable catalogs: catalog "catalog-1" (bundle "fake1.v9.9.9" not found in catalog "catalog-1" (package "fake1", channel "channel-with-missing-bundle"))`,
+				fetcher: &MockFetcher{},
+			},
+			{
+				name: "invalid catalog priority",
+				fakeCatalog: func() ([]client.Object, []*catalogmetadata.Bundle, map[string][]byte) {
+					objs, _, catalogContentMap := defaultFakeCatalog()
+
+					objs[0].SetAnnotations(map[string]string{catalogClient.CatalogPriorityAnnotation: "high"})
+
+					return objs, nil, catalogContentMap
+				},
+				wantErr: `skipped unavailable catalogs: catalog "catalog-1" (invalid priority "high" for catalog "catalog-1": strconv.ParseInt: parsing "high": invalid syntax)`,
 				fetcher: &MockFetcher{},
 			},
 			{
@@ -82,7 +100,7 @@ func TestClient(t *testing.T) {
 
 					return objs, nil, catalogContentMap
 				},
-				wantErr: `error processing response: error was provided to the WalkMetasReaderFunc: expected value for key "name" to be a string, got %!t(float64=1.23123123e+08): 1.23123123e+08`,
+				wantErr: `skipped unavailable catalogs: catalog "catalog-1" (error processing response: error was provided to the WalkMetasReaderFunc: expected value for key "name" to be a string, got %!t(float64=1.23123123e+08): 1.23123123e+08)`,
 				fetcher: &MockFetcher{},
 			},
 			{
@@ -95,7 +113,7 @@ func TestClient(t *testing.T) {
 
 					return objs, nil, catalogContentMap
 				},
-				wantErr: "error processing response: error unmarshalling bundle from catalog metadata: json: cannot unmarshal number into Go struct field Bundle.image of type string",
+				wantErr: `skipped unavailable catalogs: catalog "catalog-1" (error processing response: error unmarshalling bundle from catalog metadata: json: cannot unmarshal number into Go struct field Bundle.image of type string)`,
 				fetcher: &MockFetcher{},
 			},
 			{
@@ -108,7 +126,66 @@ func TestClient(t *testing.T) {
 
 					return objs, nil, catalogContentMap
 				},
-				wantErr: "error processing response: error unmarshalling channel from catalog metadata: json: cannot unmarshal number into Go struct field ChannelEntry.entries.name of type string",
+				wantErr: `skipped unavailable catalogs: catalog "catalog-1" (error processing response: error unmarshalling channel from catalog metadata: json: cannot unmarshal number into Go struct field ChannelEntry.entries.name of type string)`,
+				fetcher: &MockFetcher{},
+			},
+			{
+				name: "deprecations",
+				fakeCatalog: func() ([]client.Object, []*catalogmetadata.Bundle, map[string][]byte) {
+					objs, bundles, catalogContentMap := defaultFakeCatalog()
+
+					catalogContentMap["catalog-1"] = append(catalogContentMap["catalog-1"], []byte(`{
+								"schema": "olm.deprecations",
+								"package": "fake1",
+								"entries": [
+									{
+										"reference": {"schema": "olm.package"},
+										"message": "fake1 is end of life"
+									},
+									{
+										"reference": {"schema": "olm.channel", "name": "beta"},
+										"message": "beta is no longer updated"
+									},
+									{
+										"reference": {"schema": "olm.channel", "name": "alpha"},
+										"message": "alpha is no longer updated"
+									},
+									{
+										"reference": {"schema": "olm.bundle", "name": "fake1.v1.0.0"},
+										"message": "fake1.v1.0.0 has a known vulnerability"
+									}
+								]
+							}`)...)
+					bundles[0].Deprecations = []catalogmetadata.DeprecationEntry{
+						{
+							Reference: catalogmetadata.PackageScopedReference{Schema: declcfg.SchemaPackage},
+							Message:   "fake1 is end of life",
+						},
+						{
+							Reference: catalogmetadata.PackageScopedReference{Schema: declcfg.SchemaChannel, Name: "beta"},
+							Message:   "beta is no longer updated",
+						},
+						{
+							Reference: catalogmetadata.PackageScopedReference{Schema: declcfg.SchemaBundle, Name: "fake1.v1.0.0"},
+							Message:   "fake1.v1.0.0 has a known vulnerability",
+						},
+					}
+
+					return objs, bundles, catalogContentMap
+				},
+				fetcher: &MockFetcher{},
+			},
+			{
+				name: "invalid deprecation",
+				fakeCatalog: func() ([]client.Object, []*catalogmetadata.Bundle, map[string][]byte) {
+					objs, _, catalogContentMap := defaultFakeCatalog()
+
+					catalogContentMap["catalog-1"] = append(catalogContentMap["catalog-1"],
+						[]byte(`{"schema": "olm.deprecations", "package":"fake1", "entries":123123123}`)...)
+
+					return objs, nil, catalogContentMap
+				},
+				wantErr: `skipped unavailable catalogs: catalog "catalog-1" (error processing response: error unmarshalling deprecation from catalog metadata: json: cannot unmarshal number into Go struct field Deprecation.entries of type []catalogmetadata.DeprecationEntry)`,
 				fetcher: &MockFetcher{},
 			},
 			{
@@ -147,12 +224,167 @@ func TestClient(t *testing.T) {
 			})
 		}
 	})
+
+	t.Run("Packages and Channels", func(t *testing.T) {
+		ctx := context.Background()
+		objs, expectedBundles, catalogContentMap := defaultFakeCatalog()
+		fakeCatalogClient := catalogClient.New(
+			fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build(),
+			&MockFetcher{contentMap: catalogContentMap},
+		)
+
+		packages, err := fakeCatalogClient.Packages(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []*catalogmetadata.Package{expectedBundles[0].CatalogPackage, expectedBundles[1].CatalogPackage}, packages)
+
+		channels, err := fakeCatalogClient.Channels(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, append(expectedBundles[0].InChannels, expectedBundles[1].InChannels...), channels)
+
+		t.Run("fail when a catalog is unavailable", func(t *testing.T) {
+			fakeCatalogClient := catalogClient.New(
+				fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build(),
+				&MockFetcher{contentMap: catalogContentMap, failingCatalogs: []string{"catalog-2"}},
+			)
+
+			_, err := fakeCatalogClient.Packages(ctx)
+			assert.EqualError(t, err, `skipped unavailable catalogs: catalog "catalog-2" (error fetching catalog contents: mock cache error)`)
+			_, err = fakeCatalogClient.Channels(ctx)
+			assert.EqualError(t, err, `skipped unavailable catalogs: catalog "catalog-2" (error fetching catalog contents: mock cache error)`)
+		})
+	})
+
+	t.Run("BundleIndex", func(t *testing.T) {
+		ctx := context.Background()
+		objs, expectedBundles, catalogContentMap := defaultFakeCatalog()
+		for i, ref := range []string{"quay.io/test/catalog-1@sha256:1", "quay.io/test/catalog-2@sha256:1"} {
+			objs[i].(*catalogd.Catalog).Status.ResolvedSource = &catalogd.CatalogSource{
+				Type:  catalogd.SourceTypeImage,
+				Image: &catalogd.ImageSource{Ref: ref},
+			}
+		}
+		fetcher := &MockFetcher{contentMap: catalogContentMap}
+		cl := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build()
+		fakeCatalogClient := catalogClient.New(cl, fetcher)
+
+		index, err := fakeCatalogClient.BundleIndex(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, expectedBundles, index.All())
+		assert.Equal(t, expectedBundles[:1], index.ByChannel("fake1", "beta"))
+		assert.Equal(t, map[string]int{"catalog-1": 1, "catalog-2": 1}, fetcher.fetches)
+
+		t.Run("unchanged catalogs are not fetched again", func(t *testing.T) {
+			sameIndex, err := fakeCatalogClient.BundleIndex(ctx)
+			require.NoError(t, err)
+			assert.Same(t, index, sameIndex)
+			assert.Equal(t, map[string]int{"catalog-1": 1, "catalog-2": 1}, fetcher.fetches)
+		})
+
+		t.Run("catalogs with new content are fetched again", func(t *testing.T) {
+			catalog := &catalogd.Catalog{}
+			require.NoError(t, cl.Get(ctx, types.NamespacedName{Name: "catalog-2"}, catalog))
+			catalog.Status.ResolvedSource.Image.Ref = "quay.io/test/catalog-2@sha256:2"
+			require.NoError(t, cl.Status().Update(ctx, catalog))
+
+			newIndex, err := fakeCatalogClient.BundleIndex(ctx)
+			require.NoError(t, err)
+			assert.NotSame(t, index, newIndex)
+			assert.Equal(t, map[string]int{"catalog-1": 1, "catalog-2": 2}, fetcher.fetches)
+			index = newIndex
+		})
+
+		t.Run("catalogs with new labels are fetched again", func(t *testing.T) {
+			catalog := &catalogd.Catalog{}
+			require.NoError(t, cl.Get(ctx, types.NamespacedName{Name: "catalog-1"}, catalog))
+			catalog.SetLabels(map[string]string{"tier": "external"})
+			require.NoError(t, cl.Update(ctx, catalog))
+
+			newIndex, err := fakeCatalogClient.BundleIndex(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, map[string]string{"tier": "external"}, newIndex.ByPackage("fake1")[0].CatalogLabels)
+			assert.Equal(t, map[string]int{"catalog-1": 2, "catalog-2": 2}, fetcher.fetches)
+			index = newIndex
+		})
+
+		t.Run("unavailable catalogs are skipped", func(t *testing.T) {
+			fetcher.failingCatalogs = []string{"catalog-2"}
+			catalog := &catalogd.Catalog{}
+			require.NoError(t, cl.Get(ctx, types.NamespacedName{Name: "catalog-2"}, catalog))
+			catalog.Status.ResolvedSource.Image.Ref = "quay.io/test/catalog-2@sha256:3"
+			require.NoError(t, cl.Status().Update(ctx, catalog))
+
+			newIndex, err := fakeCatalogClient.BundleIndex(ctx)
+			require.NoError(t, err)
+			require.Len(t, newIndex.All(), 1)
+			assert.Equal(t, "catalog-1", newIndex.All()[0].CatalogName)
+			unavailableCatalogs := newIndex.UnavailableCatalogs()
+			require.Len(t, unavailableCatalogs, 1)
+			assert.Equal(t, "catalog-2", unavailableCatalogs[0].Name)
+			assert.EqualError(t, unavailableCatalogs[0].Err, "error fetching catalog contents: mock cache error")
+
+			_, err = fakeCatalogClient.Bundles(ctx)
+			assert.EqualError(t, err, `skipped unavailable catalogs: catalog "catalog-2" (error fetching catalog contents: mock cache error)`)
+
+			// unavailable catalogs are not fetched again until their backoff expires
+			fetcher.failingCatalogs = nil
+			newIndex, err = fakeCatalogClient.BundleIndex(ctx)
+			require.NoError(t, err)
+			assert.Len(t, newIndex.All(), 1)
+			assert.Len(t, newIndex.UnavailableCatalogs(), 1)
+			assert.Equal(t, map[string]int{"catalog-1": 2, "catalog-2": 3}, fetcher.fetches)
+
+			// unless they unpack new content
+			require.NoError(t, cl.Get(ctx, types.NamespacedName{Name: "catalog-2"}, catalog))
+			catalog.Status.ResolvedSource.Image.Ref = "quay.io/test/catalog-2@sha256:4"
+			require.NoError(t, cl.Status().Update(ctx, catalog))
+
+			newIndex, err = fakeCatalogClient.BundleIndex(ctx)
+			require.NoError(t, err)
+			assert.Len(t, newIndex.All(), 2)
+			assert.Empty(t, newIndex.UnavailableCatalogs())
+			assert.Equal(t, map[string]int{"catalog-1": 2, "catalog-2": 4}, fetcher.fetches)
+		})
+
+		t.Run("unavailable catalogs are fetched again when their backoff expires", func(t *testing.T) {
+			fetcher := &MockFetcher{contentMap: catalogContentMap, failingCatalogs: []string{"catalog-2"}}
+			fakeCatalogClient := catalogClient.New(cl, fetcher, catalogClient.WithUnavailableCatalogBackoff(time.Millisecond, time.Millisecond))
+
+			newIndex, err := fakeCatalogClient.BundleIndex(ctx)
+			require.NoError(t, err)
+			assert.Len(t, newIndex.UnavailableCatalogs(), 1)
+
+			fetcher.failingCatalogs = nil
+			time.Sleep(2 * time.Millisecond)
+			newIndex, err = fakeCatalogClient.BundleIndex(ctx)
+			require.NoError(t, err)
+			assert.Len(t, newIndex.All(), 2)
+			assert.Empty(t, newIndex.UnavailableCatalogs())
+			assert.Equal(t, map[string]int{"catalog-1": 1, "catalog-2": 2}, fetcher.fetches)
+		})
+
+		t.Run("deleted catalogs are dropped", func(t *testing.T) {
+			require.NoError(t, cl.Delete(ctx, objs[0]))
+
+			newIndex, err := fakeCatalogClient.BundleIndex(ctx)
+			require.NoError(t, err)
+			assert.Len(t, newIndex.All(), 1)
+			assert.Equal(t, "catalog-2", newIndex.All()[0].CatalogName)
+			assert.Equal(t, map[string]int{"catalog-1": 2, "catalog-2": 4}, fetcher.fetches)
+			assert.Equal(t, sets.New("catalog-2"), fetcher.retained)
+		})
+	})
 }
 
 func defaultFakeCatalog() ([]client.Object, []*catalogmetadata.Bundle, map[string][]byte) {
 	package1 := `{
 		"schema": "olm.package",
-		"name": "fake1"
+		"name": "fake1",
+		"defaultChannel": "stable",
+		"description": "fake1 does nothing",
+		"icon": {
+			"base64data": "PHN2Zz48L3N2Zz4=",
+			"mediatype": "image/svg+xml"
+		}
 	}`
 
 	bundle1 := `{
@@ -193,7 +425,8 @@ func defaultFakeCatalog() ([]client.Object, []*catalogmetadata.Bundle, map[strin
 	objs := []client.Object{
 		&catalogd.Catalog{
 			ObjectMeta: metav1.ObjectMeta{
-				Name: "catalog-1",
+				Name:   "catalog-1",
+				Labels: map[string]string{"tier": "internal"},
 			},
 			Status: catalogd.CatalogStatus{
 				Conditions: []metav1.Condition{
@@ -207,7 +440,8 @@ func defaultFakeCatalog() ([]client.Object, []*catalogmetadata.Bundle, map[strin
 		},
 		&catalogd.Catalog{
 			ObjectMeta: metav1.ObjectMeta{
-				Name: "catalog-2",
+				Name:        "catalog-2",
+				Annotations: map[string]string{catalogClient.CatalogPriorityAnnotation: "10"},
 			},
 			Status: catalogd.CatalogStatus{
 				Conditions: []metav1.Condition{
@@ -221,9 +455,41 @@ func defaultFakeCatalog() ([]client.Object, []*catalogmetadata.Bundle, map[strin
 		},
 	}
 
+	expectedPackage := func(catalogName string) *catalogmetadata.Package {
+		return &catalogmetadata.Package{
+			Package: declcfg.Package{
+				Schema:         declcfg.SchemaPackage,
+				Name:           "fake1",
+				DefaultChannel: "stable",
+				Description:    "fake1 does nothing",
+				Icon: &declcfg.Icon{
+					Data:      []byte("<svg></svg>"),
+					MediaType: "image/svg+xml",
+				},
+			},
+			CatalogName: catalogName,
+		}
+	}
+	expectedChannel := func(catalogName, channelName string) *catalogmetadata.Channel {
+		return &catalogmetadata.Channel{
+			Channel: declcfg.Channel{
+				Schema:  declcfg.SchemaChannel,
+				Name:    channelName,
+				Package: "fake1",
+				Entries: []declcfg.ChannelEntry{
+					{
+						Name: "fake1.v1.0.0",
+					},
+				},
+			},
+			CatalogName: catalogName,
+		}
+	}
+
 	expectedBundles := []*catalogmetadata.Bundle{
 		{
-			CatalogName: "catalog-1",
+			CatalogName:   "catalog-1",
+			CatalogLabels: map[string]string{"tier": "internal"},
 			Bundle: declcfg.Bundle{
 				Schema:  declcfg.SchemaBundle,
 				Name:    "fake1.v1.0.0",
@@ -237,34 +503,14 @@ func defaultFakeCatalog() ([]client.Object, []*catalogmetadata.Bundle, map[strin
 				},
 			},
 			InChannels: []*catalogmetadata.Channel{
-				{
-					Channel: declcfg.Channel{
-						Schema:  declcfg.SchemaChannel,
-						Name:    "stable",
-						Package: "fake1",
-						Entries: []declcfg.ChannelEntry{
-							{
-								Name: "fake1.v1.0.0",
-							},
-						},
-					},
-				},
-				{
-					Channel: declcfg.Channel{
-						Schema:  declcfg.SchemaChannel,
-						Name:    "beta",
-						Package: "fake1",
-						Entries: []declcfg.ChannelEntry{
-							{
-								Name: "fake1.v1.0.0",
-							},
-						},
-					},
-				},
+				expectedChannel("catalog-1", "stable"),
+				expectedChannel("catalog-1", "beta"),
 			},
+			CatalogPackage: expectedPackage("catalog-1"),
 		},
 		{
-			CatalogName: "catalog-2",
+			CatalogName:     "catalog-2",
+			CatalogPriority: 10,
 			Bundle: declcfg.Bundle{
 				Schema:  declcfg.SchemaBundle,
 				Name:    "fake1.v1.0.0",
@@ -278,19 +524,9 @@ func defaultFakeCatalog() ([]client.Object, []*catalogmetadata.Bundle, map[strin
 				},
 			},
 			InChannels: []*catalogmetadata.Channel{
-				{
-					Channel: declcfg.Channel{
-						Schema:  declcfg.SchemaChannel,
-						Name:    "stable",
-						Package: "fake1",
-						Entries: []declcfg.ChannelEntry{
-							{
-								Name: "fake1.v1.0.0",
-							},
-						},
-					},
-				},
+				expectedChannel("catalog-2", "stable"),
 			},
+			CatalogPackage: expectedPackage("catalog-2"),
 		},
 	}
 
@@ -303,14 +539,32 @@ func defaultFakeCatalog() ([]client.Object, []*catalogmetadata.Bundle, map[strin
 }
 
 var _ catalogClient.Fetcher = &MockFetcher{}
+var _ catalogClient.Pruner = &MockFetcher{}
 
 type MockFetcher struct {
+	// mu guards fetches, as catalogs are fetched concurrently
+	mu          sync.Mutex
 	contentMap  map[string][]byte
 	shouldError bool
+	// failingCatalogs are the names of the catalogs to error for, on top of shouldError
+	failingCatalogs []string
+	fetches         map[string]int
+	// retained are the catalogs retained by the last prune
+	retained sets.Set[string]
+}
+
+func (mc *MockFetcher) Prune(_ context.Context, catalogNames sets.Set[string]) {
+	mc.retained = catalogNames
 }
 
 func (mc *MockFetcher) FetchCatalogContents(_ context.Context, catalog *catalogd.Catalog) (io.ReadCloser, error) {
-	if mc.shouldError {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	if mc.fetches == nil {
+		mc.fetches = map[string]int{}
+	}
+	mc.fetches[catalog.Name]++
+	if mc.shouldError || slices.Contains(mc.failingCatalogs, catalog.Name) {
 		return nil, errors.New("mock cache error")
 	}
 
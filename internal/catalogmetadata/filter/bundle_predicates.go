@@ -1,8 +1,15 @@
 package filter
 
 import (
+	"encoding/json"
+	"fmt"
+
 	mmsemver "github.com/Masterminds/semver/v3"
 	bsemver "github.com/blang/semver/v4"
+	"github.com/operator-framework/api/pkg/constraints"
+	"github.com/operator-framework/operator-registry/alpha/property"
+	"golang.org/x/exp/slices"
+	"k8s.io/apimachinery/pkg/labels"
 
 	"github.com/operator-framework/operator-controller/internal/catalogmetadata"
 )
@@ -52,6 +59,18 @@ func InChannel(channelName string) Predicate[catalogmetadata.Bundle] {
 	}
 }
 
+// InDefaultChannel matches the bundles which are in the default channel of
+// their package, as defined in their catalog. Bundles of packages without
+// a default channel, or without package metadata at all, always match.
+func InDefaultChannel() Predicate[catalogmetadata.Bundle] {
+	return func(bundle *catalogmetadata.Bundle) bool {
+		if bundle.CatalogPackage == nil || bundle.CatalogPackage.DefaultChannel == "" {
+			return true
+		}
+		return InChannel(bundle.CatalogPackage.DefaultChannel)(bundle)
+	}
+}
+
 func WithBundleImage(bundleImage string) Predicate[catalogmetadata.Bundle] {
 	return func(bundle *catalogmetadata.Bundle) bool {
 		return bundle.Image == bundleImage
@@ -70,3 +89,147 @@ func Replaces(bundleName string) Predicate[catalogmetadata.Bundle] {
 		return false
 	}
 }
+
+func Skips(bundleName string) Predicate[catalogmetadata.Bundle] {
+	return func(bundle *catalogmetadata.Bundle) bool {
+		for _, ch := range bundle.InChannels {
+			for _, chEntry := range ch.Entries {
+				if bundle.Name != chEntry.Name {
+					continue
+				}
+				for _, skip := range chEntry.Skips {
+					if skip == bundleName {
+						return true
+					}
+				}
+			}
+		}
+		return false
+	}
+}
+
+func SkipRangeIncludes(version bsemver.Version) Predicate[catalogmetadata.Bundle] {
+	return func(bundle *catalogmetadata.Bundle) bool {
+		for _, ch := range bundle.InChannels {
+			for _, chEntry := range ch.Entries {
+				if bundle.Name != chEntry.Name || chEntry.SkipRange == "" {
+					continue
+				}
+				skipRange, err := bsemver.ParseRange(chEntry.SkipRange)
+				if err != nil {
+					continue
+				}
+				if skipRange(version) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+}
+
+func InCatalogNames(catalogNames ...string) Predicate[catalogmetadata.Bundle] {
+	return func(bundle *catalogmetadata.Bundle) bool {
+		for _, catalogName := range catalogNames {
+			if bundle.CatalogName == catalogName {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func WithCatalogLabels(selector labels.Selector) Predicate[catalogmetadata.Bundle] {
+	return func(bundle *catalogmetadata.Bundle) bool {
+		return selector.Matches(labels.Set(bundle.CatalogLabels))
+	}
+}
+
+func ProvidesGVK(gvk property.GVK) Predicate[catalogmetadata.Bundle] {
+	return func(bundle *catalogmetadata.Bundle) bool {
+		providedGVKs, err := bundle.ProvidedGVKs()
+		if err != nil {
+			return false
+		}
+		return slices.Contains(providedGVKs, gvk)
+	}
+}
+
+// MatchesCEL evaluates a compiled CEL program against the properties of the bundle,
+// exposed to the program as a list of maps with a "type" and a "value" key.
+// Properties whose value is not valid JSON are left out, bundles for which
+// the program fails are filtered out.
+func MatchesCEL(program constraints.CelProgram) Predicate[catalogmetadata.Bundle] {
+	return func(bundle *catalogmetadata.Bundle) bool {
+		properties := make([]interface{}, 0, len(bundle.Properties))
+		for _, prop := range bundle.Properties {
+			var value interface{}
+			if err := json.Unmarshal(prop.Value, &value); err != nil {
+				continue
+			}
+			properties = append(properties, map[string]interface{}{
+				"type":  prop.Type,
+				"value": value,
+			})
+		}
+		ok, err := program.Evaluate(map[string]interface{}{constraints.PropertiesKey: properties})
+		return err == nil && ok
+	}
+}
+
+var celEnv = constraints.NewCelEnvironment()
+
+// FromConstraint translates an olm.constraint into a predicate matching
+// the bundles which satisfy it. Compound constraints are translated
+// recursively: all matches bundles satisfying every nested constraint,
+// any matches bundles satisfying at least one and not matches bundles
+// satisfying none of them.
+func FromConstraint(c constraints.Constraint) (Predicate[catalogmetadata.Bundle], error) {
+	switch {
+	case c.GVK != nil:
+		return ProvidesGVK(property.GVK{Group: c.GVK.Group, Version: c.GVK.Version, Kind: c.GVK.Kind}), nil
+	case c.Package != nil:
+		semverRange, err := bsemver.ParseRange(c.Package.VersionRange)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing version range %q of package constraint %q: %s", c.Package.VersionRange, c.Package.PackageName, err)
+		}
+		return And(WithPackageName(c.Package.PackageName), InBlangSemverRange(semverRange)), nil
+	case c.All != nil:
+		predicates, err := fromCompoundConstraint(c.All)
+		if err != nil {
+			return nil, err
+		}
+		return And(predicates...), nil
+	case c.Any != nil:
+		predicates, err := fromCompoundConstraint(c.Any)
+		if err != nil {
+			return nil, err
+		}
+		return Or(predicates...), nil
+	case c.Not != nil:
+		predicates, err := fromCompoundConstraint(c.Not)
+		if err != nil {
+			return nil, err
+		}
+		return Not(Or(predicates...)), nil
+	case c.Cel != nil:
+		program, err := celEnv.Validate(c.Cel.Rule)
+		if err != nil {
+			return nil, fmt.Errorf("error compiling cel rule %q: %s", c.Cel.Rule, err)
+		}
+		return MatchesCEL(program), nil
+	}
+	return nil, fmt.Errorf("constraint must define one of gvk, package, all, any, not or cel")
+}
+
+func fromCompoundConstraint(c *constraints.CompoundConstraint) ([]Predicate[catalogmetadata.Bundle], error) {
+	predicates := make([]Predicate[catalogmetadata.Bundle], 0, len(c.Constraints))
+	for _, nested := range c.Constraints {
+		predicate, err := FromConstraint(nested)
+		if err != nil {
+			return nil, err
+		}
+		predicates = append(predicates, predicate)
+	}
+	return predicates, nil
+}
@@ -6,9 +6,12 @@ import (
 
 	mmsemver "github.com/Masterminds/semver/v3"
 	bsemver "github.com/blang/semver/v4"
+	"github.com/operator-framework/api/pkg/constraints"
 	"github.com/operator-framework/operator-registry/alpha/declcfg"
 	"github.com/operator-framework/operator-registry/alpha/property"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/labels"
 
 	"github.com/operator-framework/operator-controller/internal/catalogmetadata"
 	"github.com/operator-framework/operator-controller/internal/catalogmetadata/filter"
@@ -114,6 +117,33 @@ func TestInChannel(t *testing.T) {
 	assert.False(t, f(b3))
 }
 
+func TestInDefaultChannel(t *testing.T) {
+	pkg := &catalogmetadata.Package{Package: declcfg.Package{Name: "package1", DefaultChannel: "stable"}}
+	b1 := &catalogmetadata.Bundle{CatalogPackage: pkg, InChannels: []*catalogmetadata.Channel{
+		{Channel: declcfg.Channel{Name: "alpha"}},
+		{Channel: declcfg.Channel{Name: "stable"}},
+	}}
+	b2 := &catalogmetadata.Bundle{CatalogPackage: pkg, InChannels: []*catalogmetadata.Channel{
+		{Channel: declcfg.Channel{Name: "alpha"}},
+	}}
+	b3 := &catalogmetadata.Bundle{
+		CatalogPackage: &catalogmetadata.Package{Package: declcfg.Package{Name: "package1"}},
+		InChannels: []*catalogmetadata.Channel{
+			{Channel: declcfg.Channel{Name: "alpha"}},
+		},
+	}
+	b4 := &catalogmetadata.Bundle{InChannels: []*catalogmetadata.Channel{
+		{Channel: declcfg.Channel{Name: "alpha"}},
+	}}
+
+	f := filter.InDefaultChannel()
+
+	assert.True(t, f(b1))
+	assert.False(t, f(b2))
+	assert.True(t, f(b3))
+	assert.True(t, f(b4))
+}
+
 func TestWithBundleImage(t *testing.T) {
 	b1 := &catalogmetadata.Bundle{Bundle: declcfg.Bundle{Image: "fake-image-uri-1"}}
 	b2 := &catalogmetadata.Bundle{Bundle: declcfg.Bundle{Image: "fake-image-uri-2"}}
@@ -158,3 +188,218 @@ func TestReplaces(t *testing.T) {
 	assert.False(t, f(b2))
 	assert.False(t, f(b3))
 }
+
+func TestSkips(t *testing.T) {
+	fakeChannel := &catalogmetadata.Channel{
+		Channel: declcfg.Channel{
+			Entries: []declcfg.ChannelEntry{
+				{
+					Name:  "package1.v0.0.2",
+					Skips: []string{"package1.v0.0.1"},
+				},
+				{
+					Name:  "package1.v0.0.3",
+					Skips: []string{"package1.v0.0.2"},
+				},
+			},
+		},
+	}
+
+	b1 := &catalogmetadata.Bundle{
+		Bundle:     declcfg.Bundle{Name: "package1.v0.0.2"},
+		InChannels: []*catalogmetadata.Channel{fakeChannel},
+	}
+	b2 := &catalogmetadata.Bundle{
+		Bundle:     declcfg.Bundle{Name: "package1.v0.0.3"},
+		InChannels: []*catalogmetadata.Channel{fakeChannel},
+	}
+	b3 := &catalogmetadata.Bundle{}
+
+	f := filter.Skips("package1.v0.0.1")
+
+	assert.True(t, f(b1))
+	assert.False(t, f(b2))
+	assert.False(t, f(b3))
+}
+
+func TestSkipRangeIncludes(t *testing.T) {
+	fakeChannel := &catalogmetadata.Channel{
+		Channel: declcfg.Channel{
+			Entries: []declcfg.ChannelEntry{
+				{
+					Name:      "package1.v0.1.0",
+					SkipRange: ">=0.0.1 <0.1.0",
+				},
+				{
+					Name:      "package1.v0.2.0",
+					SkipRange: ">=0.1.0 <0.2.0",
+				},
+				{
+					Name:      "package1.v0.3.0",
+					SkipRange: "broken",
+				},
+			},
+		},
+	}
+
+	b1 := &catalogmetadata.Bundle{
+		Bundle:     declcfg.Bundle{Name: "package1.v0.1.0"},
+		InChannels: []*catalogmetadata.Channel{fakeChannel},
+	}
+	b2 := &catalogmetadata.Bundle{
+		Bundle:     declcfg.Bundle{Name: "package1.v0.2.0"},
+		InChannels: []*catalogmetadata.Channel{fakeChannel},
+	}
+	b3 := &catalogmetadata.Bundle{
+		Bundle:     declcfg.Bundle{Name: "package1.v0.3.0"},
+		InChannels: []*catalogmetadata.Channel{fakeChannel},
+	}
+	b4 := &catalogmetadata.Bundle{}
+
+	f := filter.SkipRangeIncludes(bsemver.MustParse("0.0.2"))
+
+	assert.True(t, f(b1))
+	assert.False(t, f(b2))
+	assert.False(t, f(b3))
+	assert.False(t, f(b4))
+}
+
+func TestInCatalogNames(t *testing.T) {
+	b1 := &catalogmetadata.Bundle{CatalogName: "catalog1"}
+	b2 := &catalogmetadata.Bundle{CatalogName: "catalog2"}
+	b3 := &catalogmetadata.Bundle{CatalogName: "catalog3"}
+
+	f := filter.InCatalogNames("catalog1", "catalog2")
+
+	assert.True(t, f(b1))
+	assert.True(t, f(b2))
+	assert.False(t, f(b3))
+}
+
+func TestWithCatalogLabels(t *testing.T) {
+	b1 := &catalogmetadata.Bundle{CatalogLabels: map[string]string{"tier": "internal", "team": "a"}}
+	b2 := &catalogmetadata.Bundle{CatalogLabels: map[string]string{"tier": "community"}}
+	b3 := &catalogmetadata.Bundle{}
+
+	f := filter.WithCatalogLabels(labels.SelectorFromSet(labels.Set{"tier": "internal"}))
+
+	assert.True(t, f(b1))
+	assert.False(t, f(b2))
+	assert.False(t, f(b3))
+}
+
+func TestProvidesGVK(t *testing.T) {
+	b1 := &catalogmetadata.Bundle{Bundle: declcfg.Bundle{
+		Properties: []property.Property{
+			{Type: property.TypeGVK, Value: json.RawMessage(`{"group": "foo.io", "kind": "Foo", "version": "v1"}`)},
+		},
+	}}
+	b2 := &catalogmetadata.Bundle{Bundle: declcfg.Bundle{
+		Properties: []property.Property{
+			{Type: property.TypeGVK, Value: json.RawMessage(`{"group": "bar.io", "kind": "Bar", "version": "v1"}`)},
+		},
+	}}
+	b3 := &catalogmetadata.Bundle{}
+
+	f := filter.ProvidesGVK(property.GVK{Group: "foo.io", Version: "v1", Kind: "Foo"})
+
+	assert.True(t, f(b1))
+	assert.False(t, f(b2))
+	assert.False(t, f(b3))
+}
+
+func TestFromConstraint(t *testing.T) {
+	newBundle := func(packageName, version string, properties ...property.Property) *catalogmetadata.Bundle {
+		return &catalogmetadata.Bundle{Bundle: declcfg.Bundle{
+			Package: packageName,
+			Properties: append(properties, property.Property{
+				Type:  property.TypePackage,
+				Value: json.RawMessage(`{"packageName": "` + packageName + `", "version": "` + version + `"}`),
+			}),
+		}}
+	}
+	foo1 := newBundle("foo", "1.0.0")
+	foo2 := newBundle("foo", "2.0.0", property.Property{Type: property.TypeGVK, Value: json.RawMessage(`{"group": "foo.io", "kind": "Foo", "version": "v1"}`)})
+	bar1 := newBundle("bar", "1.0.0", property.Property{Type: "custom.label", Value: json.RawMessage(`{"tier": "stable"}`)})
+
+	for _, tt := range []struct {
+		name       string
+		constraint string
+		want       []*catalogmetadata.Bundle
+	}{
+		{
+			name:       "package",
+			constraint: `{"package": {"packageName": "foo", "versionRange": ">=1.5.0"}}`,
+			want:       []*catalogmetadata.Bundle{foo2},
+		},
+		{
+			name:       "gvk",
+			constraint: `{"gvk": {"group": "foo.io", "kind": "Foo", "version": "v1"}}`,
+			want:       []*catalogmetadata.Bundle{foo2},
+		},
+		{
+			name:       "cel",
+			constraint: `{"cel": {"rule": "properties.exists(p, p.type == 'custom.label' && p.value.tier == 'stable')"}}`,
+			want:       []*catalogmetadata.Bundle{bar1},
+		},
+		{
+			name:       "cel with semver_compare",
+			constraint: `{"cel": {"rule": "properties.exists(p, p.type == 'olm.package' && semver_compare(p.value.version, '1.5.0') < 0)"}}`,
+			want:       []*catalogmetadata.Bundle{foo1, bar1},
+		},
+		{
+			name:       "all",
+			constraint: `{"all": {"constraints": [{"package": {"packageName": "foo", "versionRange": ">=1.0.0"}}, {"not": {"constraints": [{"gvk": {"group": "foo.io", "kind": "Foo", "version": "v1"}}]}}]}}`,
+			want:       []*catalogmetadata.Bundle{foo1},
+		},
+		{
+			name:       "any",
+			constraint: `{"any": {"constraints": [{"package": {"packageName": "bar", "versionRange": ">=1.0.0"}}, {"gvk": {"group": "foo.io", "kind": "Foo", "version": "v1"}}]}}`,
+			want:       []*catalogmetadata.Bundle{foo2, bar1},
+		},
+		{
+			name:       "not",
+			constraint: `{"not": {"constraints": [{"package": {"packageName": "foo", "versionRange": ">=1.0.0"}}]}}`,
+			want:       []*catalogmetadata.Bundle{bar1},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := constraints.Parse(json.RawMessage(tt.constraint))
+			require.NoError(t, err)
+			f, err := filter.FromConstraint(c)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, filter.Filter([]*catalogmetadata.Bundle{foo1, foo2, bar1}, f))
+		})
+	}
+}
+
+func TestFromConstraintErrors(t *testing.T) {
+	for _, tt := range []struct {
+		name       string
+		constraint constraints.Constraint
+		wantErr    string
+	}{
+		{
+			name:       "empty",
+			constraint: constraints.Constraint{},
+			wantErr:    "constraint must define one of gvk, package, all, any, not or cel",
+		},
+		{
+			name:       "invalid version range",
+			constraint: constraints.Constraint{Package: &constraints.PackageConstraint{PackageName: "foo", VersionRange: "not-a-range"}},
+			wantErr:    `error parsing version range "not-a-range" of package constraint "foo": Could not get version from string: "not-a-range"`,
+		},
+		{
+			name: "nested non boolean cel rule",
+			constraint: constraints.Constraint{Any: &constraints.CompoundConstraint{Constraints: []constraints.Constraint{
+				{Cel: &constraints.Cel{Rule: "1 + 1"}},
+			}}},
+			wantErr: `error compiling cel rule "1 + 1": cel expressions must have type Bool`,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := filter.FromConstraint(tt.constraint)
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
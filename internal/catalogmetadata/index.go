@@ -0,0 +1,130 @@
+package catalogmetadata
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/operator-framework/operator-registry/alpha/property"
+	"golang.org/x/exp/slices"
+)
+
+// BundleIndex holds the bundles of one or more catalogs together with
+// indexes for the lookups made during resolution, so that callers do not
+// need to scan every bundle to find the few they are interested in.
+//
+// The slices returned by the index are copies and can be modified
+// by the callers, the bundles they point to must not be modified.
+type BundleIndex struct {
+	bundles             []*Bundle
+	unavailableCatalogs []UnavailableCatalog
+	byPackage           map[string][]*Bundle
+	byImage             map[string][]*Bundle
+	byChannel           map[packageChannel][]*Bundle
+
+	// the GVK index requires parsing the properties of every bundle
+	// so it is only built the first time it is needed.
+	byGVKOnce sync.Once
+	byGVK     map[property.GVK][]*Bundle
+}
+
+type packageChannel struct {
+	packageName string
+	channelName string
+}
+
+// UnavailableCatalog is a catalog whose content could not be read,
+// and whose bundles are therefore missing from an index.
+type UnavailableCatalog struct {
+	Name   string
+	Labels map[string]string
+	Err    error
+}
+
+// UnavailableCatalogsError reports the catalogs which could not be read.
+type UnavailableCatalogsError struct {
+	Catalogs []UnavailableCatalog
+}
+
+func (e *UnavailableCatalogsError) Error() string {
+	msgs := make([]string, 0, len(e.Catalogs))
+	for _, catalog := range e.Catalogs {
+		msgs = append(msgs, fmt.Sprintf("catalog %q (%s)", catalog.Name, catalog.Err))
+	}
+	return fmt.Sprintf("skipped unavailable catalogs: %s", strings.Join(msgs, ", "))
+}
+
+func (e *UnavailableCatalogsError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Catalogs))
+	for _, catalog := range e.Catalogs {
+		errs = append(errs, catalog.Err)
+	}
+	return errs
+}
+
+// NewBundleIndex indexes the given bundles. The order of the bundles is
+// preserved in the results of every query. Bundles whose provided GVKs
+// cannot be determined are not indexed by GVK. Catalogs which could not
+// be read while gathering the bundles can be recorded on the index,
+// so that the users of the index know that it is incomplete.
+func NewBundleIndex(bundles []*Bundle, unavailableCatalogs ...UnavailableCatalog) *BundleIndex {
+	index := &BundleIndex{
+		bundles:             bundles,
+		unavailableCatalogs: unavailableCatalogs,
+		byPackage:           map[string][]*Bundle{},
+		byImage:             map[string][]*Bundle{},
+		byChannel:           map[packageChannel][]*Bundle{},
+	}
+	for _, bundle := range bundles {
+		index.byPackage[bundle.Package] = append(index.byPackage[bundle.Package], bundle)
+		index.byImage[bundle.Image] = append(index.byImage[bundle.Image], bundle)
+		for _, ch := range bundle.InChannels {
+			key := packageChannel{packageName: bundle.Package, channelName: ch.Name}
+			index.byChannel[key] = append(index.byChannel[key], bundle)
+		}
+	}
+	return index
+}
+
+// UnavailableCatalogs returns the catalogs which are missing from the index
+// as their content could not be read.
+func (i *BundleIndex) UnavailableCatalogs() []UnavailableCatalog {
+	return slices.Clone(i.unavailableCatalogs)
+}
+
+// All returns every bundle of the index.
+func (i *BundleIndex) All() []*Bundle {
+	return slices.Clone(i.bundles)
+}
+
+// ByPackage returns the bundles of the given package.
+func (i *BundleIndex) ByPackage(packageName string) []*Bundle {
+	return slices.Clone(i.byPackage[packageName])
+}
+
+// ByImage returns the bundles with the given bundle image.
+func (i *BundleIndex) ByImage(bundleImage string) []*Bundle {
+	return slices.Clone(i.byImage[bundleImage])
+}
+
+// ByGVK returns the bundles which provide the given GVK.
+func (i *BundleIndex) ByGVK(gvk property.GVK) []*Bundle {
+	i.byGVKOnce.Do(func() {
+		i.byGVK = map[property.GVK][]*Bundle{}
+		for _, bundle := range i.bundles {
+			providedGVKs, err := bundle.ProvidedGVKs()
+			if err != nil {
+				continue
+			}
+			for _, providedGVK := range providedGVKs {
+				i.byGVK[providedGVK] = append(i.byGVK[providedGVK], bundle)
+			}
+		}
+	})
+	return slices.Clone(i.byGVK[gvk])
+}
+
+// ByChannel returns the bundles which are entries of the given channel of a package.
+func (i *BundleIndex) ByChannel(packageName, channelName string) []*Bundle {
+	return slices.Clone(i.byChannel[packageChannel{packageName: packageName, channelName: channelName}])
+}
@@ -0,0 +1,86 @@
+package catalogmetadata_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/operator-framework/operator-registry/alpha/property"
+
+	"github.com/operator-framework/operator-controller/internal/catalogmetadata"
+)
+
+func TestBundleIndex(t *testing.T) {
+	stable := &catalogmetadata.Channel{Channel: declcfg.Channel{Name: "stable", Package: "package1"}}
+	beta := &catalogmetadata.Channel{Channel: declcfg.Channel{Name: "beta", Package: "package1"}}
+
+	b1 := &catalogmetadata.Bundle{
+		Bundle: declcfg.Bundle{
+			Name:    "package1.v1.0.0",
+			Package: "package1",
+			Image:   "quay.io/test/package1@v1.0.0",
+			Properties: []property.Property{
+				{Type: property.TypeGVK, Value: json.RawMessage(`{"group": "foo.io", "kind": "Foo", "version": "v1"}`)},
+			},
+		},
+		InChannels: []*catalogmetadata.Channel{stable, beta},
+	}
+	b2 := &catalogmetadata.Bundle{
+		Bundle: declcfg.Bundle{
+			Name:    "package1.v2.0.0",
+			Package: "package1",
+			Image:   "quay.io/test/package1@v2.0.0",
+			Properties: []property.Property{
+				{Type: property.TypeGVK, Value: json.RawMessage(`{"group": "foo.io", "kind": "Foo", "version": "v1"}`)},
+				{Type: property.TypeGVK, Value: json.RawMessage(`{"group": "foo.io", "kind": "Foo", "version": "v2"}`)},
+			},
+		},
+		InChannels: []*catalogmetadata.Channel{beta},
+	}
+	b3 := &catalogmetadata.Bundle{
+		Bundle: declcfg.Bundle{
+			Name:    "package2.v1.0.0",
+			Package: "package2",
+			Image:   "quay.io/test/package2@v1.0.0",
+			Properties: []property.Property{
+				{Type: property.TypeGVK, Value: json.RawMessage(`badGVK`)},
+			},
+		},
+	}
+	// the same bundle can be found in several catalogs
+	b4 := &catalogmetadata.Bundle{
+		Bundle: declcfg.Bundle{
+			Name:    "package1.v1.0.0",
+			Package: "package1",
+			Image:   "quay.io/test/package1@v1.0.0",
+		},
+		CatalogName: "another-catalog",
+	}
+
+	index := catalogmetadata.NewBundleIndex([]*catalogmetadata.Bundle{b1, b2, b3, b4})
+
+	assert.Equal(t, []*catalogmetadata.Bundle{b1, b2, b3, b4}, index.All())
+
+	assert.Equal(t, []*catalogmetadata.Bundle{b1, b2, b4}, index.ByPackage("package1"))
+	assert.Equal(t, []*catalogmetadata.Bundle{b3}, index.ByPackage("package2"))
+	assert.Empty(t, index.ByPackage("package3"))
+
+	assert.Equal(t, []*catalogmetadata.Bundle{b1, b4}, index.ByImage("quay.io/test/package1@v1.0.0"))
+	assert.Empty(t, index.ByImage("quay.io/test/package1@v3.0.0"))
+
+	assert.Equal(t, []*catalogmetadata.Bundle{b1, b2}, index.ByGVK(property.GVK{Group: "foo.io", Kind: "Foo", Version: "v1"}))
+	assert.Equal(t, []*catalogmetadata.Bundle{b2}, index.ByGVK(property.GVK{Group: "foo.io", Kind: "Foo", Version: "v2"}))
+	assert.Empty(t, index.ByGVK(property.GVK{Group: "bar.io", Kind: "Bar", Version: "v1"}))
+
+	assert.Equal(t, []*catalogmetadata.Bundle{b1}, index.ByChannel("package1", "stable"))
+	assert.Equal(t, []*catalogmetadata.Bundle{b1, b2}, index.ByChannel("package1", "beta"))
+	assert.Empty(t, index.ByChannel("package2", "stable"))
+
+	t.Run("results can be modified without affecting the index", func(t *testing.T) {
+		result := index.ByPackage("package1")
+		result[0] = b3
+		assert.Equal(t, []*catalogmetadata.Bundle{b1, b2, b4}, index.ByPackage("package1"))
+	})
+}
@@ -18,6 +18,39 @@ func ByVersion(b1, b2 *catalogmetadata.Bundle) bool {
 	return ver1.GT(*ver2)
 }
 
+// ByVersionAndCatalogPriority is a sort "less" function that orders bundles
+// in inverse version order (higher versions on top). Bundles with the same
+// version are ordered by catalog priority (higher priorities on top),
+// then by catalog name and bundle name to keep the order deterministic.
+func ByVersionAndCatalogPriority(b1, b2 *catalogmetadata.Bundle) bool {
+	ver1, err1 := b1.Version()
+	ver2, err2 := b2.Version()
+	if err1 != nil || err2 != nil {
+		if cmp := compareErrors(err1, err2); cmp != 0 {
+			return cmp < 0
+		}
+	} else if cmp := ver1.Compare(*ver2); cmp != 0 {
+		// we want higher versions on top
+		return cmp > 0
+	}
+
+	if b1.CatalogPriority != b2.CatalogPriority {
+		return b1.CatalogPriority > b2.CatalogPriority
+	}
+	if b1.CatalogName != b2.CatalogName {
+		return b1.CatalogName < b2.CatalogName
+	}
+	return b1.Name < b2.Name
+}
+
+// ByDeprecated is a sort "less" function that orders bundles which are
+// not deprecated before deprecated ones. It is meant to be used with
+// sort.SliceStable on bundles which are already sorted, so that their
+// order is otherwise kept.
+func ByDeprecated(b1, b2 *catalogmetadata.Bundle) bool {
+	return !b1.IsDeprecated() && b2.IsDeprecated()
+}
+
 // compareErrors returns 0 if both errors are either nil or not nil
 // -1 if err1 is nil and err2 is not nil
 // +1 if err1 is not nil and err2 is nil
@@ -81,3 +81,64 @@ func TestByVersion(t *testing.T) {
 		assert.Equal(t, b5empty, toSort[4])
 	})
 }
+
+func TestByVersionAndCatalogPriority(t *testing.T) {
+	newBundle := func(name, version, catalogName string, priority int32) *catalogmetadata.Bundle {
+		return &catalogmetadata.Bundle{
+			CatalogName:     catalogName,
+			CatalogPriority: priority,
+			Bundle: declcfg.Bundle{
+				Name: name,
+				Properties: []property.Property{
+					{
+						Type:  property.TypePackage,
+						Value: json.RawMessage(`{"packageName": "package1", "version": "` + version + `"}`),
+					},
+				},
+			},
+		}
+	}
+	b1 := newBundle("package1.v2.0.0", "2.0.0", "catalog-a", 0)
+	b2 := newBundle("package1.v1.0.0", "1.0.0", "catalog-b", 0)
+	b3 := newBundle("package1.v1.0.0", "1.0.0", "catalog-a", 0)
+	b4 := newBundle("package1.v1.0.0", "1.0.0", "catalog-c", 10)
+	b5 := newBundle("package1.v0.0.1", "0.0.1", "catalog-c", 10)
+	b6empty := &catalogmetadata.Bundle{CatalogPriority: 100, Bundle: declcfg.Bundle{
+		Name: "package1.empty",
+	}}
+
+	toSort := []*catalogmetadata.Bundle{b6empty, b5, b2, b1, b3, b4}
+	sort.SliceStable(toSort, func(i, j int) bool {
+		return catalogsort.ByVersionAndCatalogPriority(toSort[i], toSort[j])
+	})
+
+	assert.Equal(t, []*catalogmetadata.Bundle{b1, b4, b3, b2, b5, b6empty}, toSort)
+}
+
+func TestByDeprecated(t *testing.T) {
+	deprecated := func(name string) *catalogmetadata.Bundle {
+		return &catalogmetadata.Bundle{
+			Bundle: declcfg.Bundle{Name: name},
+			Deprecations: []catalogmetadata.DeprecationEntry{
+				{Reference: catalogmetadata.PackageScopedReference{Schema: declcfg.SchemaBundle, Name: name}, Message: "deprecated"},
+			},
+		}
+	}
+	b1 := deprecated("package1.v3.0.0")
+	b2 := &catalogmetadata.Bundle{Bundle: declcfg.Bundle{Name: "package1.v2.0.0"}}
+	b3 := deprecated("package1.v1.0.0")
+	b4 := &catalogmetadata.Bundle{
+		Bundle: declcfg.Bundle{Name: "package1.v0.1.0"},
+		// package and channel deprecations apply to every bundle alike
+		Deprecations: []catalogmetadata.DeprecationEntry{
+			{Reference: catalogmetadata.PackageScopedReference{Schema: declcfg.SchemaPackage}, Message: "deprecated"},
+		},
+	}
+
+	toSort := []*catalogmetadata.Bundle{b1, b2, b3, b4}
+	sort.SliceStable(toSort, func(i, j int) bool {
+		return catalogsort.ByDeprecated(toSort[i], toSort[j])
+	})
+
+	assert.Equal(t, []*catalogmetadata.Bundle{b2, b4, b1, b3}, toSort)
+}
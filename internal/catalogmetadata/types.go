@@ -3,10 +3,12 @@ package catalogmetadata
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 	"sync"
 
 	bsemver "github.com/blang/semver/v4"
 
+	"github.com/operator-framework/api/pkg/constraints"
 	"github.com/operator-framework/operator-registry/alpha/declcfg"
 	"github.com/operator-framework/operator-registry/alpha/property"
 )
@@ -15,18 +17,29 @@ const (
 	MediaTypePlain          = "plain+v0"
 	MediaTypeRegistry       = "registry+v1"
 	PropertyBundleMediaType = "olm.bundle.mediatype"
+
+	// PropertyMaxOpenShiftVersion holds the latest OpenShift minor version a bundle can be installed on.
+	PropertyMaxOpenShiftVersion = "olm.maxOpenShiftVersion"
+
+	// SchemaDeprecation is the schema of the catalog metadata which deprecates
+	// packages, channels and bundles.
+	SchemaDeprecation = "olm.deprecations"
 )
 
 type Schemas interface {
 	Package | Bundle | Channel
 }
 
+// Package holds the olm.package metadata of a package in a catalog,
+// such as its default channel, description and icon.
 type Package struct {
 	declcfg.Package
+	CatalogName string
 }
 
 type Channel struct {
 	declcfg.Channel
+	CatalogName string
 }
 
 type PackageRequired struct {
@@ -34,10 +47,40 @@ type PackageRequired struct {
 	SemverRange bsemver.Range `json:"-"`
 }
 
+// Deprecation holds the deprecations of a package, its channels and its bundles,
+// as published in a catalog with the olm.deprecations schema.
+type Deprecation struct {
+	Schema  string             `json:"schema"`
+	Package string             `json:"package"`
+	Entries []DeprecationEntry `json:"entries"`
+}
+
+// DeprecationEntry deprecates the package, channel or bundle it references.
+type DeprecationEntry struct {
+	Reference PackageScopedReference `json:"reference"`
+	Message   string                 `json:"message"`
+}
+
+// PackageScopedReference references the package itself when Schema is
+// olm.package, or one of its channels or bundles by Name when Schema is
+// olm.channel or olm.bundle.
+type PackageScopedReference struct {
+	Schema string `json:"schema"`
+	Name   string `json:"name,omitempty"`
+}
+
 type Bundle struct {
 	declcfg.Bundle
-	CatalogName string
-	InChannels  []*Channel
+	CatalogName     string
+	CatalogLabels   map[string]string
+	CatalogPriority int32
+	InChannels      []*Channel
+	// CatalogPackage is the package of the bundle as defined in its catalog,
+	// nil if the catalog has no olm.package metadata for it.
+	CatalogPackage *Package
+	// Deprecations are the entries deprecating the package of the bundle,
+	// the channels it is in or the bundle itself.
+	Deprecations []DeprecationEntry
 
 	mu sync.RWMutex
 	// these properties are lazy loaded as they are requested
@@ -45,7 +88,14 @@ type Bundle struct {
 	bundlePackage    *property.Package
 	semVersion       *bsemver.Version
 	requiredPackages []PackageRequired
+	providedGVKs     []property.GVK
+	requiredGVKs     []property.GVKRequired
+	olmConstraints   []constraints.Constraint
 	mediaType        *string
+
+	clusterVersionsLoaded bool
+	minKubeVersion        *bsemver.Version
+	maxOpenShiftVersion   *bsemver.Version
 }
 
 func (b *Bundle) Version() (*bsemver.Version, error) {
@@ -62,6 +112,50 @@ func (b *Bundle) RequiredPackages() ([]PackageRequired, error) {
 	return b.requiredPackages, nil
 }
 
+// ProvidedGVKs returns the GVKs the bundle provides through its olm.gvk properties.
+func (b *Bundle) ProvidedGVKs() ([]property.GVK, error) {
+	if err := b.loadProvidedGVKs(); err != nil {
+		return nil, err
+	}
+	return b.providedGVKs, nil
+}
+
+// RequiredGVKs returns the GVKs the bundle depends on through its olm.gvk.required properties.
+func (b *Bundle) RequiredGVKs() ([]property.GVKRequired, error) {
+	if err := b.loadRequiredGVKs(); err != nil {
+		return nil, err
+	}
+	return b.requiredGVKs, nil
+}
+
+// Constraints returns the generic dependency constraints of the bundle,
+// declared through its olm.constraint properties.
+func (b *Bundle) Constraints() ([]constraints.Constraint, error) {
+	if err := b.loadConstraints(); err != nil {
+		return nil, err
+	}
+	return b.olmConstraints, nil
+}
+
+// MinKubeVersion returns the minimum Kubernetes version the bundle can be installed on,
+// as declared in its olm.csv.metadata property, or nil when the bundle declares none.
+func (b *Bundle) MinKubeVersion() (*bsemver.Version, error) {
+	if err := b.loadClusterVersions(); err != nil {
+		return nil, err
+	}
+	return b.minKubeVersion, nil
+}
+
+// MaxOpenShiftVersion returns the latest OpenShift version the bundle can be installed on,
+// as declared in its olm.maxOpenShiftVersion property, or nil when the bundle declares none.
+// Only the major and minor parts of the version are meaningful.
+func (b *Bundle) MaxOpenShiftVersion() (*bsemver.Version, error) {
+	if err := b.loadClusterVersions(); err != nil {
+		return nil, err
+	}
+	return b.maxOpenShiftVersion, nil
+}
+
 func (b *Bundle) MediaType() (string, error) {
 	if err := b.loadMediaType(); err != nil {
 		return "", err
@@ -70,6 +164,36 @@ func (b *Bundle) MediaType() (string, error) {
 	return *b.mediaType, nil
 }
 
+// PackageDeprecation returns the entry deprecating the package of the bundle, if any.
+func (b *Bundle) PackageDeprecation() *DeprecationEntry {
+	return b.deprecation(declcfg.SchemaPackage, "")
+}
+
+// ChannelDeprecation returns the entry deprecating the given channel of the bundle, if any.
+func (b *Bundle) ChannelDeprecation(channelName string) *DeprecationEntry {
+	return b.deprecation(declcfg.SchemaChannel, channelName)
+}
+
+// BundleDeprecation returns the entry deprecating the bundle itself, if any.
+func (b *Bundle) BundleDeprecation() *DeprecationEntry {
+	return b.deprecation(declcfg.SchemaBundle, b.Name)
+}
+
+// IsDeprecated tells whether the bundle itself is deprecated. Bundles of
+// deprecated packages or channels are not deprecated on their own.
+func (b *Bundle) IsDeprecated() bool {
+	return b.BundleDeprecation() != nil
+}
+
+func (b *Bundle) deprecation(schema, name string) *DeprecationEntry {
+	for i := range b.Deprecations {
+		if b.Deprecations[i].Reference.Schema == schema && b.Deprecations[i].Reference.Name == name {
+			return &b.Deprecations[i]
+		}
+	}
+	return nil
+}
+
 func (b *Bundle) loadPackage() error {
 	b.mu.Lock()
 	defer b.mu.Unlock()
@@ -115,6 +239,97 @@ func (b *Bundle) loadRequiredPackages() error {
 	return nil
 }
 
+func (b *Bundle) loadProvidedGVKs() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.providedGVKs == nil {
+		providedGVKs, err := loadFromProps[property.GVK](b, property.TypeGVK, false)
+		if err != nil {
+			return fmt.Errorf("error determining bundle provided gvks for bundle %q: %s", b.Name, err)
+		}
+		if providedGVKs == nil {
+			providedGVKs = []property.GVK{}
+		}
+		b.providedGVKs = providedGVKs
+	}
+	return nil
+}
+
+func (b *Bundle) loadRequiredGVKs() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.requiredGVKs == nil {
+		requiredGVKs, err := loadFromProps[property.GVKRequired](b, property.TypeGVKRequired, false)
+		if err != nil {
+			return fmt.Errorf("error determining bundle required gvks for bundle %q: %s", b.Name, err)
+		}
+		if requiredGVKs == nil {
+			requiredGVKs = []property.GVKRequired{}
+		}
+		b.requiredGVKs = requiredGVKs
+	}
+	return nil
+}
+
+func (b *Bundle) loadConstraints() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.olmConstraints == nil {
+		olmConstraints := []constraints.Constraint{}
+		for _, prop := range b.propertiesByType(constraints.OLMConstraintType) {
+			olmConstraint, err := constraints.Parse(prop.Value)
+			if err != nil {
+				return fmt.Errorf("error determining bundle constraints for bundle %q: property %q with value %q could not be parsed: %s", b.Name, constraints.OLMConstraintType, prop.Value, err)
+			}
+			olmConstraints = append(olmConstraints, olmConstraint)
+		}
+		b.olmConstraints = olmConstraints
+	}
+	return nil
+}
+
+func (b *Bundle) loadClusterVersions() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.clusterVersionsLoaded {
+		return nil
+	}
+
+	csvMetadata, err := loadOneFromProps[property.CSVMetadata](b, property.TypeCSVMetadata, false)
+	if err != nil {
+		return fmt.Errorf("error determining bundle min kube version for bundle %q: %s", b.Name, err)
+	}
+	if csvMetadata.MinKubeVersion != "" {
+		minKubeVersion, err := bsemver.ParseTolerant(csvMetadata.MinKubeVersion)
+		if err != nil {
+			return fmt.Errorf("could not parse min kube version %q for bundle %q: %s", csvMetadata.MinKubeVersion, b.Name, err)
+		}
+		b.minKubeVersion = &minKubeVersion
+	}
+
+	// the version is either a string or a number, depending on how it was quoted in the CSV.
+	// The literal text of numbers is parsed, as 4.10 is not the same version as 4.1.
+	maxOpenShiftVersion, err := loadOneFromProps[json.RawMessage](b, PropertyMaxOpenShiftVersion, false)
+	if err != nil {
+		return fmt.Errorf("error determining bundle max openshift version for bundle %q: %s", b.Name, err)
+	}
+	if maxOpenShiftVersion != nil && string(maxOpenShiftVersion) != "null" {
+		text := string(maxOpenShiftVersion)
+		var quoted string
+		if err := json.Unmarshal(maxOpenShiftVersion, &quoted); err == nil {
+			text = quoted
+		}
+		version, err := bsemver.ParseTolerant(text)
+		if err != nil {
+			return fmt.Errorf("could not parse max openshift version %q for bundle %q: %s", text, b.Name, err)
+		}
+		b.maxOpenShiftVersion = &version
+	}
+
+	b.clusterVersionsLoaded = true
+	return nil
+}
+
 func (b *Bundle) loadMediaType() error {
 	b.mu.Lock()
 	defer b.mu.Unlock()
@@ -173,3 +388,31 @@ func loadFromProps[T any](bundle *Bundle, propType string, required bool) ([]T,
 
 	return nil, nil
 }
+
+// ConstraintString describes an olm.constraint in a human readable way.
+// The failure message of the constraint is not part of the description.
+func ConstraintString(c constraints.Constraint) string {
+	switch {
+	case c.GVK != nil:
+		return fmt.Sprintf("%s/%s/%s", c.GVK.Group, c.GVK.Version, c.GVK.Kind)
+	case c.Package != nil:
+		return fmt.Sprintf("package %q in version range %q", c.Package.PackageName, c.Package.VersionRange)
+	case c.All != nil:
+		return fmt.Sprintf("all of (%s)", compoundConstraintString(c.All))
+	case c.Any != nil:
+		return fmt.Sprintf("any of (%s)", compoundConstraintString(c.Any))
+	case c.Not != nil:
+		return fmt.Sprintf("none of (%s)", compoundConstraintString(c.Not))
+	case c.Cel != nil:
+		return fmt.Sprintf("cel rule %q", c.Cel.Rule)
+	}
+	return "empty constraint"
+}
+
+func compoundConstraintString(c *constraints.CompoundConstraint) string {
+	descriptions := make([]string, 0, len(c.Constraints))
+	for _, nested := range c.Constraints {
+		descriptions = append(descriptions, ConstraintString(nested))
+	}
+	return strings.Join(descriptions, ", ")
+}
@@ -8,6 +8,7 @@ import (
 	bsemver "github.com/blang/semver/v4"
 	"github.com/stretchr/testify/assert"
 
+	"github.com/operator-framework/api/pkg/constraints"
 	"github.com/operator-framework/operator-registry/alpha/declcfg"
 	"github.com/operator-framework/operator-registry/alpha/property"
 
@@ -147,6 +148,208 @@ func TestBundleRequiredPackages(t *testing.T) {
 	}
 }
 
+func TestBundleProvidedGVKs(t *testing.T) {
+	for _, tt := range []struct {
+		name             string
+		bundle           *catalogmetadata.Bundle
+		wantProvidedGVKs []property.GVK
+		wantErr          string
+	}{
+		{
+			name: "valid provided gvks",
+			bundle: &catalogmetadata.Bundle{Bundle: declcfg.Bundle{
+				Name: "fake-bundle.v1",
+				Properties: []property.Property{
+					{
+						Type:  property.TypeGVK,
+						Value: json.RawMessage(`{"group": "foo.io", "kind": "Foo", "version": "v1"}`),
+					},
+					{
+						Type:  property.TypeGVK,
+						Value: json.RawMessage(`{"group": "bar.io", "kind": "Bar", "version": "v1alpha1"}`),
+					},
+				},
+			}},
+			wantProvidedGVKs: []property.GVK{
+				{Group: "foo.io", Kind: "Foo", Version: "v1"},
+				{Group: "bar.io", Kind: "Bar", Version: "v1alpha1"},
+			},
+		},
+		{
+			name: "no provided gvks",
+			bundle: &catalogmetadata.Bundle{Bundle: declcfg.Bundle{
+				Name: "fake-bundle.noGVKs",
+			}},
+			wantProvidedGVKs: []property.GVK{},
+		},
+		{
+			name: "malformed gvk",
+			bundle: &catalogmetadata.Bundle{Bundle: declcfg.Bundle{
+				Name: "fake-bundle.badGVK",
+				Properties: []property.Property{
+					{
+						Type:  property.TypeGVK,
+						Value: json.RawMessage("badGVK"),
+					},
+				},
+			}},
+			wantErr: `error determining bundle provided gvks for bundle "fake-bundle.badGVK": property "olm.gvk" with value "badGVK" could not be parsed: invalid character 'b' looking for beginning of value`,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			providedGVKs, err := tt.bundle.ProvidedGVKs()
+			assert.Equal(t, tt.wantProvidedGVKs, providedGVKs)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestBundleRequiredGVKs(t *testing.T) {
+	for _, tt := range []struct {
+		name             string
+		bundle           *catalogmetadata.Bundle
+		wantRequiredGVKs []property.GVKRequired
+		wantErr          string
+	}{
+		{
+			name: "valid required gvks",
+			bundle: &catalogmetadata.Bundle{Bundle: declcfg.Bundle{
+				Name: "fake-bundle.v1",
+				Properties: []property.Property{
+					{
+						Type:  property.TypeGVKRequired,
+						Value: json.RawMessage(`{"group": "foo.io", "kind": "Foo", "version": "v1"}`),
+					},
+					{
+						Type:  property.TypeGVKRequired,
+						Value: json.RawMessage(`{"group": "bar.io", "kind": "Bar", "version": "v1alpha1"}`),
+					},
+				},
+			}},
+			wantRequiredGVKs: []property.GVKRequired{
+				{Group: "foo.io", Kind: "Foo", Version: "v1"},
+				{Group: "bar.io", Kind: "Bar", Version: "v1alpha1"},
+			},
+		},
+		{
+			name: "no required gvks",
+			bundle: &catalogmetadata.Bundle{Bundle: declcfg.Bundle{
+				Name: "fake-bundle.noGVKs",
+			}},
+			wantRequiredGVKs: []property.GVKRequired{},
+		},
+		{
+			name: "malformed gvk",
+			bundle: &catalogmetadata.Bundle{Bundle: declcfg.Bundle{
+				Name: "fake-bundle.badGVK",
+				Properties: []property.Property{
+					{
+						Type:  property.TypeGVKRequired,
+						Value: json.RawMessage("badGVK"),
+					},
+				},
+			}},
+			wantErr: `error determining bundle required gvks for bundle "fake-bundle.badGVK": property "olm.gvk.required" with value "badGVK" could not be parsed: invalid character 'b' looking for beginning of value`,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			requiredGVKs, err := tt.bundle.RequiredGVKs()
+			assert.Equal(t, tt.wantRequiredGVKs, requiredGVKs)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestBundleConstraints(t *testing.T) {
+	for _, tt := range []struct {
+		name            string
+		bundle          *catalogmetadata.Bundle
+		wantConstraints []constraints.Constraint
+		wantErr         string
+	}{
+		{
+			name: "valid constraints",
+			bundle: &catalogmetadata.Bundle{Bundle: declcfg.Bundle{
+				Name: "fake-bundle.v1",
+				Properties: []property.Property{
+					{
+						Type:  constraints.OLMConstraintType,
+						Value: json.RawMessage(`{"failureMessage": "requires foo", "package": {"packageName": "foo", "versionRange": ">=1.0.0"}}`),
+					},
+					{
+						Type:  constraints.OLMConstraintType,
+						Value: json.RawMessage(`{"any": {"constraints": [{"gvk": {"group": "bar.io", "kind": "Bar", "version": "v1"}}, {"cel": {"rule": "properties.exists(p, p.type == 'baz')"}}]}}`),
+					},
+				},
+			}},
+			wantConstraints: []constraints.Constraint{
+				{
+					FailureMessage: "requires foo",
+					Package:        &constraints.PackageConstraint{PackageName: "foo", VersionRange: ">=1.0.0"},
+				},
+				{
+					Any: &constraints.CompoundConstraint{Constraints: []constraints.Constraint{
+						{GVK: &constraints.GVKConstraint{Group: "bar.io", Kind: "Bar", Version: "v1"}},
+						{Cel: &constraints.Cel{Rule: "properties.exists(p, p.type == 'baz')"}},
+					}},
+				},
+			},
+		},
+		{
+			name: "no constraints",
+			bundle: &catalogmetadata.Bundle{Bundle: declcfg.Bundle{
+				Name: "fake-bundle.noConstraints",
+			}},
+			wantConstraints: []constraints.Constraint{},
+		},
+		{
+			name: "unknown constraint type",
+			bundle: &catalogmetadata.Bundle{Bundle: declcfg.Bundle{
+				Name: "fake-bundle.badConstraint",
+				Properties: []property.Property{
+					{
+						Type:  constraints.OLMConstraintType,
+						Value: json.RawMessage(`{"unknown": {}}`),
+					},
+				},
+			}},
+			wantErr: `error determining bundle constraints for bundle "fake-bundle.badConstraint": property "olm.constraint" with value "{\"unknown\": {}}" could not be parsed: json: unknown field "unknown"`,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			olmConstraints, err := tt.bundle.Constraints()
+			assert.Equal(t, tt.wantConstraints, olmConstraints)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestConstraintString(t *testing.T) {
+	c := constraints.Constraint{
+		All: &constraints.CompoundConstraint{Constraints: []constraints.Constraint{
+			{Package: &constraints.PackageConstraint{PackageName: "foo", VersionRange: ">=1.0.0"}},
+			{Not: &constraints.CompoundConstraint{Constraints: []constraints.Constraint{
+				{GVK: &constraints.GVKConstraint{Group: "bar.io", Kind: "Bar", Version: "v1"}},
+			}}},
+			{Cel: &constraints.Cel{Rule: "true"}},
+		}},
+	}
+	assert.Equal(t, `all of (package "foo" in version range ">=1.0.0", none of (bar.io/v1/Bar), cel rule "true")`, catalogmetadata.ConstraintString(c))
+	assert.Equal(t, "empty constraint", catalogmetadata.ConstraintString(constraints.Constraint{}))
+}
+
 func TestBundleMediaType(t *testing.T) {
 	for _, tt := range []struct {
 		name          string
@@ -201,3 +404,131 @@ func TestBundleMediaType(t *testing.T) {
 		})
 	}
 }
+
+func TestBundleClusterVersions(t *testing.T) {
+	for _, tt := range []struct {
+		name                    string
+		bundle                  *catalogmetadata.Bundle
+		wantMinKubeVersion      *bsemver.Version
+		wantMaxOpenShiftVersion *bsemver.Version
+		wantErr                 string
+	}{
+		{
+			name: "string versions",
+			bundle: &catalogmetadata.Bundle{Bundle: declcfg.Bundle{
+				Name: "fake-bundle.v1",
+				Properties: []property.Property{
+					{
+						Type:  property.TypeCSVMetadata,
+						Value: json.RawMessage(`{"minKubeVersion": "1.26.0"}`),
+					},
+					{
+						Type:  catalogmetadata.PropertyMaxOpenShiftVersion,
+						Value: json.RawMessage(`"4.12"`),
+					},
+				},
+			}},
+			wantMinKubeVersion:      &bsemver.Version{Major: 1, Minor: 26},
+			wantMaxOpenShiftVersion: &bsemver.Version{Major: 4, Minor: 12},
+		},
+		{
+			name: "numeric max openshift version",
+			bundle: &catalogmetadata.Bundle{Bundle: declcfg.Bundle{
+				Name: "fake-bundle.v2",
+				Properties: []property.Property{
+					{
+						Type:  catalogmetadata.PropertyMaxOpenShiftVersion,
+						Value: json.RawMessage(`4.9`),
+					},
+				},
+			}},
+			wantMaxOpenShiftVersion: &bsemver.Version{Major: 4, Minor: 9},
+		},
+		{
+			name: "numeric max openshift version with a trailing zero",
+			bundle: &catalogmetadata.Bundle{Bundle: declcfg.Bundle{
+				Name: "fake-bundle.v3",
+				Properties: []property.Property{
+					{
+						Type:  catalogmetadata.PropertyMaxOpenShiftVersion,
+						Value: json.RawMessage(`4.10`),
+					},
+				},
+			}},
+			wantMaxOpenShiftVersion: &bsemver.Version{Major: 4, Minor: 10},
+		},
+		{
+			name: "no versions",
+			bundle: &catalogmetadata.Bundle{Bundle: declcfg.Bundle{
+				Name: "fake-bundle.noVersions",
+				Properties: []property.Property{
+					{
+						Type:  property.TypeCSVMetadata,
+						Value: json.RawMessage(`{"displayName": "Fake"}`),
+					},
+				},
+			}},
+		},
+		{
+			name: "invalid min kube version",
+			bundle: &catalogmetadata.Bundle{Bundle: declcfg.Bundle{
+				Name: "fake-bundle.badMinKubeVersion",
+				Properties: []property.Property{
+					{
+						Type:  property.TypeCSVMetadata,
+						Value: json.RawMessage(`{"minKubeVersion": "latest"}`),
+					},
+				},
+			}},
+			wantErr: `could not parse min kube version "latest" for bundle "fake-bundle.badMinKubeVersion": Invalid character(s) found in major number "0latest"`,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			minKubeVersion, minKubeErr := tt.bundle.MinKubeVersion()
+			maxOpenShiftVersion, maxOpenShiftErr := tt.bundle.MaxOpenShiftVersion()
+			if tt.wantErr != "" {
+				assert.EqualError(t, minKubeErr, tt.wantErr)
+				assert.EqualError(t, maxOpenShiftErr, tt.wantErr)
+				return
+			}
+			assert.NoError(t, minKubeErr)
+			assert.NoError(t, maxOpenShiftErr)
+			assert.Equal(t, tt.wantMinKubeVersion, minKubeVersion)
+			assert.Equal(t, tt.wantMaxOpenShiftVersion, maxOpenShiftVersion)
+		})
+	}
+}
+
+func TestBundleDeprecations(t *testing.T) {
+	packageDeprecation := catalogmetadata.DeprecationEntry{
+		Reference: catalogmetadata.PackageScopedReference{Schema: declcfg.SchemaPackage},
+		Message:   "package fake is end of life",
+	}
+	channelDeprecation := catalogmetadata.DeprecationEntry{
+		Reference: catalogmetadata.PackageScopedReference{Schema: declcfg.SchemaChannel, Name: "alpha"},
+		Message:   "channel alpha is no longer updated",
+	}
+	bundleDeprecation := catalogmetadata.DeprecationEntry{
+		Reference: catalogmetadata.PackageScopedReference{Schema: declcfg.SchemaBundle, Name: "fake-bundle.v1"},
+		Message:   "fake-bundle.v1 has a known vulnerability",
+	}
+
+	deprecated := &catalogmetadata.Bundle{
+		Bundle:       declcfg.Bundle{Name: "fake-bundle.v1"},
+		Deprecations: []catalogmetadata.DeprecationEntry{packageDeprecation, channelDeprecation, bundleDeprecation},
+	}
+	assert.Equal(t, &packageDeprecation, deprecated.PackageDeprecation())
+	assert.Equal(t, &channelDeprecation, deprecated.ChannelDeprecation("alpha"))
+	assert.Nil(t, deprecated.ChannelDeprecation("stable"))
+	assert.Equal(t, &bundleDeprecation, deprecated.BundleDeprecation())
+	assert.True(t, deprecated.IsDeprecated())
+
+	inDeprecatedChannel := &catalogmetadata.Bundle{
+		Bundle:       declcfg.Bundle{Name: "fake-bundle.v2"},
+		Deprecations: []catalogmetadata.DeprecationEntry{channelDeprecation},
+	}
+	assert.Nil(t, inDeprecatedChannel.PackageDeprecation())
+	assert.Equal(t, &channelDeprecation, inDeprecatedChannel.ChannelDeprecation("alpha"))
+	assert.Nil(t, inDeprecatedChannel.BundleDeprecation())
+	assert.False(t, inDeprecatedChannel.IsDeprecated())
+}
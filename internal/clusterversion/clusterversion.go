@@ -0,0 +1,173 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package clusterversion determines the versions of the cluster operators are installed on,
+// so that bundles which do not support those versions can be left out of resolution.
+package clusterversion
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	bsemver "github.com/blang/semver/v4"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/discovery"
+)
+
+// Version holds the versions of the cluster. A nil version is unknown,
+// and bundles are not checked against it.
+type Version struct {
+	Kubernetes *bsemver.Version
+	// OpenShift is only known on OpenShift clusters.
+	OpenShift *bsemver.Version
+}
+
+func (v *Version) String() string {
+	if v == nil {
+		return "unknown"
+	}
+	var versions []string
+	if v.Kubernetes != nil {
+		versions = append(versions, fmt.Sprintf("kubernetes %s", v.Kubernetes))
+	}
+	if v.OpenShift != nil {
+		versions = append(versions, fmt.Sprintf("openshift %s", v.OpenShift))
+	}
+	if len(versions) == 0 {
+		return "unknown"
+	}
+	return strings.Join(versions, ", ")
+}
+
+// Parse parses the given Kubernetes and OpenShift versions, any of which
+// can be left empty when unknown. Leading "v"s and pre-release or build
+// suffixes, such as the ones added by Kubernetes distributions, are ignored.
+func Parse(kubernetesVersion, openShiftVersion string) (*Version, error) {
+	var (
+		v   Version
+		err error
+	)
+	if kubernetesVersion != "" {
+		if v.Kubernetes, err = parseRelease(kubernetesVersion); err != nil {
+			return nil, fmt.Errorf("error parsing kubernetes version %q: %w", kubernetesVersion, err)
+		}
+	}
+	if openShiftVersion != "" {
+		if v.OpenShift, err = parseRelease(openShiftVersion); err != nil {
+			return nil, fmt.Errorf("error parsing openshift version %q: %w", openShiftVersion, err)
+		}
+	}
+	return &v, nil
+}
+
+func parseRelease(version string) (*bsemver.Version, error) {
+	v, err := bsemver.ParseTolerant(version)
+	if err != nil {
+		return nil, err
+	}
+	v.Pre, v.Build = nil, nil
+	return &v, nil
+}
+
+// Provider provides the version of the cluster.
+type Provider interface {
+	ClusterVersion(ctx context.Context) (*Version, error)
+}
+
+// Static is a Provider which always provides the same version,
+// which can be nil to disable version checks altogether.
+type Static struct {
+	Version *Version
+}
+
+func (s Static) ClusterVersion(_ context.Context) (*Version, error) {
+	return s.Version, nil
+}
+
+// refreshInterval is how long the version read from the cluster is used
+// before reading it again, cluster upgrades being rare.
+const refreshInterval = 10 * time.Minute
+
+// openShiftClusterVersionPath is the path of the ClusterVersion object
+// which holds the version of OpenShift clusters.
+const openShiftClusterVersionPath = "/apis/config.openshift.io/v1/clusterversions/version"
+
+var _ Provider = &DiscoveryProvider{}
+
+// DiscoveryProvider reads the Kubernetes version of the cluster through discovery.
+// On OpenShift clusters, the OpenShift version is read from the ClusterVersion object.
+type DiscoveryProvider struct {
+	discoveryClient discovery.DiscoveryInterface
+
+	mu        sync.Mutex
+	version   *Version
+	expiresAt time.Time
+}
+
+func NewDiscoveryProvider(discoveryClient discovery.DiscoveryInterface) *DiscoveryProvider {
+	return &DiscoveryProvider{discoveryClient: discoveryClient}
+}
+
+func (p *DiscoveryProvider) ClusterVersion(ctx context.Context) (*Version, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.version != nil && time.Now().Before(p.expiresAt) {
+		return p.version, nil
+	}
+
+	serverVersion, err := p.discoveryClient.ServerVersion()
+	if err != nil {
+		return nil, fmt.Errorf("error reading kubernetes version: %w", err)
+	}
+	openShiftVersion, err := p.openShiftVersion(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error reading openshift version: %w", err)
+	}
+	version, err := Parse(serverVersion.GitVersion, openShiftVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	p.version, p.expiresAt = version, time.Now().Add(refreshInterval)
+	return version, nil
+}
+
+func (p *DiscoveryProvider) openShiftVersion(ctx context.Context) (string, error) {
+	raw, err := p.discoveryClient.RESTClient().Get().AbsPath(openShiftClusterVersionPath).Do(ctx).Raw()
+	if apierrors.IsNotFound(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	clusterVersion := struct {
+		Status struct {
+			Desired struct {
+				Version string `json:"version"`
+			} `json:"desired"`
+		} `json:"status"`
+	}{}
+	if err := json.Unmarshal(raw, &clusterVersion); err != nil {
+		return "", err
+	}
+	return clusterVersion.Status.Desired.Version, nil
+}
@@ -0,0 +1,121 @@
+package clusterversion_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	bsemver "github.com/blang/semver/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/rest"
+
+	"github.com/operator-framework/operator-controller/internal/clusterversion"
+)
+
+func TestParse(t *testing.T) {
+	version, err := clusterversion.Parse("v1.27.3+k3s1", "4.14.0-rc.1")
+	require.NoError(t, err)
+	assert.Equal(t, &clusterversion.Version{
+		Kubernetes: &bsemver.Version{Major: 1, Minor: 27, Patch: 3},
+		OpenShift:  &bsemver.Version{Major: 4, Minor: 14, Patch: 0},
+	}, version)
+	assert.Equal(t, "kubernetes 1.27.3, openshift 4.14.0", version.String())
+
+	version, err = clusterversion.Parse("", "")
+	require.NoError(t, err)
+	assert.Equal(t, &clusterversion.Version{}, version)
+	assert.Equal(t, "unknown", version.String())
+
+	_, err = clusterversion.Parse("not-a-version", "")
+	assert.ErrorContains(t, err, `error parsing kubernetes version "not-a-version"`)
+}
+
+func TestDiscoveryProvider(t *testing.T) {
+	for _, tt := range []struct {
+		name        string
+		handler     http.HandlerFunc
+		wantVersion string
+		wantErr     string
+	}{
+		{
+			name: "kubernetes",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/version":
+					_, _ = w.Write([]byte(`{"gitVersion": "v1.26.5-gke.1200"}`))
+				default:
+					http.NotFound(w, r)
+				}
+			},
+			wantVersion: "kubernetes 1.26.5",
+		},
+		{
+			name: "openshift",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/version":
+					_, _ = w.Write([]byte(`{"gitVersion": "v1.27.6+f67aeb3"}`))
+				case "/apis/config.openshift.io/v1/clusterversions/version":
+					_, _ = w.Write([]byte(`{"status": {"desired": {"version": "4.14.1"}}}`))
+				default:
+					http.NotFound(w, r)
+				}
+			},
+			wantVersion: "kubernetes 1.27.6, openshift 4.14.1",
+		},
+		{
+			name: "openshift cluster version forbidden",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/version":
+					_, _ = w.Write([]byte(`{"gitVersion": "v1.27.6+f67aeb3"}`))
+				default:
+					http.Error(w, "forbidden", http.StatusForbidden)
+				}
+			},
+			wantErr: "error reading openshift version: forbidden",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(tt.handler)
+			defer server.Close()
+
+			discoveryClient, err := discovery.NewDiscoveryClientForConfig(&rest.Config{Host: server.URL})
+			require.NoError(t, err)
+
+			version, err := clusterversion.NewDiscoveryProvider(discoveryClient).ClusterVersion(context.Background())
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantVersion, version.String())
+		})
+	}
+}
+
+func TestDiscoveryProviderCachesVersion(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/version" {
+			requests++
+			_, _ = w.Write([]byte(`{"gitVersion": "v1.27.3"}`))
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(&rest.Config{Host: server.URL})
+	require.NoError(t, err)
+	provider := clusterversion.NewDiscoveryProvider(discoveryClient)
+
+	for i := 0; i < 3; i++ {
+		_, err := provider.ClusterVersion(context.Background())
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 1, requests)
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// ErrorClass tells how a failed reconcile is retried.
+type ErrorClass string
+
+const (
+	// ErrorClassTransient errors, such as network or API server errors, may go away
+	// on their own. The reconcile is retried with backoff. Unclassified errors are transient.
+	ErrorClassTransient ErrorClass = "Transient"
+	// ErrorClassTerminal errors, such as bundles with an unknown media type, cannot go away
+	// on their own. The reconcile is not retried until the Operator, the catalogs or the
+	// BundleDeployment change, all of which are watched.
+	ErrorClassTerminal ErrorClass = "Terminal"
+	// ErrorClassWaiting errors are reported while waiting on a dependency, such as a
+	// BundleDeployment being unpacked. The reconcile is retried after a fixed delay.
+	ErrorClassWaiting ErrorClass = "Waiting"
+)
+
+// ReconcileError is an error classified for the requeue policy of the reconciler.
+type ReconcileError struct {
+	Class ErrorClass
+	Err   error
+	// RequeueAfter is the delay before retrying the reconcile of ErrorClassWaiting errors.
+	RequeueAfter time.Duration
+}
+
+func (e *ReconcileError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ReconcileError) Unwrap() error {
+	return e.Err
+}
+
+// NewTerminalError classifies err as an ErrorClassTerminal error.
+func NewTerminalError(err error) error {
+	return &ReconcileError{Class: ErrorClassTerminal, Err: err}
+}
+
+// NewWaitingError classifies err as an ErrorClassWaiting error, retried after requeueAfter.
+func NewWaitingError(err error, requeueAfter time.Duration) error {
+	return &ReconcileError{Class: ErrorClassWaiting, Err: err, RequeueAfter: requeueAfter}
+}
+
+// ClassifyError returns the class of err, which is ErrorClassTransient
+// unless err wraps a ReconcileError.
+func ClassifyError(err error) ErrorClass {
+	var reconcileErr *ReconcileError
+	if errors.As(err, &reconcileErr) {
+		return reconcileErr.Class
+	}
+	return ErrorClassTransient
+}
+
+// applyRequeuePolicy turns the error of a reconcile into the result and error
+// returned to controller-runtime, according to its class. Transient errors are
+// returned as they are to be retried with backoff. Terminal and waiting errors
+// are not returned, as controller-runtime would retry them with backoff too,
+// their message having been reported in the conditions of the Operator already.
+func applyRequeuePolicy(ctx context.Context, res ctrl.Result, err error) (ctrl.Result, error) {
+	var reconcileErr *ReconcileError
+	if !errors.As(err, &reconcileErr) {
+		return res, err
+	}
+
+	l := log.FromContext(ctx)
+	switch reconcileErr.Class {
+	case ErrorClassTerminal:
+		l.Info("not retrying until the operator or the catalogs change", "error", err.Error())
+		return res, nil
+	case ErrorClassWaiting:
+		l.V(1).Info("waiting", "reason", err.Error(), "requeueAfter", reconcileErr.RequeueAfter)
+		if res.RequeueAfter == 0 || reconcileErr.RequeueAfter < res.RequeueAfter {
+			res.RequeueAfter = reconcileErr.RequeueAfter
+		}
+		return res, nil
+	default:
+		return res, err
+	}
+}
@@ -20,24 +20,33 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"sort"
 	"strings"
+	"time"
 
+	bsemver "github.com/blang/semver/v4"
 	"github.com/go-logr/logr"
 	catalogd "github.com/operator-framework/catalogd/api/core/v1alpha1"
 	"github.com/operator-framework/deppy/pkg/deppy"
 	"github.com/operator-framework/deppy/pkg/deppy/solver"
 	rukpakv1alpha1 "github.com/operator-framework/rukpak/api/v1alpha1"
+	"golang.org/x/exp/slices"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
 	"k8s.io/utils/pointer"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
@@ -46,25 +55,53 @@ import (
 	operatorsv1alpha1 "github.com/operator-framework/operator-controller/api/v1alpha1"
 	"github.com/operator-framework/operator-controller/internal/catalogmetadata"
 	"github.com/operator-framework/operator-controller/internal/controllers/validators"
+	"github.com/operator-framework/operator-controller/internal/metrics"
+	"github.com/operator-framework/operator-controller/internal/resolution/explain"
 	olmvariables "github.com/operator-framework/operator-controller/internal/resolution/variables"
 )
 
+// Resolver computes the solution Operators are installed from.
+type Resolver interface {
+	Solve(ctx context.Context, options ...solver.Option) (*solver.Solution, error)
+}
+
 // OperatorReconciler reconciles a Operator object
 type OperatorReconciler struct {
 	client.Client
 	Scheme   *runtime.Scheme
-	Resolver *solver.DeppySolver
+	Resolver Resolver
+	Recorder record.EventRecorder
 }
 
-//+kubebuilder:rbac:groups=operators.operatorframework.io,resources=operators,verbs=get;list;watch
+// unavailableCatalogsRequeueAfter is how long to wait before reading
+// unavailable catalogs again for a degraded Operator.
+const unavailableCatalogsRequeueAfter = time.Minute
+
+// unpackingRequeueAfter is how long to wait before checking on
+// a BundleDeployment whose bundle is being unpacked again.
+const unpackingRequeueAfter = 10 * time.Second
+
+// Reasons of the events emitted for Operators
+const (
+	EventReasonResolvedBundleChanged    = "ResolvedBundleChanged"
+	EventReasonResolutionUnsatisfiable  = "ResolutionUnsatisfiable"
+	EventReasonBundleDeploymentPatched  = "BundleDeploymentPatched"
+	EventReasonInstalledConditionChange = "InstalledConditionChanged"
+)
+
+//+kubebuilder:rbac:groups=operators.operatorframework.io,resources=operators,verbs=get;list;watch;update;patch
 //+kubebuilder:rbac:groups=operators.operatorframework.io,resources=operators/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=operators.operatorframework.io,resources=operators/finalizers,verbs=update
 
-//+kubebuilder:rbac:groups=core.rukpak.io,resources=bundledeployments,verbs=get;list;watch;create;update;patch
+//+kubebuilder:rbac:groups=core.rukpak.io,resources=bundledeployments,verbs=get;list;watch;create;update;patch;delete
+
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 
 //+kubebuilder:rbac:groups=catalogd.operatorframework.io,resources=catalogs,verbs=list;watch
 //+kubebuilder:rbac:groups=catalogd.operatorframework.io,resources=catalogmetadata,verbs=list;watch
 
+//+kubebuilder:rbac:groups=config.openshift.io,resources=clusterversions,verbs=get
+
 func (r *OperatorReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	l := log.FromContext(ctx).WithName("operator-controller")
 	l.V(1).Info("starting")
@@ -72,11 +109,15 @@ func (r *OperatorReconciler) Reconcile(ctx context.Context, req ctrl.Request) (c
 
 	var existingOp = &operatorsv1alpha1.Operator{}
 	if err := r.Get(ctx, req.NamespacedName, existingOp); err != nil {
+		if apierrors.IsNotFound(err) {
+			metrics.DeleteOperatorBundles(req.Name)
+		}
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
 
 	reconciledOp := existingOp.DeepCopy()
-	res, reconcileErr := r.reconcile(ctx, reconciledOp)
+	bundles := map[string]*operatorsv1alpha1.BundleMetadata{}
+	res, reconcileErr := r.reconcile(ctx, reconciledOp, bundles)
 
 	// Do checks before any Update()s, as Update() may modify the resource structure!
 	updateStatus := !equality.Semantic.DeepEqual(existingOp.Status, reconciledOp.Status)
@@ -93,6 +134,20 @@ func (r *OperatorReconciler) Reconcile(ctx context.Context, req ctrl.Request) (c
 		panic("spec or metadata changed by reconciler")
 	}
 
+	r.recordStatusChangeEvents(existingOp, reconciledOp)
+	res, reconcileErr = applyRequeuePolicy(ctx, res, reconcileErr)
+
+	// Unavailable catalogs do not necessarily change anything watched
+	// by the controller when they become available again, so check on them
+	// periodically until the operator is no longer degraded.
+	if reconcileErr == nil && res.IsZero() && apimeta.IsStatusConditionTrue(reconciledOp.Status.Conditions, operatorsv1alpha1.TypeDegraded) {
+		res.RequeueAfter = unavailableCatalogsRequeueAfter
+	}
+	metrics.SetOperatorBundles(reconciledOp.GetName(),
+		metricsBundle(bundles, reconciledOp.Status.InstalledBundleResource),
+		metricsBundle(bundles, reconciledOp.Status.ResolvedBundleResource),
+	)
+
 	if updateFinalizers {
 		if updateErr := r.Update(ctx, reconciledOp); updateErr != nil {
 			return res, utilerrors.NewAggregate([]error{reconcileErr, updateErr})
@@ -102,6 +157,29 @@ func (r *OperatorReconciler) Reconcile(ctx context.Context, req ctrl.Request) (c
 	return res, reconcileErr
 }
 
+// recordStatusChangeEvents emits events for the transitions between the
+// status observed before and after a reconcile.
+func (r *OperatorReconciler) recordStatusChangeEvents(existingOp, reconciledOp *operatorsv1alpha1.Operator) {
+	resolvedBundle := reconciledOp.Status.ResolvedBundleResource
+	if resolvedBundle != "" && resolvedBundle != existingOp.Status.ResolvedBundleResource {
+		r.Recorder.Eventf(reconciledOp, corev1.EventTypeNormal, EventReasonResolvedBundleChanged, "resolved bundle changed to %q", resolvedBundle)
+	}
+
+	existingCond := apimeta.FindStatusCondition(existingOp.Status.Conditions, operatorsv1alpha1.TypeInstalled)
+	reconciledCond := apimeta.FindStatusCondition(reconciledOp.Status.Conditions, operatorsv1alpha1.TypeInstalled)
+	if reconciledCond == nil {
+		return
+	}
+	if existingCond != nil && existingCond.Status == reconciledCond.Status && existingCond.Reason == reconciledCond.Reason {
+		return
+	}
+	eventType := corev1.EventTypeNormal
+	if reconciledCond.Status == metav1.ConditionFalse {
+		eventType = corev1.EventTypeWarning
+	}
+	r.Recorder.Eventf(reconciledOp, eventType, EventReasonInstalledConditionChange, "%s=%s (%s): %s", reconciledCond.Type, reconciledCond.Status, reconciledCond.Reason, reconciledCond.Message)
+}
+
 // Compare resources - ignoring status & metadata.finalizers
 func checkForUnexpectedFieldChange(a, b operatorsv1alpha1.Operator) bool {
 	a.Status, b.Status = operatorsv1alpha1.OperatorStatus{}, operatorsv1alpha1.OperatorStatus{}
@@ -111,12 +189,22 @@ func checkForUnexpectedFieldChange(a, b operatorsv1alpha1.Operator) bool {
 
 // Helper function to do the actual reconcile
 //
-// Today we always return ctrl.Result{} and an error.
-// But in the future we might update this function
-// to return different results (e.g. requeue).
+// The name and version of the catalog bundles it comes across are added to bundles,
+// keyed by image, as the status of the operator only refers to bundles by image.
+//
+// The returned errors are classified with NewTerminalError and NewWaitingError
+// to control how the reconcile is retried, see applyRequeuePolicy. Unclassified
+// errors are transient: this includes unsatisfiable resolutions, which may
+// be fixed by changes to other Operators, which are not watched.
 //
 //nolint:unparam
-func (r *OperatorReconciler) reconcile(ctx context.Context, op *operatorsv1alpha1.Operator) (ctrl.Result, error) {
+func (r *OperatorReconciler) reconcile(ctx context.Context, op *operatorsv1alpha1.Operator, bundles map[string]*operatorsv1alpha1.BundleMetadata) (ctrl.Result, error) {
+	if !op.GetDeletionTimestamp().IsZero() {
+		return r.reconcileDelete(ctx, op)
+	}
+	controllerutil.AddFinalizer(op, operatorsv1alpha1.CleanupFinalizer)
+	setDeletingStatusConditionFalse(&op.Status.Conditions, "deletion has not been requested", op.GetGeneration())
+
 	// validate spec
 	if err := validators.ValidateOperatorSpec(op); err != nil {
 		// Set the TypeInstalled condition to Unknown to indicate that the resolution
@@ -127,18 +215,46 @@ func (r *OperatorReconciler) reconcile(ctx context.Context, op *operatorsv1alpha
 		// hasn't been attempted yet, due to the spec being invalid.
 		op.Status.ResolvedBundleResource = ""
 		setResolvedStatusConditionUnknown(&op.Status.Conditions, "validation has not been attempted as spec is invalid", op.GetGeneration())
+		op.Status.PendingUpgrade = nil
+		op.Status.AvailableUpgrades = nil
+		setUpgradeAvailableStatusConditionUnknown(&op.Status.Conditions, "upgrades have not been evaluated as spec is invalid", op.GetGeneration())
+		setDegradedStatusConditionUnknown(&op.Status.Conditions, "catalog availability has not been evaluated as spec is invalid", op.GetGeneration())
+		setDeprecationStatusConditionsUnknown(&op.Status.Conditions, "deprecations have not been evaluated as spec is invalid", op.GetGeneration())
 		return ctrl.Result{}, nil
 	}
+	if op.Spec.BundleImage != "" {
+		return r.reconcileBundleImage(ctx, op)
+	}
+
 	// run resolution
-	solution, err := r.Resolver.Solve(ctx)
+	solution, err := r.Resolver.Solve(ctx, solver.AddAllVariablesToSolution())
 	if err != nil {
 		op.Status.InstalledBundleResource = ""
 		setInstalledStatusConditionUnknown(&op.Status.Conditions, "installation has not been attempted as resolution failed", op.GetGeneration())
 		op.Status.ResolvedBundleResource = ""
 		setResolvedStatusConditionFailed(&op.Status.Conditions, err.Error(), op.GetGeneration())
+		op.Status.PendingUpgrade = nil
+		op.Status.AvailableUpgrades = nil
+		setUpgradeAvailableStatusConditionUnknown(&op.Status.Conditions, "upgrades have not been evaluated as resolution failed", op.GetGeneration())
+		var unavailableErr *catalogmetadata.UnavailableCatalogsError
+		if errors.As(err, &unavailableErr) {
+			setDegradedStatusConditionTrue(&op.Status.Conditions, unavailableErr.Error(), op.GetGeneration())
+		} else {
+			setDegradedStatusConditionUnknown(&op.Status.Conditions, "catalog availability is unknown as resolution failed", op.GetGeneration())
+		}
+		setDeprecationStatusConditionsUnknown(&op.Status.Conditions, "deprecations have not been evaluated as resolution failed", op.GetGeneration())
 		return ctrl.Result{}, err
 	}
 
+	// Catalogs which could not be read have been left out of the resolution,
+	// which may have picked an older bundle or no bundle at all.
+	if unavailableCatalogs := unavailableCatalogsFromSolution(solution, op.Spec.PackageName); len(unavailableCatalogs) > 0 {
+		unavailableErr := &catalogmetadata.UnavailableCatalogsError{Catalogs: unavailableCatalogs}
+		setDegradedStatusConditionTrue(&op.Status.Conditions, unavailableErr.Error(), op.GetGeneration())
+	} else {
+		setDegradedStatusConditionFalse(&op.Status.Conditions, "all catalogs are available", op.GetGeneration())
+	}
+
 	// TODO: Checking for unsat is awkward using the current version of deppy.
 	//    This awkwardness has been fixed in an unreleased version of deppy.
 	//    When there is a new minor release of deppy, we can revisit this and
@@ -149,9 +265,21 @@ func (r *OperatorReconciler) reconcile(ctx context.Context, op *operatorsv1alpha
 		op.Status.InstalledBundleResource = ""
 		setInstalledStatusConditionUnknown(&op.Status.Conditions, "installation has not been attempted as resolution is unsatisfiable", op.GetGeneration())
 		op.Status.ResolvedBundleResource = ""
-		msg := prettyUnsatMessage(unsat)
+		// the explanation is only as good as the list of Operators,
+		// so it goes on without them if they cannot be listed.
+		operators := &operatorsv1alpha1.OperatorList{}
+		if err := r.Client.List(ctx, operators); err != nil {
+			log.FromContext(ctx).Error(err, "listing operators to explain unsatisfiable resolution")
+		}
+		explanation := explain.NotSatisfiable(unsat, solution.AllVariables(), operators.Items)
+		msg := explanation.Error()
 		setResolvedStatusConditionFailed(&op.Status.Conditions, msg, op.GetGeneration())
-		return ctrl.Result{}, unsat
+		op.Status.PendingUpgrade = nil
+		op.Status.AvailableUpgrades = nil
+		setUpgradeAvailableStatusConditionUnknown(&op.Status.Conditions, "upgrades have not been evaluated as resolution is unsatisfiable", op.GetGeneration())
+		setDeprecationStatusConditionsUnknown(&op.Status.Conditions, "deprecations have not been evaluated as resolution is unsatisfiable", op.GetGeneration())
+		r.Recorder.Event(op, corev1.EventTypeWarning, EventReasonResolutionUnsatisfiable, msg)
+		return ctrl.Result{}, explanation
 	}
 
 	// lookup the bundle in the solution that corresponds to the
@@ -162,32 +290,109 @@ func (r *OperatorReconciler) reconcile(ctx context.Context, op *operatorsv1alpha
 		setInstalledStatusConditionUnknown(&op.Status.Conditions, "installation has not been attempted as resolution failed", op.GetGeneration())
 		op.Status.ResolvedBundleResource = ""
 		setResolvedStatusConditionFailed(&op.Status.Conditions, err.Error(), op.GetGeneration())
+		op.Status.PendingUpgrade = nil
+		op.Status.AvailableUpgrades = nil
+		setUpgradeAvailableStatusConditionUnknown(&op.Status.Conditions, "upgrades have not been evaluated as resolution failed", op.GetGeneration())
+		setDeprecationStatusConditionsUnknown(&op.Status.Conditions, "deprecations have not been evaluated as resolution failed", op.GetGeneration())
+		return ctrl.Result{}, NewTerminalError(err)
+	}
+
+	installedPackage, err := installedPackageFromSolution(solution, op.Spec.PackageName)
+	if err != nil {
+		op.Status.InstalledBundleResource = ""
+		setInstalledStatusConditionUnknown(&op.Status.Conditions, "installation has not been attempted as resolution failed", op.GetGeneration())
+		op.Status.ResolvedBundleResource = ""
+		setResolvedStatusConditionFailed(&op.Status.Conditions, err.Error(), op.GetGeneration())
+		op.Status.PendingUpgrade = nil
+		op.Status.AvailableUpgrades = nil
+		setUpgradeAvailableStatusConditionUnknown(&op.Status.Conditions, "upgrades have not been evaluated as resolution failed", op.GetGeneration())
+		setDeprecationStatusConditionsUnknown(&op.Status.Conditions, "deprecations have not been evaluated as resolution failed", op.GetGeneration())
 		return ctrl.Result{}, err
 	}
+	if installedPackage != nil && installedPackage.InstalledBundle() != nil {
+		bundles[installedPackage.InstalledBundle().Image] = bundleMetadata(installedPackage.InstalledBundle())
+	}
 
 	// Now we can set the Resolved Condition, and the resolvedBundleSource field to the bundle.Image value.
 	op.Status.ResolvedBundleResource = bundle.Image
+	bundles[bundle.Image] = bundleMetadata(bundle)
 	setResolvedStatusConditionSuccess(&op.Status.Conditions, fmt.Sprintf("resolved to %q", bundle.Image), op.GetGeneration())
+	setDeprecationStatusConditions(op, bundle)
+	op.Status.AvailableUpgrades, err = availableUpgradesFromSolution(solution, op.Spec.PackageName)
+	if err != nil {
+		op.Status.PendingUpgrade = nil
+		setUpgradeAvailableStatusConditionUnknown(&op.Status.Conditions, err.Error(), op.GetGeneration())
+		setInstalledStatusConditionUnknown(&op.Status.Conditions, err.Error(), op.GetGeneration())
+		return ctrl.Result{}, NewTerminalError(err)
+	}
 
-	mediaType, err := bundle.MediaType()
+	// With manual upgrade approval, keep the installed bundle around until
+	// the version of the resolved bundle gets approved.
+	pendingUpgrade, installedBundleDeployment, err := r.pendingUpgrade(ctx, op, solution, bundle)
 	if err != nil {
-		setInstalledStatusConditionFailed(&op.Status.Conditions, err.Error(), op.GetGeneration())
+		op.Status.PendingUpgrade = nil
+		setUpgradeAvailableStatusConditionUnknown(&op.Status.Conditions, err.Error(), op.GetGeneration())
+		setInstalledStatusConditionUnknown(&op.Status.Conditions, err.Error(), op.GetGeneration())
 		return ctrl.Result{}, err
 	}
-	bundleProvisioner, err := mapBundleMediaTypeToBundleProvisioner(mediaType)
+	if pendingUpgrade != nil {
+		op.Status.PendingUpgrade = pendingUpgrade
+		setUpgradeAvailableStatusConditionTrue(&op.Status.Conditions, fmt.Sprintf("upgrade to version %q is pending approval", pendingUpgrade.Version), op.GetGeneration())
+		mapBDStatusToInstalledCondition(installedBundleDeployment, op)
+		return ctrl.Result{}, nil
+	}
+	op.Status.PendingUpgrade = nil
+	setUpgradeAvailableStatusConditionFalse(&op.Status.Conditions, "no upgrade is pending approval", op.GetGeneration())
+
+	mediaType, err := bundle.MediaType()
 	if err != nil {
 		setInstalledStatusConditionFailed(&op.Status.Conditions, err.Error(), op.GetGeneration())
-		return ctrl.Result{}, err
+		return ctrl.Result{}, NewTerminalError(err)
 	}
 	// Ensure a BundleDeployment exists with its bundle source from the bundle
 	// image we just looked up in the solution.
-	dep := r.generateExpectedBundleDeployment(*op, bundle.Image, bundleProvisioner)
-	if err := r.ensureBundleDeployment(ctx, dep); err != nil {
+	return r.installBundle(ctx, op, bundle.Image, mediaType)
+}
+
+// reconcileBundleImage installs the bundle image set in the spec of the operator,
+// skipping catalog resolution entirely.
+func (r *OperatorReconciler) reconcileBundleImage(ctx context.Context, op *operatorsv1alpha1.Operator) (ctrl.Result, error) {
+	op.Status.ResolvedBundleResource = op.Spec.BundleImage
+	setResolvedStatusConditionSuccess(&op.Status.Conditions, fmt.Sprintf("resolution skipped, using bundle image %q", op.Spec.BundleImage), op.GetGeneration())
+	op.Status.PendingUpgrade = nil
+	op.Status.AvailableUpgrades = nil
+	setUpgradeAvailableStatusConditionFalse(&op.Status.Conditions, "upgrades are not evaluated for bundle images", op.GetGeneration())
+	setDegradedStatusConditionFalse(&op.Status.Conditions, "catalogs are not used for bundle images", op.GetGeneration())
+	setDeprecationStatusConditionsUnknown(&op.Status.Conditions, "deprecations are not evaluated for bundle images", op.GetGeneration())
+
+	return r.installBundle(ctx, op, op.Spec.BundleImage, op.Spec.BundleMediaType)
+}
+
+// installBundle ensures that a BundleDeployment exists for the bundle image and
+// maps the status of the BundleDeployment to the Installed condition of the operator.
+func (r *OperatorReconciler) installBundle(ctx context.Context, op *operatorsv1alpha1.Operator, bundleImage, mediaType string) (ctrl.Result, error) {
+	bundleProvisioner, err := mapBundleMediaTypeToBundleProvisioner(mediaType)
+	if err != nil {
+		setInstalledStatusConditionFailed(&op.Status.Conditions, err.Error(), op.GetGeneration())
+		return ctrl.Result{}, NewTerminalError(err)
+	}
+	dep := r.generateExpectedBundleDeployment(*op, bundleImage, bundleProvisioner)
+	patched, err := r.ensureBundleDeployment(ctx, dep)
+	if err != nil {
 		// originally Reason: operatorsv1alpha1.ReasonInstallationFailed
 		op.Status.InstalledBundleResource = ""
 		setInstalledStatusConditionFailed(&op.Status.Conditions, err.Error(), op.GetGeneration())
+		// the API server keeps rejecting invalid BundleDeployments, such as
+		// ones with an invalid bundle image reference, until the Operator changes
+		if apierrors.IsInvalid(err) {
+			return ctrl.Result{}, NewTerminalError(err)
+		}
 		return ctrl.Result{}, err
 	}
+	if patched {
+		metrics.BundleDeploymentPatches.WithLabelValues(op.GetName()).Inc()
+		r.Recorder.Eventf(op, corev1.EventTypeNormal, EventReasonBundleDeploymentPatched, "applied bundledeployment %q for bundle %q", dep.GetName(), bundleImage)
+	}
 
 	// convert existing unstructured object into bundleDeployment for easier mapping of status.
 	existingTypedBundleDeployment := &rukpakv1alpha1.BundleDeployment{}
@@ -195,17 +400,161 @@ func (r *OperatorReconciler) reconcile(ctx context.Context, op *operatorsv1alpha
 		// originally Reason: operatorsv1alpha1.ReasonInstallationStatusUnknown
 		op.Status.InstalledBundleResource = ""
 		setInstalledStatusConditionUnknown(&op.Status.Conditions, err.Error(), op.GetGeneration())
-		return ctrl.Result{}, err
+		return ctrl.Result{}, NewTerminalError(err)
 	}
 
 	// Let's set the proper Installed condition and InstalledBundleResource field based on the
 	// existing BundleDeployment object status.
 	mapBDStatusToInstalledCondition(existingTypedBundleDeployment, op)
 
+	// The BundleDeployment is only installed once its bundle is unpacked, which
+	// can take a while, so check on it again rather than only relying on its watch.
+	if isBundleDeploymentUnpacking(existingTypedBundleDeployment) {
+		return ctrl.Result{}, NewWaitingError(fmt.Errorf("bundledeployment %q is unpacking its bundle", existingTypedBundleDeployment.GetName()), unpackingRequeueAfter)
+	}
+
 	// set the status of the operator based on the respective bundle deployment status conditions.
 	return ctrl.Result{}, nil
 }
 
+// reconcileDelete tears down the BundleDeployment of an Operator which is being deleted.
+// The cleanup finalizer is only released once the BundleDeployment is gone, which means
+// that rukpak has finished removing the installed content.
+func (r *OperatorReconciler) reconcileDelete(ctx context.Context, op *operatorsv1alpha1.Operator) (ctrl.Result, error) {
+	if !controllerutil.ContainsFinalizer(op, operatorsv1alpha1.CleanupFinalizer) {
+		return ctrl.Result{}, nil
+	}
+
+	bundleDeployment := &rukpakv1alpha1.BundleDeployment{}
+	err := r.Client.Get(ctx, types.NamespacedName{Name: op.GetName()}, bundleDeployment)
+	if apierrors.IsNotFound(err) || (err == nil && !metav1.IsControlledBy(bundleDeployment, op)) {
+		// Either the BundleDeployment is gone or it was never
+		// created by this Operator. Nothing left to clean up.
+		op.Status.InstalledBundleResource = ""
+		controllerutil.RemoveFinalizer(op, operatorsv1alpha1.CleanupFinalizer)
+		return ctrl.Result{}, nil
+	}
+	if err != nil {
+		setDeletingStatusConditionTrue(&op.Status.Conditions, err.Error(), op.GetGeneration())
+		return ctrl.Result{}, err
+	}
+
+	if bundleDeployment.GetDeletionTimestamp().IsZero() {
+		if err := r.Client.Delete(ctx, bundleDeployment); client.IgnoreNotFound(err) != nil {
+			setDeletingStatusConditionTrue(&op.Status.Conditions, err.Error(), op.GetGeneration())
+			return ctrl.Result{}, err
+		}
+	}
+
+	// The Operator owns the BundleDeployment, so we get another
+	// reconcile once the BundleDeployment has been removed.
+	setDeletingStatusConditionTrue(&op.Status.Conditions, fmt.Sprintf("waiting for bundledeployment %q to be removed", bundleDeployment.GetName()), op.GetGeneration())
+	return ctrl.Result{}, nil
+}
+
+// pendingUpgrade returns the bundle the operator is waiting to be upgraded to, along with
+// the currently installed BundleDeployment. It returns nil if the resolved bundle can be
+// applied, which is the case when upgrades are automatic, nothing is installed yet, the
+// resolved bundle is already installed or the version of the resolved bundle is approved.
+// When a version is approved, resolution only upgrades to that version, holding back
+// the upgrade it would have picked otherwise, which is then the pending upgrade.
+func (r *OperatorReconciler) pendingUpgrade(ctx context.Context, op *operatorsv1alpha1.Operator, solution *solver.Solution, bundle *catalogmetadata.Bundle) (*operatorsv1alpha1.BundleMetadata, *rukpakv1alpha1.BundleDeployment, error) {
+	if op.Spec.UpgradeApproval != operatorsv1alpha1.UpgradeApprovalManual {
+		return nil, nil, nil
+	}
+
+	bundleDeployment := &rukpakv1alpha1.BundleDeployment{}
+	if err := r.Client.Get(ctx, types.NamespacedName{Name: op.GetName()}, bundleDeployment); err != nil {
+		return nil, nil, client.IgnoreNotFound(err)
+	}
+	source := bundleDeployment.Spec.Template.Spec.Source
+	if !metav1.IsControlledBy(bundleDeployment, op) || source.Type != rukpakv1alpha1.SourceTypeImage || source.Image == nil {
+		return nil, nil, nil
+	}
+	if source.Image.Ref == bundle.Image {
+		heldBackUpgrade, err := pendingUpgradeFromSolution(solution, op.Spec.PackageName)
+		if err != nil || heldBackUpgrade == nil {
+			return nil, nil, err
+		}
+		bundle = heldBackUpgrade
+	}
+
+	version, err := bundle.Version()
+	if err != nil {
+		return nil, nil, NewTerminalError(err)
+	}
+	if op.Spec.ApprovedVersion != "" {
+		approvedVersion, err := bsemver.Parse(op.Spec.ApprovedVersion)
+		if err != nil {
+			return nil, nil, NewTerminalError(fmt.Errorf("invalid .spec.approvedVersion: %w", err))
+		}
+		if version.Equals(approvedVersion) {
+			return nil, nil, nil
+		}
+	}
+
+	return &operatorsv1alpha1.BundleMetadata{
+		Name:    bundle.Name,
+		Version: version.String(),
+	}, bundleDeployment, nil
+}
+
+// pendingUpgradeFromSolution returns the upgrade of the installed bundle of the package
+// which resolution held back because it is not the approved version, if any.
+func pendingUpgradeFromSolution(solution *solver.Solution, packageName string) (*catalogmetadata.Bundle, error) {
+	installedPackage, err := installedPackageFromSolution(solution, packageName)
+	if err != nil || installedPackage == nil {
+		return nil, err
+	}
+	return installedPackage.PendingUpgrade(), nil
+}
+
+// installedPackageFromSolution returns the installed package variable of the package,
+// if the package is installed.
+func installedPackageFromSolution(solution *solver.Solution, packageName string) (*olmvariables.InstalledPackageVariable, error) {
+	variable, ok := solution.SelectedVariables()[olmvariables.InstalledPackageVariableID(packageName)]
+	if !ok {
+		return nil, nil
+	}
+	installedPackage, ok := variable.(*olmvariables.InstalledPackageVariable)
+	if !ok {
+		return nil, NewTerminalError(fmt.Errorf("unexpected variable type %T for installed package %q", variable, packageName))
+	}
+	return installedPackage, nil
+}
+
+// bundleMetadata returns the name and version of a catalog bundle. The version
+// is left empty if it is invalid, as the metadata only describes the bundle.
+func bundleMetadata(bundle *catalogmetadata.Bundle) *operatorsv1alpha1.BundleMetadata {
+	metadata := &operatorsv1alpha1.BundleMetadata{Name: bundle.Name}
+	if version, err := bundle.Version(); err == nil {
+		metadata.Version = version.String()
+	}
+	return metadata
+}
+
+// metricsBundle returns the bundle of the image recorded in the metrics: nil without image,
+// and without name nor version for images which are not catalog bundles, such as bundle images.
+func metricsBundle(bundles map[string]*operatorsv1alpha1.BundleMetadata, image string) *operatorsv1alpha1.BundleMetadata {
+	if image == "" {
+		return nil
+	}
+	if bundle, ok := bundles[image]; ok {
+		return bundle
+	}
+	return &operatorsv1alpha1.BundleMetadata{}
+}
+
+// isBundleDeploymentUnpacking returns true if rukpak is
+// still unpacking the bundle of the BundleDeployment.
+func isBundleDeploymentUnpacking(bundleDeployment *rukpakv1alpha1.BundleDeployment) bool {
+	hasValidBundle := apimeta.FindStatusCondition(bundleDeployment.Status.Conditions, rukpakv1alpha1.TypeHasValidBundle)
+	if hasValidBundle == nil || hasValidBundle.Status == metav1.ConditionTrue {
+		return false
+	}
+	return hasValidBundle.Reason == rukpakv1alpha1.ReasonUnpackPending || hasValidBundle.Reason == rukpakv1alpha1.ReasonUnpacking
+}
+
 func mapBDStatusToInstalledCondition(existingTypedBundleDeployment *rukpakv1alpha1.BundleDeployment, op *operatorsv1alpha1.Operator) {
 	bundleDeploymentReady := apimeta.FindStatusCondition(existingTypedBundleDeployment.Status.Conditions, rukpakv1alpha1.TypeInstalled)
 	if bundleDeploymentReady == nil {
@@ -264,6 +613,53 @@ func (r *OperatorReconciler) bundleFromSolution(solution *solver.Solution, packa
 	return nil, fmt.Errorf("bundle for package %q not found in solution", packageName)
 }
 
+// unavailableCatalogsFromSolution returns the catalogs which could not be read
+// while looking for the bundles of the given package.
+func unavailableCatalogsFromSolution(solution *solver.Solution, packageName string) []catalogmetadata.UnavailableCatalog {
+	var packageVariables []deppy.Variable
+	for _, variable := range solution.AllVariables() {
+		switch variable.Identifier() {
+		case olmvariables.RequiredPackageVariableID(packageName), olmvariables.InstalledPackageVariableID(packageName):
+			packageVariables = append(packageVariables, variable)
+		}
+	}
+	return olmvariables.UnavailableCatalogs(packageVariables...)
+}
+
+// availableUpgradesFromSolution returns the upgrades available for the installed bundle
+// of the package. It returns nil if the package is not installed.
+func availableUpgradesFromSolution(solution *solver.Solution, packageName string) ([]operatorsv1alpha1.AvailableUpgrade, error) {
+	variable, ok := solution.SelectedVariables()[olmvariables.InstalledPackageVariableID(packageName)]
+	if !ok {
+		return nil, nil
+	}
+	installedPackage, ok := variable.(*olmvariables.InstalledPackageVariable)
+	if !ok {
+		return nil, fmt.Errorf("unexpected variable type %T for installed package %q", variable, packageName)
+	}
+
+	var availableUpgrades []operatorsv1alpha1.AvailableUpgrade
+	for _, bundle := range installedPackage.AvailableUpgrades() {
+		version, err := bundle.Version()
+		if err != nil {
+			return nil, err
+		}
+		var channels []string
+		for _, channel := range bundle.InChannels {
+			channels = append(channels, channel.Name)
+		}
+		availableUpgrades = append(availableUpgrades, operatorsv1alpha1.AvailableUpgrade{
+			BundleMetadata: operatorsv1alpha1.BundleMetadata{
+				Name:    bundle.Name,
+				Version: version.String(),
+			},
+			Channels: channels,
+			Catalog:  bundle.CatalogName,
+		})
+	}
+	return availableUpgrades, nil
+}
+
 func (r *OperatorReconciler) generateExpectedBundleDeployment(o operatorsv1alpha1.Operator, bundlePath string, bundleProvisioner string) *unstructured.Unstructured {
 	// We use unstructured here to avoid problems of serializing default values when sending patches to the apiserver.
 	// If you use a typed object, any default values from that struct get serialized into the JSON patch, which could
@@ -294,6 +690,9 @@ func (r *OperatorReconciler) generateExpectedBundleDeployment(o operatorsv1alpha
 			},
 		},
 	}}
+	if o.Spec.BundleImage != "" {
+		bd.SetAnnotations(map[string]string{operatorsv1alpha1.BundleImageAnnotation: "true"})
+	}
 	bd.SetOwnerReferences([]metav1.OwnerReference{
 		{
 			APIVersion:         operatorsv1alpha1.GroupVersion.String(),
@@ -312,7 +711,7 @@ func (r *OperatorReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	err := ctrl.NewControllerManagedBy(mgr).
 		For(&operatorsv1alpha1.Operator{}).
 		Watches(source.NewKindWithCache(&catalogd.Catalog{}, mgr.GetCache()),
-			handler.EnqueueRequestsFromMapFunc(operatorRequestsForCatalog(context.TODO(), mgr.GetClient(), mgr.GetLogger()))).
+			catalogEventHandler(context.TODO(), mgr.GetClient(), mgr.GetLogger())).
 		Owns(&rukpakv1alpha1.BundleDeployment{}).
 		Complete(r)
 
@@ -322,7 +721,9 @@ func (r *OperatorReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return nil
 }
 
-func (r *OperatorReconciler) ensureBundleDeployment(ctx context.Context, desiredBundleDeployment *unstructured.Unstructured) error {
+// ensureBundleDeployment applies the desired BundleDeployment and reports
+// whether a patch was sent to the API server.
+func (r *OperatorReconciler) ensureBundleDeployment(ctx context.Context, desiredBundleDeployment *unstructured.Unstructured) (bool, error) {
 	// TODO: what if there happens to be an unrelated BD with the same name as the Operator?
 	//   we should probably also check to see if there's an owner reference and/or a label set
 	//   that we expect only to ever be used by the operator controller. That way, we don't
@@ -330,17 +731,20 @@ func (r *OperatorReconciler) ensureBundleDeployment(ctx context.Context, desired
 	//   owned by the Operator.
 	existingBundleDeployment, err := r.existingBundleDeploymentUnstructured(ctx, desiredBundleDeployment.GetName())
 	if client.IgnoreNotFound(err) != nil {
-		return err
+		return false, err
 	}
 
 	// If the existing BD already has everything that the desired BD has, no need to contact the API server.
 	// Make sure the status of the existingBD from the server is as expected.
 	if equality.Semantic.DeepDerivative(desiredBundleDeployment, existingBundleDeployment) {
 		*desiredBundleDeployment = *existingBundleDeployment
-		return nil
+		return false, nil
 	}
 
-	return r.Client.Patch(ctx, desiredBundleDeployment, client.Apply, client.ForceOwnership, client.FieldOwner("operator-controller"))
+	if err := r.Client.Patch(ctx, desiredBundleDeployment, client.Apply, client.ForceOwnership, client.FieldOwner("operator-controller")); err != nil {
+		return false, err
+	}
+	return true, nil
 }
 
 func (r *OperatorReconciler) existingBundleDeploymentUnstructured(ctx context.Context, name string) (*unstructured.Unstructured, error) {
@@ -408,6 +812,151 @@ func setResolvedStatusConditionUnknown(conditions *[]metav1.Condition, message s
 	})
 }
 
+// setUpgradeAvailableStatusConditionTrue sets the upgrade available status condition to true.
+func setUpgradeAvailableStatusConditionTrue(conditions *[]metav1.Condition, message string, generation int64) {
+	apimeta.SetStatusCondition(conditions, metav1.Condition{
+		Type:               operatorsv1alpha1.TypeUpgradeAvailable,
+		Status:             metav1.ConditionTrue,
+		Reason:             operatorsv1alpha1.ReasonUpgradePending,
+		Message:            message,
+		ObservedGeneration: generation,
+	})
+}
+
+// setUpgradeAvailableStatusConditionFalse sets the upgrade available status condition to false.
+func setUpgradeAvailableStatusConditionFalse(conditions *[]metav1.Condition, message string, generation int64) {
+	apimeta.SetStatusCondition(conditions, metav1.Condition{
+		Type:               operatorsv1alpha1.TypeUpgradeAvailable,
+		Status:             metav1.ConditionFalse,
+		Reason:             operatorsv1alpha1.ReasonNoUpgradePending,
+		Message:            message,
+		ObservedGeneration: generation,
+	})
+}
+
+// setUpgradeAvailableStatusConditionUnknown sets the upgrade available status condition to unknown.
+func setUpgradeAvailableStatusConditionUnknown(conditions *[]metav1.Condition, message string, generation int64) {
+	apimeta.SetStatusCondition(conditions, metav1.Condition{
+		Type:               operatorsv1alpha1.TypeUpgradeAvailable,
+		Status:             metav1.ConditionUnknown,
+		Reason:             operatorsv1alpha1.ReasonUpgradeStatusUnknown,
+		Message:            message,
+		ObservedGeneration: generation,
+	})
+}
+
+// setDegradedStatusConditionTrue sets the degraded status condition to true.
+func setDegradedStatusConditionTrue(conditions *[]metav1.Condition, message string, generation int64) {
+	apimeta.SetStatusCondition(conditions, metav1.Condition{
+		Type:               operatorsv1alpha1.TypeDegraded,
+		Status:             metav1.ConditionTrue,
+		Reason:             operatorsv1alpha1.ReasonCatalogsUnavailable,
+		Message:            message,
+		ObservedGeneration: generation,
+	})
+}
+
+// setDegradedStatusConditionFalse sets the degraded status condition to false.
+func setDegradedStatusConditionFalse(conditions *[]metav1.Condition, message string, generation int64) {
+	apimeta.SetStatusCondition(conditions, metav1.Condition{
+		Type:               operatorsv1alpha1.TypeDegraded,
+		Status:             metav1.ConditionFalse,
+		Reason:             operatorsv1alpha1.ReasonCatalogsAvailable,
+		Message:            message,
+		ObservedGeneration: generation,
+	})
+}
+
+// setDegradedStatusConditionUnknown sets the degraded status condition to unknown.
+func setDegradedStatusConditionUnknown(conditions *[]metav1.Condition, message string, generation int64) {
+	apimeta.SetStatusCondition(conditions, metav1.Condition{
+		Type:               operatorsv1alpha1.TypeDegraded,
+		Status:             metav1.ConditionUnknown,
+		Reason:             operatorsv1alpha1.ReasonCatalogStatusUnknown,
+		Message:            message,
+		ObservedGeneration: generation,
+	})
+}
+
+// setDeprecationStatusConditions sets the deprecation status conditions from the
+// deprecations the catalog publishes for the package, the channel and the bundle
+// the operator resolved to. The Deprecated condition sums them up.
+func setDeprecationStatusConditions(op *operatorsv1alpha1.Operator, bundle *catalogmetadata.Bundle) {
+	var messages []string
+	setDeprecationStatusCondition := func(conditionType string, deprecation *catalogmetadata.DeprecationEntry, notDeprecatedMessage string) {
+		if deprecation == nil {
+			apimeta.SetStatusCondition(&op.Status.Conditions, metav1.Condition{
+				Type:               conditionType,
+				Status:             metav1.ConditionFalse,
+				Reason:             operatorsv1alpha1.ReasonNotDeprecated,
+				Message:            notDeprecatedMessage,
+				ObservedGeneration: op.GetGeneration(),
+			})
+			return
+		}
+		messages = append(messages, deprecation.Message)
+		apimeta.SetStatusCondition(&op.Status.Conditions, metav1.Condition{
+			Type:               conditionType,
+			Status:             metav1.ConditionTrue,
+			Reason:             operatorsv1alpha1.ReasonDeprecated,
+			Message:            deprecation.Message,
+			ObservedGeneration: op.GetGeneration(),
+		})
+	}
+
+	setDeprecationStatusCondition(operatorsv1alpha1.TypePackageDeprecated, bundle.PackageDeprecation(),
+		fmt.Sprintf("package %q is not deprecated", bundle.Package))
+	// resolution falls back to the default channel of the package when no channel is specified
+	channel := op.Spec.Channel
+	if channel == "" && bundle.CatalogPackage != nil {
+		channel = bundle.CatalogPackage.DefaultChannel
+	}
+	if channel != "" {
+		setDeprecationStatusCondition(operatorsv1alpha1.TypeChannelDeprecated, bundle.ChannelDeprecation(channel),
+			fmt.Sprintf("channel %q is not deprecated", channel))
+	} else {
+		setDeprecationStatusCondition(operatorsv1alpha1.TypeChannelDeprecated, nil, "no channel is specified")
+	}
+	setDeprecationStatusCondition(operatorsv1alpha1.TypeBundleDeprecated, bundle.BundleDeprecation(),
+		fmt.Sprintf("bundle %q is not deprecated", bundle.Name))
+
+	if len(messages) == 0 {
+		apimeta.SetStatusCondition(&op.Status.Conditions, metav1.Condition{
+			Type:               operatorsv1alpha1.TypeDeprecated,
+			Status:             metav1.ConditionFalse,
+			Reason:             operatorsv1alpha1.ReasonNotDeprecated,
+			Message:            "neither the package, the channel nor the bundle are deprecated",
+			ObservedGeneration: op.GetGeneration(),
+		})
+		return
+	}
+	apimeta.SetStatusCondition(&op.Status.Conditions, metav1.Condition{
+		Type:               operatorsv1alpha1.TypeDeprecated,
+		Status:             metav1.ConditionTrue,
+		Reason:             operatorsv1alpha1.ReasonDeprecated,
+		Message:            strings.Join(messages, "\n"),
+		ObservedGeneration: op.GetGeneration(),
+	})
+}
+
+// setDeprecationStatusConditionsUnknown sets all the deprecation status conditions to unknown.
+func setDeprecationStatusConditionsUnknown(conditions *[]metav1.Condition, message string, generation int64) {
+	for _, conditionType := range []string{
+		operatorsv1alpha1.TypeDeprecated,
+		operatorsv1alpha1.TypePackageDeprecated,
+		operatorsv1alpha1.TypeChannelDeprecated,
+		operatorsv1alpha1.TypeBundleDeprecated,
+	} {
+		apimeta.SetStatusCondition(conditions, metav1.Condition{
+			Type:               conditionType,
+			Status:             metav1.ConditionUnknown,
+			Reason:             operatorsv1alpha1.ReasonDeprecationStatusUnknown,
+			Message:            message,
+			ObservedGeneration: generation,
+		})
+	}
+}
+
 // setInstalledStatusConditionSuccess sets the installed status condition to success.
 func setInstalledStatusConditionSuccess(conditions *[]metav1.Condition, message string, generation int64) {
 	apimeta.SetStatusCondition(conditions, metav1.Condition{
@@ -441,10 +990,57 @@ func setInstalledStatusConditionUnknown(conditions *[]metav1.Condition, message
 	})
 }
 
-// Generate reconcile requests for all operators affected by a catalog change
-func operatorRequestsForCatalog(ctx context.Context, c client.Reader, logger logr.Logger) handler.MapFunc {
-	return func(object client.Object) []reconcile.Request {
-		// no way of associating an operator to a catalog so create reconcile requests for everything
+// setDeletingStatusConditionTrue sets the deleting status condition to true.
+func setDeletingStatusConditionTrue(conditions *[]metav1.Condition, message string, generation int64) {
+	apimeta.SetStatusCondition(conditions, metav1.Condition{
+		Type:               operatorsv1alpha1.TypeDeleting,
+		Status:             metav1.ConditionTrue,
+		Reason:             operatorsv1alpha1.ReasonWaitingForBundleDeploymentRemoval,
+		Message:            message,
+		ObservedGeneration: generation,
+	})
+}
+
+// setDeletingStatusConditionFalse sets the deleting status condition to false.
+func setDeletingStatusConditionFalse(conditions *[]metav1.Condition, message string, generation int64) {
+	apimeta.SetStatusCondition(conditions, metav1.Condition{
+		Type:               operatorsv1alpha1.TypeDeleting,
+		Status:             metav1.ConditionFalse,
+		Reason:             operatorsv1alpha1.ReasonDeletionNotRequested,
+		Message:            message,
+		ObservedGeneration: generation,
+	})
+}
+
+// catalogEventHandler enqueues the operators affected by a catalog event.
+// On update, operators selecting either the old or the new catalog are enqueued,
+// so that operators which no longer select a changed catalog are resolved again.
+func catalogEventHandler(ctx context.Context, c client.Reader, logger logr.Logger) handler.EventHandler {
+	requestsFor := operatorRequestsForCatalogs(ctx, c, logger)
+	enqueue := func(q workqueue.RateLimitingInterface, requests []reconcile.Request) {
+		for _, req := range requests {
+			q.Add(req)
+		}
+	}
+	return handler.Funcs{
+		CreateFunc: func(e event.CreateEvent, q workqueue.RateLimitingInterface) {
+			enqueue(q, requestsFor(e.Object))
+		},
+		UpdateFunc: func(e event.UpdateEvent, q workqueue.RateLimitingInterface) {
+			enqueue(q, requestsFor(e.ObjectOld, e.ObjectNew))
+		},
+		DeleteFunc: func(e event.DeleteEvent, q workqueue.RateLimitingInterface) {
+			enqueue(q, requestsFor(e.Object))
+		},
+		GenericFunc: func(e event.GenericEvent, q workqueue.RateLimitingInterface) {
+			enqueue(q, requestsFor(e.Object))
+		},
+	}
+}
+
+// Generate reconcile requests for all operators affected by a change to any of the catalogs
+func operatorRequestsForCatalogs(ctx context.Context, c client.Reader, logger logr.Logger) func(catalogs ...client.Object) []reconcile.Request {
+	return func(catalogs ...client.Object) []reconcile.Request {
 		operators := operatorsv1alpha1.OperatorList{}
 		err := c.List(ctx, &operators)
 		if err != nil {
@@ -453,6 +1049,12 @@ func operatorRequestsForCatalog(ctx context.Context, c client.Reader, logger log
 		}
 		var requests []reconcile.Request
 		for _, op := range operators.Items {
+			// only enqueue operators which can source their package from the catalog
+			if !slices.ContainsFunc(catalogs, func(catalog client.Object) bool {
+				return catalogSelectorMatches(op.Spec.CatalogSelector, catalog)
+			}) {
+				continue
+			}
 			requests = append(requests, reconcile.Request{
 				NamespacedName: types.NamespacedName{
 					Namespace: op.GetNamespace(),
@@ -464,22 +1066,22 @@ func operatorRequestsForCatalog(ctx context.Context, c client.Reader, logger log
 	}
 }
 
-// TODO: This can be removed when operator controller bumps to a
-//    version of deppy that contains a fix for this issue:
-//    https://github.com/operator-framework/deppy/issues/142
-
-// prettyUnsatMessage ensures that the unsat message is deterministic and
-// human-readable. It sorts the individual constraint strings lexicographically
-// and joins them with a semicolon (rather than a comma, which the unsat.Error()
-// function does). This function also has the side effect of sorting the items
-// in the unsat slice.
-func prettyUnsatMessage(unsat deppy.NotSatisfiable) string {
-	sort.Slice(unsat, func(i, j int) bool {
-		return unsat[i].String() < unsat[j].String()
-	})
-	msgs := make([]string, 0, len(unsat))
-	for _, c := range unsat {
-		msgs = append(msgs, c.String())
+// catalogSelectorMatches returns true if the catalog is selected by the selector.
+// A nil selector selects every catalog. An invalid label selector selects
+// every catalog as well, so that the resolution error surfaces on the Operator.
+func catalogSelectorMatches(selector *operatorsv1alpha1.CatalogSelector, catalog client.Object) bool {
+	if selector == nil {
+		return true
+	}
+	if len(selector.Names) > 0 && !slices.Contains(selector.Names, catalog.GetName()) {
+		return false
+	}
+	if selector.LabelSelector != nil {
+		labelSelector, err := metav1.LabelSelectorAsSelector(selector.LabelSelector)
+		if err != nil {
+			return true
+		}
+		return labelSelector.Matches(labels.Set(catalog.GetLabels()))
 	}
-	return fmt.Sprintf("constraints not satisfiable: %s", strings.Join(msgs, "; "))
+	return true
 }
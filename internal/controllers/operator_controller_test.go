@@ -3,8 +3,10 @@ package controllers_test
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"testing"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -14,10 +16,12 @@ import (
 	rukpakv1alpha1 "github.com/operator-framework/rukpak/api/v1alpha1"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/client-go/tools/record"
 	featuregatetesting "k8s.io/component-base/featuregate/testing"
 	"k8s.io/utils/pointer"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -26,6 +30,7 @@ import (
 
 	operatorsv1alpha1 "github.com/operator-framework/operator-controller/api/v1alpha1"
 	"github.com/operator-framework/operator-controller/internal/catalogmetadata"
+	"github.com/operator-framework/operator-controller/internal/clusterversion"
 	"github.com/operator-framework/operator-controller/internal/conditionsets"
 	"github.com/operator-framework/operator-controller/internal/controllers"
 	"github.com/operator-framework/operator-controller/pkg/features"
@@ -44,7 +49,8 @@ var _ = Describe("Operator Controller Test", func() {
 		reconciler = &controllers.OperatorReconciler{
 			Client:   cl,
 			Scheme:   sch,
-			Resolver: solver.NewDeppySolver(controllers.NewVariableSource(cl, &fakeCatalogClient)),
+			Resolver: solver.NewDeppySolver(controllers.NewVariableSource(cl, &fakeCatalogClient, clusterversion.Static{})),
+			Recorder: record.NewFakeRecorder(1000),
 		}
 	})
 	When("the operator does not exist", func() {
@@ -178,6 +184,12 @@ var _ = Describe("Operator Controller Test", func() {
 				It("sets the InstalledBundleResource status field", func() {
 					Expect(operator.Status.InstalledBundleResource).To(Equal(""))
 				})
+				It("records events for the resolution and the bundledeployment patch", func() {
+					recorder := reconciler.Recorder.(*record.FakeRecorder)
+					Expect(recorder.Events).To(Receive(Equal(`Normal BundleDeploymentPatched applied bundledeployment "` + opKey.Name + `" for bundle "quay.io/operatorhubio/prometheus@fake2.0.0"`)))
+					Expect(recorder.Events).To(Receive(Equal(`Normal ResolvedBundleChanged resolved bundle changed to "quay.io/operatorhubio/prometheus@fake2.0.0"`)))
+					Expect(recorder.Events).To(Receive(Equal("Normal InstalledConditionChanged Installed=Unknown (InstallationStatusUnknown): bundledeployment status is unknown")))
+				})
 				It("sets the status on operator", func() {
 					cond := apimeta.FindStatusCondition(operator.Status.Conditions, operatorsv1alpha1.TypeResolved)
 					Expect(cond).NotTo(BeNil())
@@ -480,6 +492,36 @@ var _ = Describe("Operator Controller Test", func() {
 							Expect(cond.Message).To(Equal("bundledeployment not ready: installing"))
 						})
 
+						It("verify operator requeues while the bundle of the bundleDeployment is unpacking", func() {
+							apimeta.SetStatusCondition(&bd.Status.Conditions, metav1.Condition{
+								Type:    rukpakv1alpha1.TypeHasValidBundle,
+								Status:  metav1.ConditionFalse,
+								Message: "unpacking",
+								Reason:  rukpakv1alpha1.ReasonUnpacking,
+							})
+
+							By("updating the status of bundleDeployment")
+							err := cl.Status().Update(ctx, bd)
+							Expect(err).NotTo(HaveOccurred())
+
+							By("running reconcile")
+							res, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: opKey})
+							Expect(res).To(Equal(ctrl.Result{RequeueAfter: 10 * time.Second}))
+							Expect(err).NotTo(HaveOccurred())
+
+							By("fetching the updated operator after reconcile")
+							op := &operatorsv1alpha1.Operator{}
+							err = cl.Get(ctx, opKey, op)
+							Expect(err).NotTo(HaveOccurred())
+
+							By("checking the expected conditions")
+							cond := apimeta.FindStatusCondition(op.Status.Conditions, operatorsv1alpha1.TypeInstalled)
+							Expect(cond).NotTo(BeNil())
+							Expect(cond.Status).To(Equal(metav1.ConditionUnknown))
+							Expect(cond.Reason).To(Equal(operatorsv1alpha1.ReasonInstallationStatusUnknown))
+							Expect(cond.Message).To(Equal("bundledeployment status is unknown"))
+						})
+
 						It("verify operator status when bundleDeployment installation status is unknown", func() {
 							apimeta.SetStatusCondition(&bd.Status.Conditions, metav1.Condition{
 								Type:    rukpakv1alpha1.TypeInstalled,
@@ -954,8 +996,8 @@ var _ = Describe("Operator Controller Test", func() {
 				By("running reconcile")
 				res, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: opKey})
 				Expect(res).To(Equal(ctrl.Result{}))
-				Expect(err).To(HaveOccurred())
-				Expect(err.Error()).To(Equal("unknown bundle mediatype: badmedia+v1"))
+				By("not retrying, as the bundle mediatype is not going to change")
+				Expect(err).NotTo(HaveOccurred())
 
 				By("fetching updated operator after reconcile")
 				Expect(cl.Get(ctx, opKey, operator)).NotTo(HaveOccurred())
@@ -1059,7 +1101,8 @@ func TestOperatorUpgrade(t *testing.T) {
 	reconciler := &controllers.OperatorReconciler{
 		Client:   cl,
 		Scheme:   sch,
-		Resolver: solver.NewDeppySolver(controllers.NewVariableSource(cl, &fakeCatalogClient)),
+		Resolver: solver.NewDeppySolver(controllers.NewVariableSource(cl, &fakeCatalogClient, clusterversion.Static{})),
+		Recorder: record.NewFakeRecorder(1000),
 	}
 
 	t.Run("semver upgrade constraints", func(t *testing.T) {
@@ -1128,7 +1171,7 @@ func TestOperatorUpgrade(t *testing.T) {
 		assert.Equal(t, metav1.ConditionFalse, cond.Status)
 		assert.Equal(t, operatorsv1alpha1.ReasonResolutionFailed, cond.Reason)
 		assert.Contains(t, cond.Message, "constraints not satisfiable")
-		assert.Contains(t, cond.Message, "installed package prometheus requires at least one of fake-catalog-prometheus-operatorhub/prometheus/beta/1.2.0, fake-catalog-prometheus-operatorhub/prometheus/beta/1.0.1, fake-catalog-prometheus-operatorhub/prometheus/beta/1.0.0;")
+		assert.Contains(t, cond.Message, fmt.Sprintf("operator %q has prometheus 1.0.0 installed, which can only be kept;", opKey.Name))
 
 		// Valid update skipping one version
 		operator.Spec.Version = "1.2.0"
@@ -1221,7 +1264,7 @@ func TestOperatorUpgrade(t *testing.T) {
 		assert.Equal(t, metav1.ConditionFalse, cond.Status)
 		assert.Equal(t, operatorsv1alpha1.ReasonResolutionFailed, cond.Reason)
 		assert.Contains(t, cond.Message, "constraints not satisfiable")
-		assert.Contains(t, cond.Message, "installed package prometheus requires at least one of fake-catalog-prometheus-operatorhub/prometheus/beta/1.0.1, fake-catalog-prometheus-operatorhub/prometheus/beta/1.0.0;")
+		assert.Contains(t, cond.Message, fmt.Sprintf("operator %q has prometheus 1.0.0 installed, which can only be kept;", opKey.Name))
 
 		// Valid update skipping one version
 		operator.Spec.Version = "1.0.1"
@@ -1247,6 +1290,519 @@ func TestOperatorUpgrade(t *testing.T) {
 		assert.Equal(t, operatorsv1alpha1.ReasonSuccess, cond.Reason)
 		assert.Equal(t, `resolved to "quay.io/operatorhubio/prometheus@fake1.0.1"`, cond.Message)
 	})
+
+	t.Run("ignore upgrade constraints", func(t *testing.T) {
+		defer func() {
+			require.NoError(t, cl.DeleteAllOf(ctx, &operatorsv1alpha1.Operator{}))
+			require.NoError(t, cl.DeleteAllOf(ctx, &rukpakv1alpha1.BundleDeployment{}))
+		}()
+
+		opKey := types.NamespacedName{Name: fmt.Sprintf("operator-test-%s", rand.String(8))}
+		operator := &operatorsv1alpha1.Operator{
+			ObjectMeta: metav1.ObjectMeta{Name: opKey.Name},
+			Spec: operatorsv1alpha1.OperatorSpec{
+				PackageName:             "prometheus",
+				Version:                 "1.2.0",
+				Channel:                 "beta",
+				UpgradeConstraintPolicy: operatorsv1alpha1.UpgradeConstraintPolicyIgnore,
+			},
+		}
+		// Create an operator
+		err := cl.Create(ctx, operator)
+		require.NoError(t, err)
+
+		// Run reconcile
+		res, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: opKey})
+		require.NoError(t, err)
+		assert.Equal(t, ctrl.Result{}, res)
+
+		// Refresh the operator after reconcile
+		err = cl.Get(ctx, opKey, operator)
+		require.NoError(t, err)
+
+		// Checking the status fields
+		assert.Equal(t, "quay.io/operatorhubio/prometheus@fake1.2.0", operator.Status.ResolvedBundleResource)
+
+		// Downgrade, which is not part of the upgrade graph
+		operator.Spec.Version = "1.0.0"
+		err = cl.Update(ctx, operator)
+		require.NoError(t, err)
+
+		// Run reconcile again
+		res, err = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: opKey})
+		require.NoError(t, err)
+		assert.Equal(t, ctrl.Result{}, res)
+
+		// Refresh the operator after reconcile
+		err = cl.Get(ctx, opKey, operator)
+		require.NoError(t, err)
+
+		// Checking the status fields
+		assert.Equal(t, "quay.io/operatorhubio/prometheus@fake1.0.0", operator.Status.ResolvedBundleResource)
+
+		// checking the expected conditions
+		cond := apimeta.FindStatusCondition(operator.Status.Conditions, operatorsv1alpha1.TypeResolved)
+		require.NotNil(t, cond)
+		assert.Equal(t, metav1.ConditionTrue, cond.Status)
+		assert.Equal(t, operatorsv1alpha1.ReasonSuccess, cond.Reason)
+		assert.Equal(t, `resolved to "quay.io/operatorhubio/prometheus@fake1.0.0"`, cond.Message)
+	})
+}
+
+func TestOperatorManualUpgradeApproval(t *testing.T) {
+	defer featuregatetesting.SetFeatureGateDuringTest(t, features.OperatorControllerFeatureGate, features.ForceSemverUpgradeConstraints, true)()
+	ctx := context.Background()
+	fakeCatalogClient := testutil.NewFakeCatalogClient(testBundleList)
+	reconciler := &controllers.OperatorReconciler{
+		Client:   cl,
+		Scheme:   sch,
+		Resolver: solver.NewDeppySolver(controllers.NewVariableSource(cl, &fakeCatalogClient, clusterversion.Static{})),
+		Recorder: record.NewFakeRecorder(1000),
+	}
+	defer func() {
+		require.NoError(t, cl.DeleteAllOf(ctx, &operatorsv1alpha1.Operator{}))
+		require.NoError(t, cl.DeleteAllOf(ctx, &rukpakv1alpha1.BundleDeployment{}))
+	}()
+
+	opKey := types.NamespacedName{Name: fmt.Sprintf("operator-test-%s", rand.String(8))}
+	operator := &operatorsv1alpha1.Operator{
+		ObjectMeta: metav1.ObjectMeta{Name: opKey.Name},
+		Spec: operatorsv1alpha1.OperatorSpec{
+			PackageName:     "prometheus",
+			Version:         "1.0.0",
+			Channel:         "beta",
+			UpgradeApproval: operatorsv1alpha1.UpgradeApprovalManual,
+		},
+	}
+	require.NoError(t, cl.Create(ctx, operator))
+
+	// The initial install does not need to be approved
+	res, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: opKey})
+	require.NoError(t, err)
+	assert.Equal(t, ctrl.Result{}, res)
+
+	bd := &rukpakv1alpha1.BundleDeployment{}
+	require.NoError(t, cl.Get(ctx, opKey, bd))
+	assert.Equal(t, "quay.io/operatorhubio/prometheus@fake1.0.0", bd.Spec.Template.Spec.Source.Image.Ref)
+
+	require.NoError(t, cl.Get(ctx, opKey, operator))
+	assert.Nil(t, operator.Status.PendingUpgrade)
+	assert.Empty(t, operator.Status.AvailableUpgrades)
+	cond := apimeta.FindStatusCondition(operator.Status.Conditions, operatorsv1alpha1.TypeUpgradeAvailable)
+	require.NotNil(t, cond)
+	assert.Equal(t, metav1.ConditionFalse, cond.Status)
+	assert.Equal(t, operatorsv1alpha1.ReasonNoUpgradePending, cond.Reason)
+
+	// Lift the version constraint: the upgrade is resolved, but not applied
+	operator.Spec.Version = ""
+	require.NoError(t, cl.Update(ctx, operator))
+
+	res, err = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: opKey})
+	require.NoError(t, err)
+	assert.Equal(t, ctrl.Result{}, res)
+
+	require.NoError(t, cl.Get(ctx, opKey, bd))
+	assert.Equal(t, "quay.io/operatorhubio/prometheus@fake1.0.0", bd.Spec.Template.Spec.Source.Image.Ref)
+
+	require.NoError(t, cl.Get(ctx, opKey, operator))
+	assert.Equal(t, "quay.io/operatorhubio/prometheus@fake1.2.0", operator.Status.ResolvedBundleResource)
+	assert.Equal(t, &operatorsv1alpha1.BundleMetadata{Name: "operatorhub/prometheus/beta/1.2.0", Version: "1.2.0"}, operator.Status.PendingUpgrade)
+	assert.Equal(t, []operatorsv1alpha1.AvailableUpgrade{
+		{
+			BundleMetadata: operatorsv1alpha1.BundleMetadata{Name: "operatorhub/prometheus/beta/1.2.0", Version: "1.2.0"},
+			Channels:       []string{"beta"},
+			Catalog:        "fake-catalog",
+		},
+		{
+			BundleMetadata: operatorsv1alpha1.BundleMetadata{Name: "operatorhub/prometheus/beta/1.0.1", Version: "1.0.1"},
+			Channels:       []string{"beta"},
+			Catalog:        "fake-catalog",
+		},
+	}, operator.Status.AvailableUpgrades)
+	cond = apimeta.FindStatusCondition(operator.Status.Conditions, operatorsv1alpha1.TypeUpgradeAvailable)
+	require.NotNil(t, cond)
+	assert.Equal(t, metav1.ConditionTrue, cond.Status)
+	assert.Equal(t, operatorsv1alpha1.ReasonUpgradePending, cond.Reason)
+	assert.Equal(t, `upgrade to version "1.2.0" is pending approval`, cond.Message)
+	verifyConditionsInvariants(operator)
+
+	// Approve an older version than the pending upgrade: resolution is limited to it
+	operator.Spec.ApprovedVersion = "1.0.1"
+	require.NoError(t, cl.Update(ctx, operator))
+
+	res, err = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: opKey})
+	require.NoError(t, err)
+	assert.Equal(t, ctrl.Result{}, res)
+
+	require.NoError(t, cl.Get(ctx, opKey, bd))
+	assert.Equal(t, "quay.io/operatorhubio/prometheus@fake1.0.1", bd.Spec.Template.Spec.Source.Image.Ref)
+
+	// The newer successor is pending approval again
+	res, err = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: opKey})
+	require.NoError(t, err)
+	assert.Equal(t, ctrl.Result{}, res)
+
+	require.NoError(t, cl.Get(ctx, opKey, bd))
+	assert.Equal(t, "quay.io/operatorhubio/prometheus@fake1.0.1", bd.Spec.Template.Spec.Source.Image.Ref)
+
+	require.NoError(t, cl.Get(ctx, opKey, operator))
+	assert.Equal(t, "quay.io/operatorhubio/prometheus@fake1.0.1", operator.Status.ResolvedBundleResource)
+	assert.Equal(t, &operatorsv1alpha1.BundleMetadata{Name: "operatorhub/prometheus/beta/1.2.0", Version: "1.2.0"}, operator.Status.PendingUpgrade)
+	cond = apimeta.FindStatusCondition(operator.Status.Conditions, operatorsv1alpha1.TypeUpgradeAvailable)
+	require.NotNil(t, cond)
+	assert.Equal(t, metav1.ConditionTrue, cond.Status)
+	assert.Equal(t, `upgrade to version "1.2.0" is pending approval`, cond.Message)
+	verifyConditionsInvariants(operator)
+
+	// Approve the pending upgrade
+	operator.Spec.ApprovedVersion = "1.2.0"
+	require.NoError(t, cl.Update(ctx, operator))
+
+	res, err = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: opKey})
+	require.NoError(t, err)
+	assert.Equal(t, ctrl.Result{}, res)
+
+	require.NoError(t, cl.Get(ctx, opKey, bd))
+	assert.Equal(t, "quay.io/operatorhubio/prometheus@fake1.2.0", bd.Spec.Template.Spec.Source.Image.Ref)
+
+	require.NoError(t, cl.Get(ctx, opKey, operator))
+	assert.Nil(t, operator.Status.PendingUpgrade)
+	cond = apimeta.FindStatusCondition(operator.Status.Conditions, operatorsv1alpha1.TypeUpgradeAvailable)
+	require.NotNil(t, cond)
+	assert.Equal(t, metav1.ConditionFalse, cond.Status)
+	assert.Equal(t, operatorsv1alpha1.ReasonNoUpgradePending, cond.Reason)
+}
+
+func TestOperatorBundleImage(t *testing.T) {
+	ctx := context.Background()
+	fakeCatalogClient := testutil.NewFakeCatalogClient(testBundleList)
+	reconciler := &controllers.OperatorReconciler{
+		Client:   cl,
+		Scheme:   sch,
+		Resolver: solver.NewDeppySolver(controllers.NewVariableSource(cl, &fakeCatalogClient, clusterversion.Static{})),
+		Recorder: record.NewFakeRecorder(1000),
+	}
+	defer func() {
+		require.NoError(t, cl.DeleteAllOf(ctx, &operatorsv1alpha1.Operator{}))
+		require.NoError(t, cl.DeleteAllOf(ctx, &rukpakv1alpha1.BundleDeployment{}))
+	}()
+
+	const bundleImage = "quay.io/example/hotfix@sha256:0123456789abcdef"
+	opKey := types.NamespacedName{Name: fmt.Sprintf("operator-test-%s", rand.String(8))}
+	operator := &operatorsv1alpha1.Operator{
+		ObjectMeta: metav1.ObjectMeta{Name: opKey.Name},
+		Spec: operatorsv1alpha1.OperatorSpec{
+			// the package does not exist in any catalog
+			PackageName:     "hotfix",
+			BundleImage:     bundleImage,
+			BundleMediaType: catalogmetadata.MediaTypePlain,
+		},
+	}
+	require.NoError(t, cl.Create(ctx, operator))
+
+	res, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: opKey})
+	require.NoError(t, err)
+	assert.Equal(t, ctrl.Result{}, res)
+
+	bd := &rukpakv1alpha1.BundleDeployment{}
+	require.NoError(t, cl.Get(ctx, opKey, bd))
+	assert.Equal(t, "core-rukpak-io-plain", bd.Spec.ProvisionerClassName)
+	assert.Equal(t, "core-rukpak-io-plain", bd.Spec.Template.Spec.ProvisionerClassName)
+	assert.Equal(t, bundleImage, bd.Spec.Template.Spec.Source.Image.Ref)
+	assert.Equal(t, "true", bd.Annotations[operatorsv1alpha1.BundleImageAnnotation])
+
+	require.NoError(t, cl.Get(ctx, opKey, operator))
+	assert.Equal(t, bundleImage, operator.Status.ResolvedBundleResource)
+	cond := apimeta.FindStatusCondition(operator.Status.Conditions, operatorsv1alpha1.TypeResolved)
+	require.NotNil(t, cond)
+	assert.Equal(t, metav1.ConditionTrue, cond.Status)
+	assert.Equal(t, operatorsv1alpha1.ReasonSuccess, cond.Reason)
+	assert.Equal(t, fmt.Sprintf("resolution skipped, using bundle image %q", bundleImage), cond.Message)
+	cond = apimeta.FindStatusCondition(operator.Status.Conditions, operatorsv1alpha1.TypeInstalled)
+	require.NotNil(t, cond)
+	assert.Equal(t, metav1.ConditionUnknown, cond.Status)
+	assert.Equal(t, operatorsv1alpha1.ReasonInstallationStatusUnknown, cond.Reason)
+	verifyConditionsInvariants(operator)
+
+	// Resolving the other operators is not affected by the bundle deployment
+	otherOpKey := types.NamespacedName{Name: fmt.Sprintf("operator-test-%s", rand.String(8))}
+	otherOperator := &operatorsv1alpha1.Operator{
+		ObjectMeta: metav1.ObjectMeta{Name: otherOpKey.Name},
+		Spec:       operatorsv1alpha1.OperatorSpec{PackageName: "prometheus"},
+	}
+	require.NoError(t, cl.Create(ctx, otherOperator))
+
+	res, err = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: otherOpKey})
+	require.NoError(t, err)
+	assert.Equal(t, ctrl.Result{}, res)
+
+	// Moving off the bundle image resolves the operator from its package again,
+	// although the bundle deployment still points at the bundle image for now
+	require.NoError(t, cl.Get(ctx, opKey, operator))
+	operator.Spec.PackageName = "plain"
+	operator.Spec.BundleImage = ""
+	operator.Spec.BundleMediaType = ""
+	require.NoError(t, cl.Update(ctx, operator))
+
+	res, err = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: opKey})
+	require.NoError(t, err)
+	assert.Equal(t, ctrl.Result{}, res)
+
+	require.NoError(t, cl.Get(ctx, opKey, bd))
+	assert.Equal(t, "quay.io/operatorhub/plain@sha256:plain", bd.Spec.Template.Spec.Source.Image.Ref)
+	assert.NotContains(t, bd.Annotations, operatorsv1alpha1.BundleImageAnnotation)
+
+	require.NoError(t, cl.Get(ctx, opKey, operator))
+	assert.Equal(t, "quay.io/operatorhub/plain@sha256:plain", operator.Status.ResolvedBundleResource)
+	verifyConditionsInvariants(operator)
+}
+
+func TestOperatorDeletion(t *testing.T) {
+	ctx := context.Background()
+	fakeCatalogClient := testutil.NewFakeCatalogClient(testBundleList)
+	reconciler := &controllers.OperatorReconciler{
+		Client:   cl,
+		Scheme:   sch,
+		Resolver: solver.NewDeppySolver(controllers.NewVariableSource(cl, &fakeCatalogClient, clusterversion.Static{})),
+		Recorder: record.NewFakeRecorder(1000),
+	}
+	defer func() {
+		require.NoError(t, cl.DeleteAllOf(ctx, &rukpakv1alpha1.BundleDeployment{}))
+	}()
+
+	opKey := types.NamespacedName{Name: fmt.Sprintf("operator-test-%s", rand.String(8))}
+	operator := &operatorsv1alpha1.Operator{
+		ObjectMeta: metav1.ObjectMeta{Name: opKey.Name},
+		Spec: operatorsv1alpha1.OperatorSpec{
+			PackageName: "prometheus",
+			Version:     "1.0.0",
+			Channel:     "beta",
+		},
+	}
+	// Create an operator
+	require.NoError(t, cl.Create(ctx, operator))
+
+	// Run reconcile
+	res, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: opKey})
+	require.NoError(t, err)
+	assert.Equal(t, ctrl.Result{}, res)
+
+	// The cleanup finalizer is added and a BundleDeployment is created
+	require.NoError(t, cl.Get(ctx, opKey, operator))
+	assert.Contains(t, operator.Finalizers, operatorsv1alpha1.CleanupFinalizer)
+	cond := apimeta.FindStatusCondition(operator.Status.Conditions, operatorsv1alpha1.TypeDeleting)
+	require.NotNil(t, cond)
+	assert.Equal(t, metav1.ConditionFalse, cond.Status)
+	assert.Equal(t, operatorsv1alpha1.ReasonDeletionNotRequested, cond.Reason)
+	bd := &rukpakv1alpha1.BundleDeployment{}
+	require.NoError(t, cl.Get(ctx, types.NamespacedName{Name: opKey.Name}, bd))
+
+	// Delete the operator, which is kept around by the finalizer
+	require.NoError(t, cl.Delete(ctx, operator))
+	require.NoError(t, cl.Get(ctx, opKey, operator))
+	assert.False(t, operator.GetDeletionTimestamp().IsZero())
+
+	// Run reconcile, which removes the BundleDeployment
+	res, err = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: opKey})
+	require.NoError(t, err)
+	assert.Equal(t, ctrl.Result{}, res)
+
+	require.NoError(t, cl.Get(ctx, opKey, operator))
+	assert.Contains(t, operator.Finalizers, operatorsv1alpha1.CleanupFinalizer)
+	cond = apimeta.FindStatusCondition(operator.Status.Conditions, operatorsv1alpha1.TypeDeleting)
+	require.NotNil(t, cond)
+	assert.Equal(t, metav1.ConditionTrue, cond.Status)
+	assert.Equal(t, operatorsv1alpha1.ReasonWaitingForBundleDeploymentRemoval, cond.Reason)
+	assert.Equal(t, fmt.Sprintf("waiting for bundledeployment %q to be removed", opKey.Name), cond.Message)
+	err = cl.Get(ctx, types.NamespacedName{Name: opKey.Name}, bd)
+	assert.True(t, apierrors.IsNotFound(err))
+
+	// Run reconcile once the BundleDeployment is gone, which releases the finalizer
+	res, err = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: opKey})
+	require.NoError(t, err)
+	assert.Equal(t, ctrl.Result{}, res)
+
+	err = cl.Get(ctx, opKey, operator)
+	assert.True(t, apierrors.IsNotFound(err))
+}
+
+func TestOperatorUnavailableCatalogs(t *testing.T) {
+	ctx := context.Background()
+	unavailableCatalog := catalogmetadata.UnavailableCatalog{Name: "broken-catalog", Err: errors.New("fake error")}
+	fakeCatalogClient := testutil.NewFakeCatalogClientWithUnavailableCatalogs(testBundleList, unavailableCatalog)
+	reconciler := &controllers.OperatorReconciler{
+		Client:   cl,
+		Scheme:   sch,
+		Resolver: solver.NewDeppySolver(controllers.NewVariableSource(cl, &fakeCatalogClient, clusterversion.Static{})),
+		Recorder: record.NewFakeRecorder(1000),
+	}
+	defer func() {
+		require.NoError(t, cl.DeleteAllOf(ctx, &operatorsv1alpha1.Operator{}))
+		require.NoError(t, cl.DeleteAllOf(ctx, &rukpakv1alpha1.BundleDeployment{}))
+	}()
+
+	t.Run("resolves from the available catalogs", func(t *testing.T) {
+		opKey := types.NamespacedName{Name: fmt.Sprintf("operator-test-%s", rand.String(8))}
+		operator := &operatorsv1alpha1.Operator{
+			ObjectMeta: metav1.ObjectMeta{Name: opKey.Name},
+			Spec: operatorsv1alpha1.OperatorSpec{
+				PackageName: "prometheus",
+				Version:     "1.0.0",
+				Channel:     "beta",
+			},
+		}
+		require.NoError(t, cl.Create(ctx, operator))
+
+		res, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: opKey})
+		require.NoError(t, err)
+		assert.Equal(t, ctrl.Result{RequeueAfter: time.Minute}, res)
+
+		require.NoError(t, cl.Get(ctx, opKey, operator))
+		assert.Equal(t, "quay.io/operatorhubio/prometheus@fake1.0.0", operator.Status.ResolvedBundleResource)
+		cond := apimeta.FindStatusCondition(operator.Status.Conditions, operatorsv1alpha1.TypeDegraded)
+		require.NotNil(t, cond)
+		assert.Equal(t, metav1.ConditionTrue, cond.Status)
+		assert.Equal(t, operatorsv1alpha1.ReasonCatalogsUnavailable, cond.Reason)
+		assert.Equal(t, `skipped unavailable catalogs: catalog "broken-catalog" (fake error)`, cond.Message)
+		verifyConditionsInvariants(operator)
+	})
+
+	t.Run("reports the unavailable catalogs when the package is not found", func(t *testing.T) {
+		opKey := types.NamespacedName{Name: fmt.Sprintf("operator-test-%s", rand.String(8))}
+		operator := &operatorsv1alpha1.Operator{
+			ObjectMeta: metav1.ObjectMeta{Name: opKey.Name},
+			Spec:       operatorsv1alpha1.OperatorSpec{PackageName: "missing"},
+		}
+		require.NoError(t, cl.Create(ctx, operator))
+
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: opKey})
+		require.Error(t, err)
+
+		require.NoError(t, cl.Get(ctx, opKey, operator))
+		cond := apimeta.FindStatusCondition(operator.Status.Conditions, operatorsv1alpha1.TypeResolved)
+		require.NotNil(t, cond)
+		assert.Equal(t, metav1.ConditionFalse, cond.Status)
+		assert.Contains(t, cond.Message, "no package 'missing' found")
+		cond = apimeta.FindStatusCondition(operator.Status.Conditions, operatorsv1alpha1.TypeDegraded)
+		require.NotNil(t, cond)
+		assert.Equal(t, metav1.ConditionTrue, cond.Status)
+		assert.Equal(t, operatorsv1alpha1.ReasonCatalogsUnavailable, cond.Reason)
+		verifyConditionsInvariants(operator)
+	})
+}
+
+func TestOperatorDeprecations(t *testing.T) {
+	ctx := context.Background()
+	newBundle := func(version string, deprecations ...catalogmetadata.DeprecationEntry) *catalogmetadata.Bundle {
+		return &catalogmetadata.Bundle{
+			Bundle: declcfg.Bundle{
+				Name:    "operatorhub/prometheus/beta/" + version,
+				Package: "prometheus",
+				Image:   "quay.io/operatorhubio/prometheus@fake" + version,
+				Properties: []property.Property{
+					{Type: property.TypePackage, Value: json.RawMessage(`{"packageName":"prometheus","version":"` + version + `"}`)},
+				},
+			},
+			CatalogName: "fake-catalog",
+			InChannels:  []*catalogmetadata.Channel{&prometheusBetaChannel},
+			CatalogPackage: &catalogmetadata.Package{
+				Package:     declcfg.Package{Name: "prometheus", DefaultChannel: "beta"},
+				CatalogName: "fake-catalog",
+			},
+			Deprecations: deprecations,
+		}
+	}
+	packageDeprecation := catalogmetadata.DeprecationEntry{
+		Reference: catalogmetadata.PackageScopedReference{Schema: declcfg.SchemaPackage},
+		Message:   "prometheus is moving to a new package",
+	}
+	channelDeprecation := catalogmetadata.DeprecationEntry{
+		Reference: catalogmetadata.PackageScopedReference{Schema: declcfg.SchemaChannel, Name: "beta"},
+		Message:   "the beta channel is no longer updated",
+	}
+	fakeCatalogClient := testutil.NewFakeCatalogClient([]*catalogmetadata.Bundle{
+		newBundle("1.0.0", packageDeprecation, channelDeprecation),
+		newBundle("2.0.0", packageDeprecation, channelDeprecation, catalogmetadata.DeprecationEntry{
+			Reference: catalogmetadata.PackageScopedReference{Schema: declcfg.SchemaBundle, Name: "operatorhub/prometheus/beta/2.0.0"},
+			Message:   "prometheus 2.0.0 has a known vulnerability",
+		}),
+	})
+	reconciler := &controllers.OperatorReconciler{
+		Client:   cl,
+		Scheme:   sch,
+		Resolver: solver.NewDeppySolver(controllers.NewVariableSource(cl, &fakeCatalogClient, clusterversion.Static{})),
+		Recorder: record.NewFakeRecorder(1000),
+	}
+	defer func() {
+		require.NoError(t, cl.DeleteAllOf(ctx, &operatorsv1alpha1.Operator{}))
+		require.NoError(t, cl.DeleteAllOf(ctx, &rukpakv1alpha1.BundleDeployment{}))
+	}()
+
+	opKey := types.NamespacedName{Name: fmt.Sprintf("operator-test-%s", rand.String(8))}
+	operator := &operatorsv1alpha1.Operator{
+		ObjectMeta: metav1.ObjectMeta{Name: opKey.Name},
+		Spec: operatorsv1alpha1.OperatorSpec{
+			PackageName: "prometheus",
+			Channel:     "beta",
+		},
+	}
+	require.NoError(t, cl.Create(ctx, operator))
+
+	_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: opKey})
+	require.NoError(t, err)
+
+	require.NoError(t, cl.Get(ctx, opKey, operator))
+	// the deprecated bundle is passed over for the older one
+	assert.Equal(t, "quay.io/operatorhubio/prometheus@fake1.0.0", operator.Status.ResolvedBundleResource)
+	for conditionType, want := range map[string]metav1.Condition{
+		operatorsv1alpha1.TypeDeprecated: {
+			Status:  metav1.ConditionTrue,
+			Reason:  operatorsv1alpha1.ReasonDeprecated,
+			Message: "prometheus is moving to a new package\nthe beta channel is no longer updated",
+		},
+		operatorsv1alpha1.TypePackageDeprecated: {
+			Status:  metav1.ConditionTrue,
+			Reason:  operatorsv1alpha1.ReasonDeprecated,
+			Message: "prometheus is moving to a new package",
+		},
+		operatorsv1alpha1.TypeChannelDeprecated: {
+			Status:  metav1.ConditionTrue,
+			Reason:  operatorsv1alpha1.ReasonDeprecated,
+			Message: "the beta channel is no longer updated",
+		},
+		operatorsv1alpha1.TypeBundleDeprecated: {
+			Status:  metav1.ConditionFalse,
+			Reason:  operatorsv1alpha1.ReasonNotDeprecated,
+			Message: `bundle "operatorhub/prometheus/beta/1.0.0" is not deprecated`,
+		},
+	} {
+		cond := apimeta.FindStatusCondition(operator.Status.Conditions, conditionType)
+		require.NotNil(t, cond, conditionType)
+		assert.Equal(t, want.Status, cond.Status, conditionType)
+		assert.Equal(t, want.Reason, cond.Reason, conditionType)
+		assert.Equal(t, want.Message, cond.Message, conditionType)
+	}
+	verifyConditionsInvariants(operator)
+
+	t.Run("the default channel is checked when no channel is specified", func(t *testing.T) {
+		opKey := types.NamespacedName{Name: fmt.Sprintf("operator-test-%s", rand.String(8))}
+		operator := &operatorsv1alpha1.Operator{
+			ObjectMeta: metav1.ObjectMeta{Name: opKey.Name},
+			Spec:       operatorsv1alpha1.OperatorSpec{PackageName: "prometheus"},
+		}
+		require.NoError(t, cl.Create(ctx, operator))
+
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: opKey})
+		require.NoError(t, err)
+
+		require.NoError(t, cl.Get(ctx, opKey, operator))
+		cond := apimeta.FindStatusCondition(operator.Status.Conditions, operatorsv1alpha1.TypeChannelDeprecated)
+		require.NotNil(t, cond)
+		assert.Equal(t, metav1.ConditionTrue, cond.Status)
+		assert.Equal(t, operatorsv1alpha1.ReasonDeprecated, cond.Reason)
+		assert.Equal(t, "the beta channel is no longer updated", cond.Message)
+		verifyConditionsInvariants(operator)
+	})
 }
 
 var (
@@ -1388,3 +1944,20 @@ var testBundleList = []*catalogmetadata.Bundle{
 		InChannels:  []*catalogmetadata.Channel{&badmediaBetaChannel},
 	},
 }
+
+func TestClassifyError(t *testing.T) {
+	err := errors.New("fake error")
+	assert.Equal(t, controllers.ErrorClassTransient, controllers.ClassifyError(err))
+
+	terminalErr := controllers.NewTerminalError(err)
+	assert.Equal(t, controllers.ErrorClassTerminal, controllers.ClassifyError(terminalErr))
+	assert.Equal(t, controllers.ErrorClassTerminal, controllers.ClassifyError(fmt.Errorf("wrapped: %w", terminalErr)))
+	assert.ErrorIs(t, terminalErr, err)
+	assert.EqualError(t, terminalErr, "fake error")
+
+	waitingErr := controllers.NewWaitingError(err, time.Minute)
+	assert.Equal(t, controllers.ErrorClassWaiting, controllers.ClassifyError(waitingErr))
+	var reconcileErr *controllers.ReconcileError
+	require.ErrorAs(t, waitingErr, &reconcileErr)
+	assert.Equal(t, time.Minute, reconcileErr.RequeueAfter)
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 
 	mmsemver "github.com/Masterminds/semver/v3"
+	bsemver "github.com/blang/semver/v4"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	operatorsv1alpha1 "github.com/operator-framework/operator-controller/api/v1alpha1"
 )
@@ -24,15 +26,49 @@ func validateSemver(operator *operatorsv1alpha1.Operator) error {
 	return nil
 }
 
+// validateCatalogSelector validates that the operator's catalog label selector, if provided,
+// is a valid label selector. Invalid ones, such as an In requirement without values,
+// are accepted by the CRD schema but would fail the resolution of every operator.
+func validateCatalogSelector(operator *operatorsv1alpha1.Operator) error {
+	if operator.Spec.CatalogSelector == nil || operator.Spec.CatalogSelector.LabelSelector == nil {
+		return nil
+	}
+	if _, err := metav1.LabelSelectorAsSelector(operator.Spec.CatalogSelector.LabelSelector); err != nil {
+		return fmt.Errorf("invalid .spec.catalogSelector.labelSelector: %w", err)
+	}
+	return nil
+}
+
+// validateApprovedVersion validates that the operator's approved version, if provided, is a valid SemVer version.
+func validateApprovedVersion(operator *operatorsv1alpha1.Operator) error {
+	if operator.Spec.ApprovedVersion == "" {
+		return nil
+	}
+	if _, err := bsemver.Parse(operator.Spec.ApprovedVersion); err != nil {
+		return fmt.Errorf("invalid .spec.approvedVersion: %w", err)
+	}
+	return nil
+}
+
+// validateBundleMediaType validates that the operator's bundle media type is only set along with a bundle image.
+func validateBundleMediaType(operator *operatorsv1alpha1.Operator) error {
+	if operator.Spec.BundleMediaType != "" && operator.Spec.BundleImage == "" {
+		return fmt.Errorf(".spec.bundleMediaType can only be set along with .spec.bundleImage")
+	}
+	return nil
+}
+
 // ValidateOperatorSpec validates the operator spec, e.g. ensuring that .spec.version, if provided, is a valid SemVer
 func ValidateOperatorSpec(operator *operatorsv1alpha1.Operator) error {
 	validators := []operatorCRValidatorFunc{
 		validateSemver,
+		validateCatalogSelector,
+		validateApprovedVersion,
+		validateBundleMediaType,
 	}
 
-	// TODO: currently we only have a single validator, but more will likely be added in the future
-	//  we need to make a decision on whether we want to run all validators or stop at the first error. If the the former,
-	//  we should consider how to present this to the user in a way that is easy to understand and fix.
+	// TODO: we stop at the first error. We need to make a decision on whether we want to run all validators instead,
+	//  and if so, consider how to present the errors to the user in a way that is easy to understand and fix.
 	//  this issue is tracked here: https://github.com/operator-framework/operator-controller/issues/167
 	for _, validator := range validators {
 		if err := validator(operator); err != nil {
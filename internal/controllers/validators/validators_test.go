@@ -3,6 +3,7 @@ package validators_test
 import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/operator-framework/operator-controller/api/v1alpha1"
 	"github.com/operator-framework/operator-controller/internal/controllers/validators"
@@ -59,5 +60,67 @@ var _ = Describe("Validators", func() {
 			err := validators.ValidateOperatorSpec(operator)
 			Expect(err).NotTo(HaveOccurred())
 		})
+
+		It("should not return an error for a valid catalog label selector", func() {
+			operator := &v1alpha1.Operator{
+				Spec: v1alpha1.OperatorSpec{
+					CatalogSelector: &v1alpha1.CatalogSelector{
+						LabelSelector: &metav1.LabelSelector{
+							MatchExpressions: []metav1.LabelSelectorRequirement{
+								{Key: "tier", Operator: metav1.LabelSelectorOpIn, Values: []string{"internal"}},
+							},
+						},
+					},
+				},
+			}
+			err := validators.ValidateOperatorSpec(operator)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should return an error for an invalid catalog label selector", func() {
+			operator := &v1alpha1.Operator{
+				Spec: v1alpha1.OperatorSpec{
+					CatalogSelector: &v1alpha1.CatalogSelector{
+						LabelSelector: &metav1.LabelSelector{
+							MatchExpressions: []metav1.LabelSelectorRequirement{
+								{Key: "tier", Operator: metav1.LabelSelectorOpIn},
+							},
+						},
+					},
+				},
+			}
+			err := validators.ValidateOperatorSpec(operator)
+			Expect(err).To(MatchError(ContainSubstring("invalid .spec.catalogSelector.labelSelector")))
+		})
+
+		It("should not return an error for a valid approved version", func() {
+			operator := &v1alpha1.Operator{
+				Spec: v1alpha1.OperatorSpec{
+					ApprovedVersion: "1.2.3",
+				},
+			}
+			err := validators.ValidateOperatorSpec(operator)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should return an error for a bundle media type without a bundle image", func() {
+			operator := &v1alpha1.Operator{
+				Spec: v1alpha1.OperatorSpec{
+					BundleMediaType: "plain+v0",
+				},
+			}
+			err := validators.ValidateOperatorSpec(operator)
+			Expect(err).To(MatchError(".spec.bundleMediaType can only be set along with .spec.bundleImage"))
+		})
+
+		It("should return an error for an approved version which is a range", func() {
+			operator := &v1alpha1.Operator{
+				Spec: v1alpha1.OperatorSpec{
+					ApprovedVersion: ">=1.2.3",
+				},
+			}
+			err := validators.ValidateOperatorSpec(operator)
+			Expect(err).To(HaveOccurred())
+		})
 	})
 })
@@ -21,22 +21,73 @@ import (
 
 	"github.com/operator-framework/deppy/pkg/deppy/input"
 
+	"github.com/operator-framework/operator-controller/internal/clusterversion"
+	"github.com/operator-framework/operator-controller/internal/resolution/resolver"
 	"github.com/operator-framework/operator-controller/internal/resolution/variablesources"
 )
 
-func NewVariableSource(cl client.Client, catalogClient variablesources.BundleProvider) variablesources.NestedVariableSource {
+// NewVariableSource returns the variable source of the resolution, on top of the
+// variables of its input variable source, if any.
+func NewVariableSource(cl client.Client, catalogClient variablesources.BundleProvider, clusterVersion clusterversion.Provider) variablesources.NestedVariableSource {
 	return variablesources.NestedVariableSource{
 		func(inputVariableSource input.VariableSource) (input.VariableSource, error) {
-			return variablesources.NewOperatorVariableSource(cl, catalogClient, inputVariableSource), nil
-		},
-		func(inputVariableSource input.VariableSource) (input.VariableSource, error) {
-			return variablesources.NewBundleDeploymentVariableSource(cl, catalogClient, inputVariableSource), nil
-		},
-		func(inputVariableSource input.VariableSource) (input.VariableSource, error) {
-			return variablesources.NewBundlesAndDepsVariableSource(catalogClient, inputVariableSource), nil
-		},
-		func(inputVariableSource input.VariableSource) (input.VariableSource, error) {
-			return variablesources.NewCRDUniquenessConstraintsVariableSource(inputVariableSource), nil
+			return composeVariableSource(cl, catalogClient, clusterVersion, inputVariableSource, nil, nil), nil
 		},
 	}
 }
+
+// NewIncrementalResolver returns a resolver built from the same variable sources
+// as NewVariableSource, which only resolves again when an Operator, a BundleDeployment,
+// a Catalog or the version of the cluster has changed. The variables for the required and the installed packages
+// are memoized separately, so that changes to BundleDeployments, which happen on every
+// install, do not require reading the catalogs again for every Operator.
+func NewIncrementalResolver(cl client.Client, catalogClient variablesources.BundleProvider, clusterVersion clusterversion.Provider) *resolver.Resolver {
+	operators := resolver.OperatorGenerations(cl)
+	bundleDeployments := resolver.BundleDeploymentGenerations(cl)
+	catalogs := resolver.CatalogResolvedRefs(cl)
+
+	return resolver.New(
+		composeVariableSource(cl, catalogClient, clusterVersion, nil,
+			func(requiredPackages input.VariableSource) input.VariableSource {
+				return variablesources.NewMemoizedVariableSource(resolver.Keys(operators, catalogs), requiredPackages)
+			},
+			func(installedPackages input.VariableSource) input.VariableSource {
+				return variablesources.NewMemoizedVariableSource(resolver.Keys(operators, bundleDeployments, catalogs), installedPackages)
+			},
+		),
+		resolver.Keys(operators, bundleDeployments, catalogs, resolver.ClusterVersion(clusterVersion)),
+	)
+}
+
+// composeVariableSource composes the variable sources of the resolution: the packages
+// required by the Operators and installed by the BundleDeployments, the bundles they
+// depend on and the constraints between them. The required and the installed package
+// variable sources are passed through the given wrappers, if any.
+func composeVariableSource(
+	cl client.Client,
+	catalogClient variablesources.BundleProvider,
+	clusterVersion clusterversion.Provider,
+	inputVariableSource input.VariableSource,
+	wrapRequiredPackages, wrapInstalledPackages func(input.VariableSource) input.VariableSource,
+) input.VariableSource {
+	var requiredPackages input.VariableSource = variablesources.NewOperatorVariableSource(cl, catalogClient, nil)
+	if wrapRequiredPackages != nil {
+		requiredPackages = wrapRequiredPackages(requiredPackages)
+	}
+	var installedPackages input.VariableSource = variablesources.NewBundleDeploymentVariableSource(cl, catalogClient, nil)
+	if wrapInstalledPackages != nil {
+		installedPackages = wrapInstalledPackages(installedPackages)
+	}
+
+	packages := variablesources.SliceVariableSource{requiredPackages, installedPackages}
+	if inputVariableSource != nil {
+		packages = append(variablesources.SliceVariableSource{inputVariableSource}, packages...)
+	}
+
+	return variablesources.NewClusterVersionConstraintsVariableSource(
+		clusterVersion,
+		variablesources.NewCRDUniquenessConstraintsVariableSource(
+			variablesources.NewBundlesAndDepsVariableSource(catalogClient, packages...),
+		),
+	)
+}
@@ -0,0 +1,282 @@
+// Package httpclient builds the HTTP client used to download catalog contents
+// from catalogd, which can serve them over TLS with a certificate signed by
+// a private certificate authority, require client certificates, or be briefly
+// unavailable.
+package httpclient
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// Options configures the HTTP client.
+type Options struct {
+	// CAFile is the path of a PEM bundle of certificate authorities trusted
+	// in addition to the ones of the system. It is read again whenever it changes,
+	// so that certificate authorities can be rotated without restarts.
+	CAFile string
+	// CertFile and KeyFile are the paths of the PEM encoded client certificate
+	// and key presented to the servers requesting one. They are read again
+	// whenever they change.
+	CertFile string
+	KeyFile  string
+	// Timeout limits the time of each attempt of a request, including the time
+	// spent reading the response body. 0 means no limit.
+	Timeout time.Duration
+	// Retries is the number of times a request is attempted again after
+	// a connection error, a timeout or a 429 or 5xx response.
+	Retries int
+	// RetryBackoff is the time waited before the first retry, which doubles
+	// before each of the following ones.
+	RetryBackoff time.Duration
+}
+
+// New returns an HTTP client configured with the given options.
+func New(options Options) (*http.Client, error) {
+	if (options.CertFile == "") != (options.KeyFile == "") {
+		return nil, errors.New("the client certificate and key files must be set together")
+	}
+
+	transport := &reloadingTransport{options: options}
+	// fail early if the files cannot be loaded
+	if _, err := transport.current(context.Background()); err != nil {
+		return nil, err
+	}
+
+	return &http.Client{
+		Transport: &retryingTransport{
+			next:    transport,
+			timeout: options.Timeout,
+			retries: options.Retries,
+			backoff: options.RetryBackoff,
+		},
+	}, nil
+}
+
+// reloadingTransport is an http.RoundTripper which builds its TLS configuration
+// from the files of its options, and builds it again when any of them changes.
+type reloadingTransport struct {
+	options Options
+
+	mu        sync.Mutex
+	transport *http.Transport
+	// stamps identifies the versions of the files last loaded, so that
+	// they are only read again once they change.
+	stamps []fileStamp
+}
+
+func (t *reloadingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	transport, err := t.current(req.Context())
+	if err != nil {
+		return nil, err
+	}
+	return transport.RoundTrip(req)
+}
+
+// current returns the transport built from the current contents of the files.
+// If the files cannot be loaded, for instance while they are being replaced,
+// the transport built from their previous contents is returned instead.
+func (t *reloadingTransport) current(ctx context.Context) (*http.Transport, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	paths := []string{t.options.CAFile, t.options.CertFile, t.options.KeyFile}
+	stamps, err := statFiles(paths...)
+	if err == nil && t.transport != nil && equalStamps(stamps, t.stamps) {
+		return t.transport, nil
+	}
+	var files [][]byte
+	if err == nil {
+		files, err = readFiles(paths...)
+	}
+	var tlsConfig *tls.Config
+	if err == nil {
+		tlsConfig, err = newTLSConfig(files[0], files[1], files[2])
+	}
+	if err != nil {
+		if t.transport == nil {
+			return nil, err
+		}
+		log.FromContext(ctx).Error(err, "error reloading TLS configuration, using the previous one")
+		if stamps != nil {
+			// do not load the invalid files again until they change
+			t.stamps = stamps
+		}
+		return t.transport, nil
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	if t.transport != nil {
+		t.transport.CloseIdleConnections()
+	}
+	t.transport, t.stamps = transport, stamps
+	return transport, nil
+}
+
+// fileStamp identifies a version of a file by its modification time and size.
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// statFiles returns the stamps of the given files, with zero
+// stamps for the ones whose path is empty.
+func statFiles(paths ...string) ([]fileStamp, error) {
+	stamps := make([]fileStamp, len(paths))
+	for i, path := range paths {
+		if path == "" {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		stamps[i] = fileStamp{modTime: info.ModTime(), size: info.Size()}
+	}
+	return stamps, nil
+}
+
+func equalStamps(a, b []fileStamp) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !a[i].modTime.Equal(b[i].modTime) || a[i].size != b[i].size {
+			return false
+		}
+	}
+	return true
+}
+
+// readFiles returns the contents of the given files, with nil
+// contents for the ones whose path is empty.
+func readFiles(paths ...string) ([][]byte, error) {
+	files := make([][]byte, len(paths))
+	for i, path := range paths {
+		if path == "" {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		files[i] = data
+	}
+	return files, nil
+}
+
+func newTLSConfig(caPEM, certPEM, keyPEM []byte) (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if caPEM != nil {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, errors.New("error loading CA bundle: no valid PEM certificate found")
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if certPEM != nil {
+		cert, err := tls.X509KeyPair(certPEM, keyPEM)
+		if err != nil {
+			return nil, fmt.Errorf("error loading client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+// retryingTransport is an http.RoundTripper which limits the time of each attempt
+// of a request, and retries the failed attempts with an exponential backoff.
+// Requests with a body are not retried, as their body cannot be read twice.
+type retryingTransport struct {
+	next    http.RoundTripper
+	timeout time.Duration
+	retries int
+	backoff time.Duration
+}
+
+func (t *retryingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	retries := t.retries
+	if req.Body != nil && req.Body != http.NoBody {
+		retries = 0
+	}
+	backoff := wait.Backoff{
+		Duration: t.backoff,
+		Factor:   2,
+		Jitter:   0.1,
+		Steps:    math.MaxInt32,
+	}
+
+	for attempt := 0; ; attempt++ {
+		resp, err := t.attempt(req)
+		if attempt >= retries || !retriable(resp, err) || req.Context().Err() != nil {
+			return resp, err
+		}
+		if resp != nil {
+			// drain the body so that the connection can be reused
+			_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(backoff.Step())
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func (t *retryingTransport) attempt(req *http.Request) (*http.Response, error) {
+	if t.timeout <= 0 {
+		return t.next.RoundTrip(req)
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), t.timeout)
+	resp, err := t.next.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	// the timeout also applies to reading the body,
+	// so it is only released once the body is closed
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+func retriable(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return resp.StatusCode == http.StatusTooManyRequests ||
+		resp.StatusCode >= http.StatusInternalServerError && resp.StatusCode != http.StatusNotImplemented
+}
+
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnClose) Close() error {
+	defer c.cancel()
+	return c.ReadCloser.Close()
+}
@@ -0,0 +1,187 @@
+package httpclient_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/util/cert"
+
+	"github.com/operator-framework/operator-controller/internal/httpclient"
+)
+
+func TestNew(t *testing.T) {
+	_, err := httpclient.New(httpclient.Options{CertFile: "tls.crt"})
+	assert.EqualError(t, err, "the client certificate and key files must be set together")
+
+	_, err = httpclient.New(httpclient.Options{CAFile: filepath.Join(t.TempDir(), "missing.crt")})
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	caFile := filepath.Join(t.TempDir(), "ca.crt")
+	require.NoError(t, os.WriteFile(caFile, []byte("not a certificate"), 0600))
+	_, err = httpclient.New(httpclient.Options{CAFile: caFile})
+	assert.EqualError(t, err, "error loading CA bundle: no valid PEM certificate found")
+}
+
+func TestCAReload(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	otherCA, _, err := cert.GenerateSelfSignedCertKey("other", nil, nil)
+	require.NoError(t, err)
+	caFile := filepath.Join(t.TempDir(), "ca.crt")
+	require.NoError(t, os.WriteFile(caFile, otherCA, 0600))
+
+	c, err := httpclient.New(httpclient.Options{CAFile: caFile})
+	require.NoError(t, err)
+
+	_, err = c.Get(server.URL)
+	assert.ErrorContains(t, err, "certificate signed by unknown authority")
+
+	serverCA := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+
+	t.Run("the file is not read again while its modification time and size are unchanged", func(t *testing.T) {
+		info, err := os.Stat(caFile)
+		require.NoError(t, err)
+		require.Less(t, len(serverCA), len(otherCA))
+		padded := append(append([]byte{}, serverCA...), bytes.Repeat([]byte("\n"), len(otherCA)-len(serverCA))...)
+		require.NoError(t, os.WriteFile(caFile, padded, 0600))
+		require.NoError(t, os.Chtimes(caFile, info.ModTime(), info.ModTime()))
+
+		_, err = c.Get(server.URL)
+		assert.ErrorContains(t, err, "certificate signed by unknown authority")
+	})
+
+	require.NoError(t, os.WriteFile(caFile, serverCA, 0600))
+
+	resp, err := c.Get(server.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	t.Run("the previous CA bundle is used while the file is invalid", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(caFile, nil, 0600))
+
+		resp, err := c.Get(server.URL)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+}
+
+func TestClientCertificate(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.TLS.PeerCertificates[0].Subject.CommonName))
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert, MinVersion: tls.VersionTLS12}
+	server.StartTLS()
+	defer server.Close()
+
+	dir := t.TempDir()
+	caFile, certFile, keyFile := filepath.Join(dir, "ca.crt"), filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	serverCA := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	require.NoError(t, os.WriteFile(caFile, serverCA, 0600))
+	writeClientCertificate := func(host string) {
+		certPEM, keyPEM, err := cert.GenerateSelfSignedCertKey(host, nil, nil)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(certFile, certPEM, 0600))
+		require.NoError(t, os.WriteFile(keyFile, keyPEM, 0600))
+	}
+	writeClientCertificate("operator-controller")
+
+	c, err := httpclient.New(httpclient.Options{CAFile: caFile, CertFile: certFile, KeyFile: keyFile})
+	require.NoError(t, err)
+	assert.Regexp(t, "^operator-controller@", get(t, c, server.URL))
+
+	writeClientCertificate("operator-controller-renewed")
+	assert.Regexp(t, "^operator-controller-renewed@", get(t, c, server.URL))
+}
+
+func TestRetries(t *testing.T) {
+	var requests, failures int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests <= failures {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	c, err := httpclient.New(httpclient.Options{Retries: 2, RetryBackoff: time.Millisecond})
+	require.NoError(t, err)
+
+	requests, failures = 0, 2
+	assert.Equal(t, "ok", get(t, c, server.URL))
+	assert.Equal(t, 3, requests)
+
+	t.Run("the last response is returned when retries are exhausted", func(t *testing.T) {
+		requests, failures = 0, 3
+		resp, err := c.Get(server.URL)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+		assert.Equal(t, 3, requests)
+	})
+
+	t.Run("client errors are not retried", func(t *testing.T) {
+		notFound := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			http.NotFound(w, r)
+		}))
+		defer notFound.Close()
+
+		requests = 0
+		resp, err := c.Get(notFound.URL)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+		assert.Equal(t, 1, requests)
+	})
+}
+
+func TestTimeout(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			<-r.Context().Done()
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	c, err := httpclient.New(httpclient.Options{Timeout: 50 * time.Millisecond})
+	require.NoError(t, err)
+	_, err = c.Get(server.URL)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	c, err = httpclient.New(httpclient.Options{Timeout: 50 * time.Millisecond, Retries: 1, RetryBackoff: time.Millisecond})
+	require.NoError(t, err)
+	requests = 0
+	assert.Equal(t, "ok", get(t, c, server.URL))
+	assert.Equal(t, 2, requests)
+}
+
+func get(t *testing.T, c *http.Client, url string) string {
+	resp, err := c.Get(url)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return string(body)
+}
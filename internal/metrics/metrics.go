@@ -0,0 +1,158 @@
+package metrics
+
+import (
+	"errors"
+	"time"
+
+	"github.com/operator-framework/deppy/pkg/deppy"
+	"github.com/operator-framework/deppy/pkg/deppy/solver"
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	operatorsv1alpha1 "github.com/operator-framework/operator-controller/api/v1alpha1"
+)
+
+const (
+	namespace = "operator_controller"
+
+	ResolutionResultSat   = "sat"
+	ResolutionResultUnsat = "unsat"
+	ResolutionResultError = "error"
+)
+
+var (
+	// ResolutionDuration is the latency of the resolutions, partitioned by result.
+	ResolutionDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "resolution_duration_seconds",
+		Help:      "Latency of the resolutions, partitioned by result (sat, unsat or error).",
+		Buckets:   prometheus.ExponentialBuckets(0.01, 2, 12),
+	}, []string{"result"})
+
+	// ResolutionVariables is the number of variables considered by each resolution.
+	ResolutionVariables = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "resolution_variables",
+		Help:      "Number of variables considered by each resolution.",
+		Buckets:   prometheus.ExponentialBuckets(1, 4, 10),
+	})
+
+	// ResolutionConstraints is the number of constraints considered by each resolution.
+	ResolutionConstraints = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "resolution_constraints",
+		Help:      "Number of constraints considered by each resolution.",
+		Buckets:   prometheus.ExponentialBuckets(1, 4, 10),
+	})
+
+	// ResolutionCacheHits is the number of resolutions answered with the solution of a previous resolution.
+	ResolutionCacheHits = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "resolution_cache_hits_total",
+		Help:      "Number of resolutions answered with the solution of a previous resolution as none of its inputs changed.",
+	})
+
+	// CatalogCacheHits is the number of catalog content fetches served from the cache.
+	CatalogCacheHits = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "catalog_cache_hits_total",
+		Help:      "Number of catalog content fetches served from the cache.",
+	}, []string{"catalog"})
+
+	// CatalogCacheMisses is the number of catalog content fetches which had to be downloaded.
+	CatalogCacheMisses = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "catalog_cache_misses_total",
+		Help:      "Number of catalog content fetches which had to be downloaded from catalogd.",
+	}, []string{"catalog"})
+
+	// CatalogDownloadBytes is the number of bytes of catalog content downloaded from catalogd.
+	CatalogDownloadBytes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "catalog_download_bytes_total",
+		Help:      "Number of bytes of catalog content downloaded from catalogd.",
+	}, []string{"catalog"})
+
+	// BundleDeploymentPatches is the number of patches applied to the BundleDeployments of the operators.
+	BundleDeploymentPatches = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "bundledeployment_patches_total",
+		Help:      "Number of patches applied to the BundleDeployment of an operator.",
+	}, []string{"operator"})
+
+	// OperatorInstalledBundle is set to 1 for the bundle installed for an operator.
+	OperatorInstalledBundle = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "operator_installed_bundle_info",
+		Help:      "Name and version of the bundle installed for an operator, which are empty for bundle images. The value is always 1.",
+	}, []string{"operator", "bundle_name", "bundle_version"})
+
+	// OperatorResolvedBundle is set to 1 for the bundle resolved for an operator.
+	OperatorResolvedBundle = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "operator_resolved_bundle_info",
+		Help:      "Name and version of the bundle resolved for an operator, which are empty for bundle images. The value is always 1.",
+	}, []string{"operator", "bundle_name", "bundle_version"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		ResolutionDuration,
+		ResolutionVariables,
+		ResolutionConstraints,
+		ResolutionCacheHits,
+		CatalogCacheHits,
+		CatalogCacheMisses,
+		CatalogDownloadBytes,
+		BundleDeploymentPatches,
+		OperatorInstalledBundle,
+		OperatorResolvedBundle,
+	)
+}
+
+// ObserveResolution records the outcome of a resolution which started at the given time.
+// The solution is expected to be computed with the solver.AddAllVariablesToSolution option
+// for the number of variables and constraints to be recorded.
+func ObserveResolution(start time.Time, solution *solver.Solution, err error) {
+	result := ResolutionResultSat
+	switch {
+	case err != nil:
+		result = ResolutionResultError
+	case errors.As(solution.Error(), &deppy.NotSatisfiable{}):
+		result = ResolutionResultUnsat
+	}
+	ResolutionDuration.WithLabelValues(result).Observe(time.Since(start).Seconds())
+
+	if solution == nil {
+		return
+	}
+	constraints := 0
+	for _, variable := range solution.AllVariables() {
+		constraints += len(variable.Constraints())
+	}
+	ResolutionVariables.Observe(float64(len(solution.AllVariables())))
+	ResolutionConstraints.Observe(float64(constraints))
+}
+
+// SetOperatorBundles records the bundles installed and resolved for an operator.
+// Nil bundles remove the corresponding series. Bundles without a name nor
+// a version, such as bundle images, are recorded with empty labels.
+func SetOperatorBundles(operatorName string, installedBundle, resolvedBundle *operatorsv1alpha1.BundleMetadata) {
+	setInfo(OperatorInstalledBundle, operatorName, installedBundle)
+	setInfo(OperatorResolvedBundle, operatorName, resolvedBundle)
+}
+
+// DeleteOperatorBundles removes the series of an operator which no longer exists,
+// including its count of bundle deployment patches.
+func DeleteOperatorBundles(operatorName string) {
+	setInfo(OperatorInstalledBundle, operatorName, nil)
+	setInfo(OperatorResolvedBundle, operatorName, nil)
+	BundleDeploymentPatches.DeleteLabelValues(operatorName)
+}
+
+func setInfo(gauge *prometheus.GaugeVec, operatorName string, bundle *operatorsv1alpha1.BundleMetadata) {
+	gauge.DeletePartialMatch(prometheus.Labels{"operator": operatorName})
+	if bundle != nil {
+		gauge.WithLabelValues(operatorName, bundle.Name, bundle.Version).Set(1)
+	}
+}
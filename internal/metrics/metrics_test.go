@@ -0,0 +1,107 @@
+package metrics_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/operator-framework/deppy/pkg/deppy/solver"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	operatorsv1alpha1 "github.com/operator-framework/operator-controller/api/v1alpha1"
+	"github.com/operator-framework/operator-controller/internal/metrics"
+)
+
+func TestObserveResolution(t *testing.T) {
+	before := sampleCount(t, metrics.ResolutionDuration.WithLabelValues(metrics.ResolutionResultError))
+
+	metrics.ObserveResolution(time.Now(), nil, errors.New("something bad happened"))
+
+	assert.Equal(t, before+1, sampleCount(t, metrics.ResolutionDuration.WithLabelValues(metrics.ResolutionResultError)))
+}
+
+func TestObserveResolutionSat(t *testing.T) {
+	before := sampleCount(t, metrics.ResolutionDuration.WithLabelValues(metrics.ResolutionResultSat))
+	variablesBefore := sampleCount(t, metrics.ResolutionVariables)
+
+	metrics.ObserveResolution(time.Now(), &solver.Solution{}, nil)
+
+	assert.Equal(t, before+1, sampleCount(t, metrics.ResolutionDuration.WithLabelValues(metrics.ResolutionResultSat)))
+	assert.Equal(t, variablesBefore+1, sampleCount(t, metrics.ResolutionVariables))
+}
+
+func TestSetOperatorBundles(t *testing.T) {
+	v100 := &operatorsv1alpha1.BundleMetadata{Name: "test.v1.0.0", Version: "1.0.0"}
+	v110 := &operatorsv1alpha1.BundleMetadata{Name: "test.v1.1.0", Version: "1.1.0"}
+
+	metrics.SetOperatorBundles("test-operator", v100, v110)
+	assert.Equal(t, 1, seriesCount(t, metrics.OperatorInstalledBundle, "test-operator"))
+	assert.Equal(t, 1, seriesCount(t, metrics.OperatorResolvedBundle, "test-operator"))
+	assert.Equal(t, 1.0, gaugeValue(t, metrics.OperatorInstalledBundle.WithLabelValues("test-operator", "test.v1.0.0", "1.0.0")))
+	assert.Equal(t, 1.0, gaugeValue(t, metrics.OperatorResolvedBundle.WithLabelValues("test-operator", "test.v1.1.0", "1.1.0")))
+
+	// a new bundle replaces the previous series
+	metrics.SetOperatorBundles("test-operator", v110, v110)
+	assert.Equal(t, 1, seriesCount(t, metrics.OperatorInstalledBundle, "test-operator"))
+	assert.Equal(t, 1, seriesCount(t, metrics.OperatorResolvedBundle, "test-operator"))
+	assert.Equal(t, 1.0, gaugeValue(t, metrics.OperatorInstalledBundle.WithLabelValues("test-operator", "test.v1.1.0", "1.1.0")))
+
+	// nothing installed anymore
+	metrics.SetOperatorBundles("test-operator", nil, v110)
+	assert.Equal(t, 0, seriesCount(t, metrics.OperatorInstalledBundle, "test-operator"))
+	assert.Equal(t, 1, seriesCount(t, metrics.OperatorResolvedBundle, "test-operator"))
+
+	metrics.DeleteOperatorBundles("test-operator")
+	assert.Equal(t, 0, seriesCount(t, metrics.OperatorInstalledBundle, "test-operator"))
+	assert.Equal(t, 0, seriesCount(t, metrics.OperatorResolvedBundle, "test-operator"))
+}
+
+func TestDeleteOperatorBundles(t *testing.T) {
+	metrics.SetOperatorBundles("deleted-operator", &operatorsv1alpha1.BundleMetadata{Name: "test.v1.0.0", Version: "1.0.0"}, nil)
+	metrics.BundleDeploymentPatches.WithLabelValues("deleted-operator").Inc()
+	metrics.BundleDeploymentPatches.WithLabelValues("other-operator").Inc()
+	assert.Equal(t, 1, seriesCount(t, metrics.BundleDeploymentPatches, "deleted-operator"))
+
+	metrics.DeleteOperatorBundles("deleted-operator")
+	assert.Equal(t, 0, seriesCount(t, metrics.OperatorInstalledBundle, "deleted-operator"))
+	assert.Equal(t, 0, seriesCount(t, metrics.BundleDeploymentPatches, "deleted-operator"))
+	assert.Equal(t, 1, seriesCount(t, metrics.BundleDeploymentPatches, "other-operator"))
+}
+
+func sampleCount(t *testing.T, observer prometheus.Observer) uint64 {
+	t.Helper()
+	metric, ok := observer.(prometheus.Metric)
+	require.True(t, ok)
+	out := &dto.Metric{}
+	require.NoError(t, metric.Write(out))
+	return out.GetHistogram().GetSampleCount()
+}
+
+func gaugeValue(t *testing.T, gauge prometheus.Gauge) float64 {
+	t.Helper()
+	out := &dto.Metric{}
+	require.NoError(t, gauge.Write(out))
+	return out.GetGauge().GetValue()
+}
+
+func seriesCount(t *testing.T, collector prometheus.Collector, operatorName string) int {
+	t.Helper()
+	ch := make(chan prometheus.Metric, 100)
+	collector.Collect(ch)
+	close(ch)
+
+	count := 0
+	for metric := range ch {
+		out := &dto.Metric{}
+		require.NoError(t, metric.Write(out))
+		for _, label := range out.GetLabel() {
+			if label.GetName() == "operator" && label.GetValue() == operatorName {
+				count++
+			}
+		}
+	}
+	return count
+}
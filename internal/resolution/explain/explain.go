@@ -0,0 +1,484 @@
+package explain
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/operator-framework/deppy/pkg/deppy"
+	"github.com/operator-framework/deppy/pkg/deppy/constraint"
+	"github.com/operator-framework/operator-registry/alpha/property"
+	"golang.org/x/exp/slices"
+
+	operatorsv1alpha1 "github.com/operator-framework/operator-controller/api/v1alpha1"
+	"github.com/operator-framework/operator-controller/internal/catalogmetadata"
+	olmvariables "github.com/operator-framework/operator-controller/internal/resolution/variables"
+)
+
+// maxListedBundles is the number of bundles listed in a message,
+// before the remaining ones are only counted.
+const maxListedBundles = 5
+
+// CauseType tells which rule of the resolution a Cause comes from.
+type CauseType string
+
+const (
+	// CauseTypeRequiredPackage is a package required by Operators.
+	CauseTypeRequiredPackage CauseType = "RequiredPackage"
+	// CauseTypeInstalledPackage is a package installed by Operators, which can
+	// only be replaced by one of its successors.
+	CauseTypeInstalledPackage CauseType = "InstalledPackage"
+	// CauseTypeBundleDependency is a bundle depending on other packages.
+	CauseTypeBundleDependency CauseType = "BundleDependency"
+	// CauseTypeUniqueness is a set of bundles which cannot be installed together.
+	CauseTypeUniqueness CauseType = "Uniqueness"
+	// CauseTypeGVKUniqueness is a set of bundles which cannot be installed
+	// together as they provide the same GVK.
+	CauseTypeGVKUniqueness CauseType = "GVKUniqueness"
+	// CauseTypeClusterVersion is a bundle which does not support the version of the cluster.
+	CauseTypeClusterVersion CauseType = "ClusterVersion"
+	// CauseTypeOther is a constraint the explainer knows nothing about.
+	CauseTypeOther CauseType = "Other"
+)
+
+// causeTypeOrder orders the causes from the requirements
+// of the Operators to the conflicts they lead to.
+var causeTypeOrder = map[CauseType]int{
+	CauseTypeRequiredPackage:  0,
+	CauseTypeInstalledPackage: 1,
+	CauseTypeBundleDependency: 2,
+	CauseTypeUniqueness:       3,
+	CauseTypeGVKUniqueness:    3,
+	CauseTypeClusterVersion:   3,
+	CauseTypeOther:            4,
+}
+
+// BundleRef identifies a bundle of a catalog.
+type BundleRef struct {
+	Catalog string
+	Package string
+	Name    string
+	Version string
+}
+
+// Cause is one of the rules which together make a resolution unsatisfiable.
+type Cause struct {
+	Type CauseType
+	// Message describes the cause in a sentence.
+	Message string
+	// Operators are the names of the Operators the cause comes from, if any.
+	Operators []string
+	// Package is the package the cause is about, if any.
+	Package string
+	// GVK is the group/version/kind the cause is about, if any.
+	GVK string
+	// Bundle is the bundle the cause is about, which is the installed
+	// bundle of an installed package, the bundle having dependencies
+	// or the bundle which cannot be installed on the cluster.
+	Bundle *BundleRef
+	// Bundles are the bundles which can satisfy the cause,
+	// or the bundles which conflict with each other.
+	Bundles []BundleRef
+}
+
+// Explanation describes why a resolution is not satisfiable, as a chain
+// of causes going from the requirements of the Operators to the conflicts
+// they lead to.
+type Explanation struct {
+	Causes []Cause
+
+	unsat deppy.NotSatisfiable
+}
+
+// String returns the messages of the causes of the explanation.
+func (e *Explanation) String() string {
+	msgs := make([]string, 0, len(e.Causes))
+	for _, cause := range e.Causes {
+		msgs = append(msgs, cause.Message)
+	}
+	return strings.Join(msgs, "; ")
+}
+
+func (e *Explanation) Error() string {
+	return fmt.Sprintf("constraints not satisfiable: %s", e.String())
+}
+
+func (e *Explanation) Unwrap() error {
+	return e.unsat
+}
+
+// NotSatisfiable explains the constraints of an unsatisfiable resolution
+// in terms of the Operators, packages and bundles they come from.
+// The variables are the ones given to the solver, they are needed to
+// look up the bundles the constraints refer to.
+func NotSatisfiable(unsat deppy.NotSatisfiable, variables []deppy.Variable, operators []operatorsv1alpha1.Operator) *Explanation {
+	e := &explainer{
+		bundles:   map[deppy.Identifier]*catalogmetadata.Bundle{},
+		installed: map[deppy.Identifier]bool{},
+		involved:  map[deppy.Identifier]bool{},
+		operators: operators,
+	}
+	for _, variable := range variables {
+		e.addBundles(variable)
+	}
+
+	// group the constraints by variable, as every variable
+	// stands for a single rule of the resolution.
+	var order []deppy.Identifier
+	byVariable := map[deppy.Identifier][]deppy.AppliedConstraint{}
+	for _, applied := range unsat {
+		id := applied.Variable.Identifier()
+		if _, ok := byVariable[id]; !ok {
+			order = append(order, id)
+		}
+		byVariable[id] = append(byVariable[id], applied)
+		e.addBundles(applied.Variable)
+		e.involved[id] = true
+		if dependency, ok := applied.Constraint.(*constraint.DependencyConstraint); ok {
+			for _, dependencyID := range dependency.DependencyIDs() {
+				e.involved[dependencyID] = true
+			}
+		}
+	}
+
+	causes := make([]Cause, 0, len(order))
+	for _, id := range order {
+		causes = append(causes, e.explain(byVariable[id])...)
+	}
+	sort.SliceStable(causes, func(i, j int) bool {
+		if causeTypeOrder[causes[i].Type] != causeTypeOrder[causes[j].Type] {
+			return causeTypeOrder[causes[i].Type] < causeTypeOrder[causes[j].Type]
+		}
+		return causes[i].Message < causes[j].Message
+	})
+
+	return &Explanation{Causes: causes, unsat: unsat}
+}
+
+type explainer struct {
+	// bundles maps the identifiers of the bundle variables to their bundle.
+	bundles map[deppy.Identifier]*catalogmetadata.Bundle
+	// installed holds the identifiers of the installed bundles.
+	installed map[deppy.Identifier]bool
+	// involved holds the identifiers of the variables
+	// which take part in the unsatisfiable constraints.
+	involved  map[deppy.Identifier]bool
+	operators []operatorsv1alpha1.Operator
+}
+
+func (e *explainer) addBundles(variable deppy.Variable) {
+	var bundles []*catalogmetadata.Bundle
+	switch v := variable.(type) {
+	case *olmvariables.BundleVariable:
+		bundles = append(bundles, v.Bundle())
+		bundles = append(bundles, v.Dependencies()...)
+	case *olmvariables.RequiredPackageVariable:
+		bundles = append(bundles, v.Bundles()...)
+	case *olmvariables.InstalledPackageVariable:
+		bundles = append(bundles, v.Bundles()...)
+		if v.InstalledBundle() != nil {
+			e.installed[olmvariables.BundleVariableID(v.InstalledBundle())] = true
+			bundles = append(bundles, v.InstalledBundle())
+		}
+	}
+	for _, bundle := range bundles {
+		e.bundles[olmvariables.BundleVariableID(bundle)] = bundle
+	}
+}
+
+func (e *explainer) explain(applied []deppy.AppliedConstraint) []Cause {
+	var dependency *constraint.DependencyConstraint
+	var atMost *constraint.AtMostConstraint
+	for _, a := range applied {
+		switch c := a.Constraint.(type) {
+		case *constraint.DependencyConstraint:
+			dependency = c
+		case *constraint.AtMostConstraint:
+			atMost = c
+		}
+	}
+
+	switch v := applied[0].Variable.(type) {
+	case *olmvariables.RequiredPackageVariable:
+		return []Cause{e.requiredPackage(v, dependency)}
+	case *olmvariables.InstalledPackageVariable:
+		return []Cause{e.installedPackage(v, dependency)}
+	case *olmvariables.BundleVariable:
+		if dependency != nil {
+			return []Cause{e.bundleDependency(v, dependency)}
+		}
+	case *olmvariables.BundleUniquenessVariable:
+		if atMost != nil {
+			return []Cause{e.uniqueness(atMost)}
+		}
+	case *olmvariables.GVKUniquenessVariable:
+		if atMost != nil {
+			return []Cause{e.gvkUniqueness(v, atMost)}
+		}
+	case *olmvariables.IncompatibleBundleVariable:
+		ref := newBundleRef(v.Bundle())
+		return []Cause{{
+			Type:    CauseTypeClusterVersion,
+			Message: fmt.Sprintf("%s cannot be installed on this cluster as it %s", bundleString(v.Bundle()), v.Reason()),
+			Package: v.Bundle().Package,
+			Bundle:  &ref,
+		}}
+	}
+
+	causes := make([]Cause, 0, len(applied))
+	for _, a := range applied {
+		causes = append(causes, Cause{Type: CauseTypeOther, Message: a.String()})
+	}
+	return causes
+}
+
+func (e *explainer) requiredPackage(v *olmvariables.RequiredPackageVariable, dependency *constraint.DependencyConstraint) Cause {
+	packageName := v.PackageName()
+	cause := Cause{Type: CauseTypeRequiredPackage, Package: packageName}
+
+	var requirements []string
+	for _, operator := range e.operatorsOfPackage(packageName) {
+		// mirrors the operators the required packages are made of
+		if !operator.GetDeletionTimestamp().IsZero() || operator.Spec.BundleImage != "" {
+			continue
+		}
+		cause.Operators = append(cause.Operators, operator.Name)
+		requirement := fmt.Sprintf("operator %q requires package %q", operator.Name, packageName)
+		if operator.Spec.Version != "" {
+			requirement += fmt.Sprintf(" in version range %q", operator.Spec.Version)
+		}
+		if operator.Spec.Channel != "" {
+			requirement += fmt.Sprintf(" from channel %q", operator.Spec.Channel)
+		}
+		requirements = append(requirements, requirement)
+	}
+	if len(requirements) == 0 {
+		requirements = append(requirements, fmt.Sprintf("package %q is required", packageName))
+	}
+	cause.Message = strings.Join(requirements, " and ")
+
+	if dependency != nil {
+		cause.Bundles = e.bundleRefs(dependency.DependencyIDs())
+		if len(cause.Bundles) == 0 {
+			cause.Message += ", which no bundle satisfies"
+		} else {
+			cause.Message += fmt.Sprintf(", satisfied by %s", e.listBundles(dependency.DependencyIDs()))
+		}
+	}
+	return cause
+}
+
+func (e *explainer) installedPackage(v *olmvariables.InstalledPackageVariable, dependency *constraint.DependencyConstraint) Cause {
+	packageName := v.PackageName()
+	cause := Cause{Type: CauseTypeInstalledPackage, Package: packageName}
+
+	installed := fmt.Sprintf("package %q", packageName)
+	var installedID deppy.Identifier
+	if v.InstalledBundle() != nil {
+		installedID = olmvariables.BundleVariableID(v.InstalledBundle())
+		ref := newBundleRef(v.InstalledBundle())
+		cause.Bundle = &ref
+		installed = bundleString(v.InstalledBundle())
+	}
+
+	var owners []string
+	for _, operator := range e.operatorsOfPackage(packageName) {
+		cause.Operators = append(cause.Operators, operator.Name)
+		owners = append(owners, fmt.Sprintf("%q", operator.Name))
+	}
+	if len(owners) == 0 {
+		cause.Message = fmt.Sprintf("%s is installed", installed)
+	} else {
+		cause.Message = fmt.Sprintf("operator %s has %s installed", strings.Join(owners, " and "), installed)
+	}
+
+	if dependency != nil {
+		cause.Bundles = e.bundleRefs(dependency.DependencyIDs())
+		var successorIDs []deppy.Identifier
+		for _, id := range dependency.DependencyIDs() {
+			if id != installedID {
+				successorIDs = append(successorIDs, id)
+			}
+		}
+		if len(successorIDs) == 0 {
+			cause.Message += ", which can only be kept"
+		} else {
+			cause.Message += fmt.Sprintf(", which can only be kept or upgraded to %s", e.listBundles(successorIDs))
+		}
+	}
+	return cause
+}
+
+func (e *explainer) bundleDependency(v *olmvariables.BundleVariable, dependency *constraint.DependencyConstraint) Cause {
+	ref := newBundleRef(v.Bundle())
+	cause := Cause{
+		Type:    CauseTypeBundleDependency,
+		Package: v.Bundle().Package,
+		Bundle:  &ref,
+		Bundles: e.bundleRefs(dependency.DependencyIDs()),
+	}
+
+	var requirements []string
+	requiredPackages, err := v.Bundle().RequiredPackages()
+	if err == nil {
+		for _, requiredPackage := range requiredPackages {
+			requirement := fmt.Sprintf("package %q", requiredPackage.PackageName)
+			if requiredPackage.VersionRange != "" {
+				requirement += fmt.Sprintf(" in version range %q", requiredPackage.VersionRange)
+			}
+			requirements = append(requirements, requirement)
+		}
+	}
+	requiredGVKs, err := v.Bundle().RequiredGVKs()
+	if err == nil {
+		for _, requiredGVK := range requiredGVKs {
+			requirements = append(requirements, olmvariables.GVKString(property.GVK(requiredGVK)))
+		}
+	}
+	olmConstraints, err := v.Bundle().Constraints()
+	if err == nil {
+		for _, olmConstraint := range olmConstraints {
+			if olmConstraint.FailureMessage != "" {
+				requirements = append(requirements, fmt.Sprintf("%s (%s)", catalogmetadata.ConstraintString(olmConstraint), olmConstraint.FailureMessage))
+				continue
+			}
+			requirements = append(requirements, catalogmetadata.ConstraintString(olmConstraint))
+		}
+	}
+	if len(requirements) == 0 {
+		cause.Message = fmt.Sprintf("%s requires one of %s", bundleString(v.Bundle()), e.listBundles(dependency.DependencyIDs()))
+		return cause
+	}
+	cause.Message = fmt.Sprintf("%s requires %s, satisfied by %s", bundleString(v.Bundle()), strings.Join(requirements, " and "), e.listBundles(dependency.DependencyIDs()))
+	return cause
+}
+
+func (e *explainer) uniqueness(atMost *constraint.AtMostConstraint) Cause {
+	ids := e.conflictingIDs(atMost)
+	cause := Cause{Type: CauseTypeUniqueness, Bundles: e.bundleRefs(ids)}
+	packageName := ""
+	for i, ref := range cause.Bundles {
+		if i > 0 && ref.Package != packageName {
+			packageName = ""
+			break
+		}
+		packageName = ref.Package
+	}
+	cause.Package = packageName
+
+	if packageName != "" {
+		cause.Message = fmt.Sprintf("only one of %s can be installed as they belong to package %q", e.listBundles(ids), packageName)
+	} else {
+		cause.Message = fmt.Sprintf("only %d of %s can be installed", atMost.N(), e.listBundles(ids))
+	}
+	return cause
+}
+
+func (e *explainer) gvkUniqueness(v *olmvariables.GVKUniquenessVariable, atMost *constraint.AtMostConstraint) Cause {
+	ids := e.conflictingIDs(atMost)
+	cause := Cause{
+		Type:    CauseTypeGVKUniqueness,
+		GVK:     olmvariables.GVKString(v.GVK()),
+		Bundles: e.bundleRefs(ids),
+	}
+
+	var packages []string
+	for _, ref := range cause.Bundles {
+		packages = append(packages, fmt.Sprintf("%q", ref.Package))
+	}
+	sort.Strings(packages)
+	packages = slices.Compact(packages)
+
+	if len(packages) == 1 {
+		cause.Message = fmt.Sprintf("only one of %s can be installed as they all provide %s", e.listBundles(ids), cause.GVK)
+	} else {
+		cause.Message = fmt.Sprintf("only one of %s can be installed as packages %s all provide %s", e.listBundles(ids), strings.Join(packages, ", "), cause.GVK)
+	}
+	return cause
+}
+
+// conflictingIDs returns the identifiers of the bundles which take part in the
+// other constraints, unless it leaves less than two bundles to conflict with each other.
+func (e *explainer) conflictingIDs(atMost *constraint.AtMostConstraint) []deppy.Identifier {
+	var ids []deppy.Identifier
+	for _, id := range atMost.Ids() {
+		if e.involved[id] {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) < 2 {
+		return atMost.Ids()
+	}
+	return ids
+}
+
+func (e *explainer) operatorsOfPackage(packageName string) []operatorsv1alpha1.Operator {
+	var operators []operatorsv1alpha1.Operator
+	for _, operator := range e.operators {
+		if operator.Spec.PackageName == packageName {
+			operators = append(operators, operator)
+		}
+	}
+	sort.Slice(operators, func(i, j int) bool {
+		return operators[i].Name < operators[j].Name
+	})
+	return operators
+}
+
+func (e *explainer) bundleRefs(ids []deppy.Identifier) []BundleRef {
+	refs := make([]BundleRef, 0, len(ids))
+	for _, id := range ids {
+		if bundle, ok := e.bundles[id]; ok {
+			refs = append(refs, newBundleRef(bundle))
+		}
+	}
+	return refs
+}
+
+// listBundles lists the bundles identified by the given identifiers in
+// a human-readable form, only counting the bundles beyond maxListedBundles.
+func (e *explainer) listBundles(ids []deppy.Identifier) string {
+	var names []string
+	seen := map[string]bool{}
+	for _, id := range ids {
+		name := string(id)
+		if bundle, ok := e.bundles[id]; ok {
+			name = bundleString(bundle)
+		}
+		if e.installed[id] {
+			name = "installed " + name
+		}
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		names = append(names, name)
+	}
+
+	if len(names) > maxListedBundles {
+		return fmt.Sprintf("%s and %d more", strings.Join(names[:maxListedBundles], ", "), len(names)-maxListedBundles)
+	}
+	return strings.Join(names, ", ")
+}
+
+func newBundleRef(bundle *catalogmetadata.Bundle) BundleRef {
+	ref := BundleRef{
+		Catalog: bundle.CatalogName,
+		Package: bundle.Package,
+		Name:    bundle.Name,
+	}
+	if version, err := bundle.Version(); err == nil {
+		ref.Version = version.String()
+	}
+	return ref
+}
+
+// bundleString returns the package and version of a bundle,
+// or its name when its version is not known.
+func bundleString(bundle *catalogmetadata.Bundle) string {
+	version, err := bundle.Version()
+	if err != nil {
+		return bundle.Name
+	}
+	return fmt.Sprintf("%s %s", bundle.Package, version)
+}
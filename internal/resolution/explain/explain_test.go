@@ -0,0 +1,220 @@
+package explain_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/operator-framework/deppy/pkg/deppy"
+	"github.com/operator-framework/deppy/pkg/deppy/solver"
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/operator-framework/operator-registry/alpha/property"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	operatorsv1alpha1 "github.com/operator-framework/operator-controller/api/v1alpha1"
+	"github.com/operator-framework/operator-controller/internal/catalogmetadata"
+	"github.com/operator-framework/operator-controller/internal/resolution/explain"
+	olmvariables "github.com/operator-framework/operator-controller/internal/resolution/variables"
+)
+
+type staticVariableSource []deppy.Variable
+
+func (s staticVariableSource) GetVariables(_ context.Context) ([]deppy.Variable, error) {
+	return s, nil
+}
+
+func bundle(packageName, version string, properties ...property.Property) *catalogmetadata.Bundle {
+	properties = append(properties, property.Property{
+		Type:  property.TypePackage,
+		Value: json.RawMessage(fmt.Sprintf(`{"packageName": %q, "version": %q}`, packageName, version)),
+	})
+	return &catalogmetadata.Bundle{
+		Bundle: declcfg.Bundle{
+			Name:       fmt.Sprintf("%s.v%s", packageName, version),
+			Package:    packageName,
+			Properties: properties,
+		},
+		CatalogName: "test-catalog",
+	}
+}
+
+func TestNotSatisfiable(t *testing.T) {
+	bar21 := bundle("bar", "2.1.0", property.Property{Type: property.TypePackageRequired, Value: json.RawMessage(`{"packageName": "baz", "versionRange": "<1.0.0"}`)})
+	baz09 := bundle("baz", "0.9.0")
+	baz13 := bundle("baz", "1.3.0")
+
+	variables := []deppy.Variable{
+		olmvariables.NewRequiredPackageVariable("bar", []*catalogmetadata.Bundle{bar21}),
+		olmvariables.NewBundleVariable(bar21, []*catalogmetadata.Bundle{baz09}),
+		olmvariables.NewBundleVariable(baz09, nil),
+		olmvariables.NewInstalledPackageVariable("baz", baz13, []*catalogmetadata.Bundle{baz13}, nil, nil),
+		olmvariables.NewBundleVariable(baz13, nil),
+		olmvariables.NewBundleUniquenessVariable("bar package uniqueness", olmvariables.BundleVariableID(bar21)),
+		olmvariables.NewBundleUniquenessVariable("baz package uniqueness", olmvariables.BundleVariableID(baz09), olmvariables.BundleVariableID(baz13)),
+	}
+	operators := []operatorsv1alpha1.Operator{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo"},
+			Spec:       operatorsv1alpha1.OperatorSpec{PackageName: "bar", Version: ">=2.0.0"},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "baz"},
+			Spec:       operatorsv1alpha1.OperatorSpec{PackageName: "baz"},
+		},
+	}
+
+	solution, err := solver.NewDeppySolver(staticVariableSource(variables)).Solve(context.Background())
+	require.NoError(t, err)
+	unsat := deppy.NotSatisfiable{}
+	require.ErrorAs(t, solution.Error(), &unsat)
+
+	explanation := explain.NotSatisfiable(unsat, variables, operators)
+	assert.Equal(t, `operator "foo" requires package "bar" in version range ">=2.0.0", satisfied by bar 2.1.0; `+
+		`operator "baz" has baz 1.3.0 installed, which can only be kept; `+
+		`bar 2.1.0 requires package "baz" in version range "<1.0.0", satisfied by baz 0.9.0; `+
+		`only one of baz 0.9.0, installed baz 1.3.0 can be installed as they belong to package "baz"`, explanation.String())
+	assert.Equal(t, "constraints not satisfiable: "+explanation.String(), explanation.Error())
+	assert.True(t, errors.As(explanation, &deppy.NotSatisfiable{}))
+
+	require.Len(t, explanation.Causes, 4)
+	assert.Equal(t, explain.Cause{
+		Type:      explain.CauseTypeRequiredPackage,
+		Message:   `operator "foo" requires package "bar" in version range ">=2.0.0", satisfied by bar 2.1.0`,
+		Operators: []string{"foo"},
+		Package:   "bar",
+		Bundles:   []explain.BundleRef{{Catalog: "test-catalog", Package: "bar", Name: "bar.v2.1.0", Version: "2.1.0"}},
+	}, explanation.Causes[0])
+	assert.Equal(t, explain.CauseTypeInstalledPackage, explanation.Causes[1].Type)
+	assert.Equal(t, &explain.BundleRef{Catalog: "test-catalog", Package: "baz", Name: "baz.v1.3.0", Version: "1.3.0"}, explanation.Causes[1].Bundle)
+	assert.Equal(t, explain.CauseTypeBundleDependency, explanation.Causes[2].Type)
+	assert.Equal(t, "bar", explanation.Causes[2].Package)
+	assert.Equal(t, explain.Cause{
+		Type:    explain.CauseTypeUniqueness,
+		Message: `only one of baz 0.9.0, installed baz 1.3.0 can be installed as they belong to package "baz"`,
+		Package: "baz",
+		Bundles: []explain.BundleRef{
+			{Catalog: "test-catalog", Package: "baz", Name: "baz.v0.9.0", Version: "0.9.0"},
+			{Catalog: "test-catalog", Package: "baz", Name: "baz.v1.3.0", Version: "1.3.0"},
+		},
+	}, explanation.Causes[3])
+}
+
+func TestNotSatisfiableWithoutCandidates(t *testing.T) {
+	variables := []deppy.Variable{
+		olmvariables.NewRequiredPackageVariable("bar", nil),
+	}
+
+	solution, err := solver.NewDeppySolver(staticVariableSource(variables)).Solve(context.Background())
+	require.NoError(t, err)
+	unsat := deppy.NotSatisfiable{}
+	require.ErrorAs(t, solution.Error(), &unsat)
+
+	explanation := explain.NotSatisfiable(unsat, variables, nil)
+	assert.Equal(t, `package "bar" is required, which no bundle satisfies`, explanation.String())
+}
+
+func TestNotSatisfiableGVKUniqueness(t *testing.T) {
+	fooGVK := property.Property{Type: property.TypeGVK, Value: json.RawMessage(`{"group": "foo.io", "kind": "Foo", "version": "v1"}`)}
+	foo10 := bundle("foo", "1.0.0", fooGVK)
+	otherFoo20 := bundle("other-foo", "2.0.0", fooGVK)
+
+	variables := []deppy.Variable{
+		olmvariables.NewRequiredPackageVariable("foo", []*catalogmetadata.Bundle{foo10}),
+		olmvariables.NewRequiredPackageVariable("other-foo", []*catalogmetadata.Bundle{otherFoo20}),
+		olmvariables.NewBundleVariable(foo10, nil),
+		olmvariables.NewBundleVariable(otherFoo20, nil),
+		olmvariables.NewGVKUniquenessVariable(property.GVK{Group: "foo.io", Version: "v1", Kind: "Foo"},
+			olmvariables.BundleVariableID(foo10), olmvariables.BundleVariableID(otherFoo20)),
+	}
+
+	solution, err := solver.NewDeppySolver(staticVariableSource(variables)).Solve(context.Background())
+	require.NoError(t, err)
+	unsat := deppy.NotSatisfiable{}
+	require.ErrorAs(t, solution.Error(), &unsat)
+
+	explanation := explain.NotSatisfiable(unsat, variables, nil)
+	require.Len(t, explanation.Causes, 3)
+	assert.Equal(t, explain.Cause{
+		Type:    explain.CauseTypeGVKUniqueness,
+		Message: `only one of foo 1.0.0, other-foo 2.0.0 can be installed as packages "foo", "other-foo" all provide foo.io/v1/Foo`,
+		GVK:     "foo.io/v1/Foo",
+		Bundles: []explain.BundleRef{
+			{Catalog: "test-catalog", Package: "foo", Name: "foo.v1.0.0", Version: "1.0.0"},
+			{Catalog: "test-catalog", Package: "other-foo", Name: "other-foo.v2.0.0", Version: "2.0.0"},
+		},
+	}, explanation.Causes[2])
+}
+
+func TestNotSatisfiableGVKDependency(t *testing.T) {
+	foo10 := bundle("foo", "1.0.0", property.Property{Type: property.TypeGVKRequired, Value: json.RawMessage(`{"group": "bar.io", "kind": "Bar", "version": "v1"}`)})
+	bar09 := bundle("bar", "0.9.0")
+	bar10 := bundle("bar", "1.0.0", property.Property{Type: property.TypeGVK, Value: json.RawMessage(`{"group": "bar.io", "kind": "Bar", "version": "v1"}`)})
+
+	variables := []deppy.Variable{
+		olmvariables.NewRequiredPackageVariable("foo", []*catalogmetadata.Bundle{foo10}),
+		olmvariables.NewBundleVariable(foo10, []*catalogmetadata.Bundle{bar10}),
+		olmvariables.NewInstalledPackageVariable("bar", bar09, []*catalogmetadata.Bundle{bar09}, nil, nil),
+		olmvariables.NewBundleVariable(bar09, nil),
+		olmvariables.NewBundleVariable(bar10, nil),
+		olmvariables.NewBundleUniquenessVariable("bar package uniqueness", olmvariables.BundleVariableID(bar09), olmvariables.BundleVariableID(bar10)),
+	}
+
+	solution, err := solver.NewDeppySolver(staticVariableSource(variables)).Solve(context.Background())
+	require.NoError(t, err)
+	unsat := deppy.NotSatisfiable{}
+	require.ErrorAs(t, solution.Error(), &unsat)
+
+	explanation := explain.NotSatisfiable(unsat, variables, nil)
+	assert.Contains(t, explanation.String(), `foo 1.0.0 requires bar.io/v1/Bar, satisfied by bar 1.0.0`)
+}
+
+func TestNotSatisfiableConstraintDependency(t *testing.T) {
+	foo10 := bundle("foo", "1.0.0", property.Property{Type: "olm.constraint", Value: json.RawMessage(`{"failureMessage": "foo needs bar", "cel": {"rule": "properties.exists(p, p.type == 'olm.package' && p.value.packageName == 'bar')"}}`)})
+	bar09 := bundle("bar", "0.9.0")
+	bar10 := bundle("bar", "1.0.0")
+
+	variables := []deppy.Variable{
+		olmvariables.NewRequiredPackageVariable("foo", []*catalogmetadata.Bundle{foo10}),
+		olmvariables.NewBundleVariable(foo10, []*catalogmetadata.Bundle{bar10}),
+		olmvariables.NewInstalledPackageVariable("bar", bar09, []*catalogmetadata.Bundle{bar09}, nil, nil),
+		olmvariables.NewBundleVariable(bar09, nil),
+		olmvariables.NewBundleVariable(bar10, nil),
+		olmvariables.NewBundleUniquenessVariable("bar package uniqueness", olmvariables.BundleVariableID(bar09), olmvariables.BundleVariableID(bar10)),
+	}
+
+	solution, err := solver.NewDeppySolver(staticVariableSource(variables)).Solve(context.Background())
+	require.NoError(t, err)
+	unsat := deppy.NotSatisfiable{}
+	require.ErrorAs(t, solution.Error(), &unsat)
+
+	explanation := explain.NotSatisfiable(unsat, variables, nil)
+	assert.Contains(t, explanation.String(), `foo 1.0.0 requires cel rule "properties.exists(p, p.type == 'olm.package' && p.value.packageName == 'bar')" (foo needs bar), satisfied by bar 1.0.0`)
+}
+
+func TestNotSatisfiableClusterVersion(t *testing.T) {
+	foo20 := bundle("foo", "2.0.0")
+
+	variables := []deppy.Variable{
+		olmvariables.NewRequiredPackageVariable("foo", []*catalogmetadata.Bundle{foo20}),
+		olmvariables.NewBundleVariable(foo20, nil),
+		olmvariables.NewIncompatibleBundleVariable(foo20, "requires kubernetes 1.28.0 or later, the cluster runs kubernetes 1.27.3"),
+	}
+
+	solution, err := solver.NewDeppySolver(staticVariableSource(variables)).Solve(context.Background())
+	require.NoError(t, err)
+	unsat := deppy.NotSatisfiable{}
+	require.ErrorAs(t, solution.Error(), &unsat)
+
+	explanation := explain.NotSatisfiable(unsat, variables, nil)
+	require.Len(t, explanation.Causes, 2)
+	assert.Equal(t, explain.Cause{
+		Type:    explain.CauseTypeClusterVersion,
+		Message: "foo 2.0.0 cannot be installed on this cluster as it requires kubernetes 1.28.0 or later, the cluster runs kubernetes 1.27.3",
+		Package: "foo",
+		Bundle:  &explain.BundleRef{Catalog: "test-catalog", Package: "foo", Name: "foo.v2.0.0", Version: "2.0.0"},
+	}, explanation.Causes[1])
+}
@@ -0,0 +1,127 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resolver
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	catalogd "github.com/operator-framework/catalogd/api/core/v1alpha1"
+	rukpakv1alpha1 "github.com/operator-framework/rukpak/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	operatorsv1alpha1 "github.com/operator-framework/operator-controller/api/v1alpha1"
+	catalogclient "github.com/operator-framework/operator-controller/internal/catalogmetadata/client"
+	"github.com/operator-framework/operator-controller/internal/clusterversion"
+)
+
+// KeyFunc describes the inputs of a resolution as a string.
+// Two calls return the same key as long as the inputs stay the same.
+type KeyFunc func(ctx context.Context) (string, error)
+
+// Keys combines the keys of several inputs into a single key.
+func Keys(keyFuncs ...KeyFunc) KeyFunc {
+	return func(ctx context.Context) (string, error) {
+		keys := make([]string, 0, len(keyFuncs))
+		for _, keyFunc := range keyFuncs {
+			key, err := keyFunc(ctx)
+			if err != nil {
+				return "", err
+			}
+			keys = append(keys, key)
+		}
+		return strings.Join(keys, "\n"), nil
+	}
+}
+
+// OperatorGenerations returns a key which changes whenever an Operator
+// is created, deleted or has its spec changed.
+func OperatorGenerations(cl client.Client) KeyFunc {
+	return func(ctx context.Context) (string, error) {
+		operatorList := operatorsv1alpha1.OperatorList{}
+		if err := cl.List(ctx, &operatorList); err != nil {
+			return "", err
+		}
+
+		entries := make([]string, 0, len(operatorList.Items))
+		for _, operator := range operatorList.Items {
+			entries = append(entries, fmt.Sprintf("operator/%s/%s/%d/%t",
+				operator.GetName(), operator.GetUID(), operator.GetGeneration(), !operator.GetDeletionTimestamp().IsZero()))
+		}
+		return joinSorted(entries), nil
+	}
+}
+
+// BundleDeploymentGenerations returns a key which changes whenever a BundleDeployment
+// is created, deleted or has its spec changed.
+func BundleDeploymentGenerations(cl client.Client) KeyFunc {
+	return func(ctx context.Context) (string, error) {
+		bundleDeploymentList := rukpakv1alpha1.BundleDeploymentList{}
+		if err := cl.List(ctx, &bundleDeploymentList); err != nil {
+			return "", err
+		}
+
+		entries := make([]string, 0, len(bundleDeploymentList.Items))
+		for _, bundleDeployment := range bundleDeploymentList.Items {
+			entries = append(entries, fmt.Sprintf("bundledeployment/%s/%s/%d",
+				bundleDeployment.GetName(), bundleDeployment.GetUID(), bundleDeployment.GetGeneration()))
+		}
+		return joinSorted(entries), nil
+	}
+}
+
+// CatalogResolvedRefs returns a key which changes whenever a Catalog
+// is created, deleted, unpacks new content or has its labels or priority changed.
+func CatalogResolvedRefs(cl client.Client) KeyFunc {
+	return func(ctx context.Context) (string, error) {
+		catalogList := catalogd.CatalogList{}
+		if err := cl.List(ctx, &catalogList); err != nil {
+			return "", err
+		}
+
+		entries := make([]string, 0, len(catalogList.Items))
+		for _, catalog := range catalogList.Items {
+			resolvedRef := ""
+			if catalog.Status.ResolvedSource != nil && catalog.Status.ResolvedSource.Image != nil {
+				resolvedRef = catalog.Status.ResolvedSource.Image.Ref
+			}
+			entries = append(entries, fmt.Sprintf("catalog/%s/%s/%s/%s/%s",
+				catalog.GetName(), catalog.GetUID(), resolvedRef,
+				catalog.GetAnnotations()[catalogclient.CatalogPriorityAnnotation], labels.Set(catalog.GetLabels())))
+		}
+		return joinSorted(entries), nil
+	}
+}
+
+// ClusterVersion returns a key which changes whenever the version of the cluster changes.
+func ClusterVersion(provider clusterversion.Provider) KeyFunc {
+	return func(ctx context.Context) (string, error) {
+		version, err := provider.ClusterVersion(ctx)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("cluster/%s", version), nil
+	}
+}
+
+func joinSorted(entries []string) string {
+	sort.Strings(entries)
+	return strings.Join(entries, "\n")
+}
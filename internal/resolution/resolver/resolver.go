@@ -0,0 +1,86 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resolver
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/operator-framework/deppy/pkg/deppy/input"
+	"github.com/operator-framework/deppy/pkg/deppy/solver"
+
+	"github.com/operator-framework/operator-controller/internal/metrics"
+	olmvariables "github.com/operator-framework/operator-controller/internal/resolution/variables"
+)
+
+// Resolver solves the resolution problem described by a variable source
+// and shares the solution between callers for as long as the inputs of
+// the resolution, as described by its key, stay the same.
+//
+// Reconciles of several Operators in a row therefore only pay for
+// a single resolution until an Operator, a BundleDeployment or a Catalog changes.
+type Resolver struct {
+	solver  *solver.DeppySolver
+	keyFunc KeyFunc
+
+	mu       sync.Mutex
+	key      string
+	solution *solver.Solution
+}
+
+func New(variableSource input.VariableSource, keyFunc KeyFunc) *Resolver {
+	return &Resolver{
+		solver:  solver.NewDeppySolver(variableSource),
+		keyFunc: keyFunc,
+	}
+}
+
+// Solve returns the solution for the current inputs of the resolution.
+// The solution is computed again only when the inputs have changed since
+// the previous call, with the given options. Solutions always include all
+// the variables considered, as they are shared between callers, which must
+// therefore pass the same options. Errors are never shared between calls,
+// neither are solutions computed while some catalogs were unavailable.
+func (r *Resolver) Solve(ctx context.Context, options ...solver.Option) (*solver.Solution, error) {
+	key, err := r.keyFunc(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.solution != nil && r.key == key {
+		metrics.ResolutionCacheHits.Inc()
+		return r.solution, nil
+	}
+
+	start := time.Now()
+	solution, err := r.solver.Solve(ctx, append([]solver.Option{solver.AddAllVariablesToSolution()}, options...)...)
+	metrics.ObserveResolution(start, solution, err)
+	if err != nil {
+		return nil, err
+	}
+	if len(olmvariables.UnavailableCatalogs(solution.AllVariables()...)) > 0 {
+		r.key, r.solution = "", nil
+		return solution, nil
+	}
+	r.key, r.solution = key, solution
+
+	return solution, nil
+}
@@ -0,0 +1,193 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resolver_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	bsemver "github.com/blang/semver/v4"
+	catalogd "github.com/operator-framework/catalogd/api/core/v1alpha1"
+	"github.com/operator-framework/deppy/pkg/deppy"
+	"github.com/operator-framework/deppy/pkg/deppy/constraint"
+	"github.com/operator-framework/deppy/pkg/deppy/input"
+	rukpakv1alpha1 "github.com/operator-framework/rukpak/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	operatorsv1alpha1 "github.com/operator-framework/operator-controller/api/v1alpha1"
+	"github.com/operator-framework/operator-controller/internal/clusterversion"
+	"github.com/operator-framework/operator-controller/internal/resolution/resolver"
+)
+
+func TestResolver(t *testing.T) {
+	ctx := context.Background()
+
+	operator := &operatorsv1alpha1.Operator{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-operator"},
+		Spec:       operatorsv1alpha1.OperatorSpec{PackageName: "test-package"},
+	}
+	bundleDeployment := &rukpakv1alpha1.BundleDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-operator"},
+	}
+	catalog := &catalogd.Catalog{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-catalog"},
+		Status: catalogd.CatalogStatus{
+			ResolvedSource: &catalogd.CatalogSource{
+				Type:  catalogd.SourceTypeImage,
+				Image: &catalogd.ImageSource{Ref: "quay.io/test/catalog@sha256:1"},
+			},
+		},
+	}
+	cl := fakeClient(operator, bundleDeployment, catalog)
+	clusterVersion := &clusterversion.Static{Version: &clusterversion.Version{Kubernetes: &bsemver.Version{Major: 1, Minor: 27}}}
+
+	source := &countingVariableSource{}
+	r := resolver.New(source, resolver.Keys(
+		resolver.OperatorGenerations(cl),
+		resolver.BundleDeploymentGenerations(cl),
+		resolver.CatalogResolvedRefs(cl),
+		resolver.ClusterVersion(clusterVersion),
+	))
+
+	solution, err := r.Solve(ctx)
+	require.NoError(t, err)
+	require.NoError(t, solution.Error())
+	assert.True(t, solution.IsSelected("test-variable"))
+	assert.Len(t, solution.AllVariables(), 1)
+	assert.Equal(t, 1, source.calls)
+
+	t.Run("unchanged inputs share the solution", func(t *testing.T) {
+		sharedSolution, err := r.Solve(ctx)
+		require.NoError(t, err)
+		assert.Same(t, solution, sharedSolution)
+		assert.Equal(t, 1, source.calls)
+	})
+
+	t.Run("status changes do not trigger a resolution", func(t *testing.T) {
+		operator.Status.ResolvedBundleResource = "quay.io/test/bundle@sha256:1"
+		require.NoError(t, cl.Status().Update(ctx, operator))
+
+		_, err := r.Solve(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, source.calls)
+	})
+
+	for _, tt := range []struct {
+		name   string
+		change func(t *testing.T)
+	}{
+		{
+			name: "operator spec change",
+			change: func(t *testing.T) {
+				operator.Spec.Version = "1.0.0"
+				operator.SetGeneration(operator.GetGeneration() + 1)
+				require.NoError(t, cl.Update(ctx, operator))
+			},
+		},
+		{
+			name: "bundle deployment spec change",
+			change: func(t *testing.T) {
+				bundleDeployment.SetGeneration(bundleDeployment.GetGeneration() + 1)
+				require.NoError(t, cl.Update(ctx, bundleDeployment))
+			},
+		},
+		{
+			name: "catalog resolved ref change",
+			change: func(t *testing.T) {
+				catalog.Status.ResolvedSource.Image.Ref = "quay.io/test/catalog@sha256:2"
+				require.NoError(t, cl.Status().Update(ctx, catalog))
+			},
+		},
+		{
+			name: "catalog label change",
+			change: func(t *testing.T) {
+				catalog.SetLabels(map[string]string{"tier": "internal"})
+				require.NoError(t, cl.Update(ctx, catalog))
+			},
+		},
+		{
+			name: "cluster version change",
+			change: func(t *testing.T) {
+				clusterVersion.Version = &clusterversion.Version{Kubernetes: &bsemver.Version{Major: 1, Minor: 28}}
+			},
+		},
+		{
+			name: "new operator",
+			change: func(t *testing.T) {
+				require.NoError(t, cl.Create(ctx, &operatorsv1alpha1.Operator{
+					ObjectMeta: metav1.ObjectMeta{Name: "another-operator"},
+					Spec:       operatorsv1alpha1.OperatorSpec{PackageName: "another-package"},
+				}))
+			},
+		},
+	} {
+		t.Run(tt.name+" triggers a resolution", func(t *testing.T) {
+			calls := source.calls
+			tt.change(t)
+
+			newSolution, err := r.Solve(ctx)
+			require.NoError(t, err)
+			assert.NotSame(t, solution, newSolution)
+			assert.Equal(t, calls+1, source.calls)
+			solution = newSolution
+		})
+	}
+
+	t.Run("errors are not shared", func(t *testing.T) {
+		require.NoError(t, cl.Delete(ctx, bundleDeployment))
+		calls := source.calls
+		source.err = errors.New("fake error from GetVariables")
+
+		_, err := r.Solve(ctx)
+		assert.EqualError(t, err, "fake error from GetVariables")
+
+		source.err = nil
+		_, err = r.Solve(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, calls+2, source.calls)
+	})
+}
+
+func fakeClient(objects ...client.Object) client.Client {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(operatorsv1alpha1.AddToScheme(scheme))
+	utilruntime.Must(rukpakv1alpha1.AddToScheme(scheme))
+	utilruntime.Must(catalogd.AddToScheme(scheme))
+	return fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build()
+}
+
+var _ input.VariableSource = &countingVariableSource{}
+
+type countingVariableSource struct {
+	calls int
+	err   error
+}
+
+func (c *countingVariableSource) GetVariables(_ context.Context) ([]deppy.Variable, error) {
+	c.calls++
+	if c.err != nil {
+		return nil, c.err
+	}
+	return []deppy.Variable{input.NewSimpleVariable("test-variable", constraint.Mandatory())}, nil
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/operator-framework/deppy/pkg/deppy"
 	"github.com/operator-framework/deppy/pkg/deppy/constraint"
 	"github.com/operator-framework/deppy/pkg/deppy/input"
+	"github.com/operator-framework/operator-registry/alpha/property"
 
 	"github.com/operator-framework/operator-controller/internal/catalogmetadata"
 )
@@ -26,13 +27,23 @@ func (b *BundleVariable) Dependencies() []*catalogmetadata.Bundle {
 	return b.dependencies
 }
 
-func NewBundleVariable(bundle *catalogmetadata.Bundle, dependencies []*catalogmetadata.Bundle) *BundleVariable {
-	dependencyIDs := make([]deppy.Identifier, 0, len(dependencies))
-	for _, dependency := range dependencies {
-		dependencyIDs = append(dependencyIDs, BundleVariableID(dependency))
+// NewBundleVariable returns the variable of a bundle with the given dependencies.
+// Each of the requirements is a group of dependencies, one of which must be
+// selected along with the bundle, so that every requirement holds on its own.
+// Without requirements, all the dependencies make up a single requirement.
+func NewBundleVariable(bundle *catalogmetadata.Bundle, dependencies []*catalogmetadata.Bundle, requirements ...[]*catalogmetadata.Bundle) *BundleVariable {
+	if len(requirements) == 0 {
+		requirements = [][]*catalogmetadata.Bundle{dependencies}
 	}
 	var constraints []deppy.Constraint
-	if len(dependencyIDs) > 0 {
+	for _, requirement := range requirements {
+		if len(requirement) == 0 {
+			continue
+		}
+		dependencyIDs := make([]deppy.Identifier, 0, len(requirement))
+		for _, dependency := range requirement {
+			dependencyIDs = append(dependencyIDs, BundleVariableID(dependency))
+		}
 		constraints = append(constraints, constraint.Dependency(dependencyIDs...))
 	}
 	return &BundleVariable{
@@ -59,6 +70,70 @@ func NewBundleUniquenessVariable(id deppy.Identifier, atMostIDs ...deppy.Identif
 	}
 }
 
+var _ deppy.Variable = &GVKUniquenessVariable{}
+
+// GVKUniquenessVariable is a bundle uniqueness variable which instructs the resolver to choose at most
+// a single bundle among the bundles providing a given gvk, so that no two operators own the same API.
+type GVKUniquenessVariable struct {
+	*input.SimpleVariable
+	gvk property.GVK
+}
+
+// GVK returns the gvk which can be provided by at most a single bundle.
+func (g *GVKUniquenessVariable) GVK() property.GVK {
+	return g.gvk
+}
+
+func NewGVKUniquenessVariable(gvk property.GVK, atMostIDs ...deppy.Identifier) *GVKUniquenessVariable {
+	return &GVKUniquenessVariable{
+		SimpleVariable: input.NewSimpleVariable(GVKUniquenessVariableID(gvk), constraint.AtMost(1, atMostIDs...)),
+		gvk:            gvk,
+	}
+}
+
+// GVKUniquenessVariableID returns the ID of the uniqueness variable of a given gvk.
+func GVKUniquenessVariableID(gvk property.GVK) deppy.Identifier {
+	return deppy.IdentifierFromString(fmt.Sprintf("%s gvk uniqueness", GVKString(gvk)))
+}
+
+// GVKString returns the group/version/kind form of a gvk.
+func GVKString(gvk property.GVK) string {
+	return fmt.Sprintf("%s/%s/%s", gvk.Group, gvk.Version, gvk.Kind)
+}
+
+var _ deppy.Variable = &IncompatibleBundleVariable{}
+
+// IncompatibleBundleVariable is a mandatory variable which keeps a bundle
+// that cannot be installed on the cluster out of the solution.
+type IncompatibleBundleVariable struct {
+	*input.SimpleVariable
+	bundle *catalogmetadata.Bundle
+	reason string
+}
+
+// Bundle returns the bundle which cannot be installed.
+func (i *IncompatibleBundleVariable) Bundle() *catalogmetadata.Bundle {
+	return i.bundle
+}
+
+// Reason tells why the bundle cannot be installed.
+func (i *IncompatibleBundleVariable) Reason() string {
+	return i.reason
+}
+
+func NewIncompatibleBundleVariable(bundle *catalogmetadata.Bundle, reason string) *IncompatibleBundleVariable {
+	return &IncompatibleBundleVariable{
+		SimpleVariable: input.NewSimpleVariable(IncompatibleBundleVariableID(bundle), constraint.Mandatory(), constraint.Conflict(BundleVariableID(bundle))),
+		bundle:         bundle,
+		reason:         reason,
+	}
+}
+
+// IncompatibleBundleVariableID returns the ID of the variable excluding a given bundle.
+func IncompatibleBundleVariableID(bundle *catalogmetadata.Bundle) deppy.Identifier {
+	return deppy.IdentifierFromString(fmt.Sprintf("%s incompatible", BundleVariableID(bundle)))
+}
+
 // BundleVariableID returns an ID for a given bundle.
 func BundleVariableID(bundle *catalogmetadata.Bundle) deppy.Identifier {
 	return deppy.Identifier(
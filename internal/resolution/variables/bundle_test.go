@@ -72,3 +72,56 @@ func TestBundleUniquenessVariable(t *testing.T) {
 		}
 	}
 }
+
+func TestGVKUniquenessVariable(t *testing.T) {
+	gvk := property.GVK{Group: "foo.io", Version: "v1", Kind: "Foo"}
+	atMostIDs := []deppy.Identifier{
+		deppy.IdentifierFromString("test-at-most-id-1"),
+		deppy.IdentifierFromString("test-at-most-id-2"),
+	}
+	gvkUniquenessVariable := olmvariables.NewGVKUniquenessVariable(gvk, atMostIDs...)
+
+	id := deppy.IdentifierFromString("foo.io/v1/Foo gvk uniqueness")
+	if gvkUniquenessVariable.Identifier() != id {
+		t.Errorf("identifier '%v' does not match expected '%v'", gvkUniquenessVariable.Identifier(), id)
+	}
+	if gvkUniquenessVariable.GVK() != gvk {
+		t.Errorf("gvk '%v' does not match expected '%v'", gvkUniquenessVariable.GVK(), gvk)
+	}
+
+	constraints := []deppy.Constraint{constraint.AtMost(1, atMostIDs...)}
+	for i, c := range gvkUniquenessVariable.Constraints() {
+		if c.String("test") != constraints[i].String("test") {
+			t.Errorf("constraint[%v] '%v' does not match expected '%v'", i, c, constraints[i])
+		}
+	}
+}
+
+func TestIncompatibleBundleVariable(t *testing.T) {
+	bundle := &catalogmetadata.Bundle{
+		CatalogName: "fake-catalog",
+		Bundle:      declcfg.Bundle{Name: "bundle-1", Package: "test-package"},
+	}
+	incompatibleBundleVariable := olmvariables.NewIncompatibleBundleVariable(bundle, "fake reason")
+
+	id := deppy.IdentifierFromString("fake-catalog-test-package-bundle-1 incompatible")
+	if incompatibleBundleVariable.Identifier() != id {
+		t.Errorf("identifier '%v' does not match expected '%v'", incompatibleBundleVariable.Identifier(), id)
+	}
+	if incompatibleBundleVariable.Bundle() != bundle {
+		t.Errorf("bundle '%v' does not match expected '%v'", incompatibleBundleVariable.Bundle(), bundle)
+	}
+	if incompatibleBundleVariable.Reason() != "fake reason" {
+		t.Errorf("reason '%v' does not match expected '%v'", incompatibleBundleVariable.Reason(), "fake reason")
+	}
+
+	constraints := []deppy.Constraint{constraint.Mandatory(), constraint.Conflict(olmvariables.BundleVariableID(bundle))}
+	if len(incompatibleBundleVariable.Constraints()) != len(constraints) {
+		t.Fatalf("expected %d constraints, got %d", len(constraints), len(incompatibleBundleVariable.Constraints()))
+	}
+	for i, c := range incompatibleBundleVariable.Constraints() {
+		if c.String("test") != constraints[i].String("test") {
+			t.Errorf("constraint[%v] '%v' does not match expected '%v'", i, c, constraints[i])
+		}
+	}
+}
@@ -14,21 +14,65 @@ var _ deppy.Variable = &InstalledPackageVariable{}
 
 type InstalledPackageVariable struct {
 	*input.SimpleVariable
-	bundles []*catalogmetadata.Bundle
+	packageName         string
+	installedBundle     *catalogmetadata.Bundle
+	bundles             []*catalogmetadata.Bundle
+	availableUpgrades   []*catalogmetadata.Bundle
+	pendingUpgrade      *catalogmetadata.Bundle
+	unavailableCatalogs []catalogmetadata.UnavailableCatalog
+}
+
+// InstalledBundle returns the bundle of the package which is currently installed.
+func (r *InstalledPackageVariable) InstalledBundle() *catalogmetadata.Bundle {
+	return r.installedBundle
+}
+
+func (r *InstalledPackageVariable) PackageName() string {
+	return r.packageName
 }
 
 func (r *InstalledPackageVariable) Bundles() []*catalogmetadata.Bundle {
 	return r.bundles
 }
 
-func NewInstalledPackageVariable(packageName string, bundles []*catalogmetadata.Bundle) *InstalledPackageVariable {
-	id := deppy.IdentifierFromString(fmt.Sprintf("installed package %s", packageName))
+// AvailableUpgrades returns the successors of the installed bundle
+// following the upgrade graph, regardless of the constraints of the
+// operator which limit the bundles it can be upgraded to.
+func (r *InstalledPackageVariable) AvailableUpgrades() []*catalogmetadata.Bundle {
+	return r.availableUpgrades
+}
+
+// PendingUpgrade returns the bundle the installed bundle would be upgraded to,
+// had its version been approved. It is nil unless the upgrades of the installed
+// bundle are limited to an approved version which differs from it.
+func (r *InstalledPackageVariable) PendingUpgrade() *catalogmetadata.Bundle {
+	return r.pendingUpgrade
+}
+
+// UnavailableCatalogs returns the catalogs which could have provided
+// upgrades for the installed bundle, but could not be read.
+func (r *InstalledPackageVariable) UnavailableCatalogs() []catalogmetadata.UnavailableCatalog {
+	return r.unavailableCatalogs
+}
+
+func NewInstalledPackageVariable(packageName string, installedBundle *catalogmetadata.Bundle, bundles []*catalogmetadata.Bundle, availableUpgrades []*catalogmetadata.Bundle, pendingUpgrade *catalogmetadata.Bundle, unavailableCatalogs ...catalogmetadata.UnavailableCatalog) *InstalledPackageVariable {
+	id := InstalledPackageVariableID(packageName)
 	variableIDs := make([]deppy.Identifier, 0, len(bundles))
 	for _, bundle := range bundles {
 		variableIDs = append(variableIDs, BundleVariableID(bundle))
 	}
 	return &InstalledPackageVariable{
-		SimpleVariable: input.NewSimpleVariable(id, constraint.Mandatory(), constraint.Dependency(variableIDs...)),
-		bundles:        bundles,
+		SimpleVariable:      input.NewSimpleVariable(id, constraint.Mandatory(), constraint.Dependency(variableIDs...)),
+		packageName:         packageName,
+		installedBundle:     installedBundle,
+		bundles:             bundles,
+		availableUpgrades:   availableUpgrades,
+		pendingUpgrade:      pendingUpgrade,
+		unavailableCatalogs: unavailableCatalogs,
 	}
 }
+
+// InstalledPackageVariableID returns the ID of the installed package variable of a given package.
+func InstalledPackageVariableID(packageName string) deppy.Identifier {
+	return deppy.IdentifierFromString(fmt.Sprintf("installed package %s", packageName))
+}
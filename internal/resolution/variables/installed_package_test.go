@@ -27,16 +27,33 @@ func TestInstalledPackageVariable(t *testing.T) {
 			{Type: property.TypePackage, Value: json.RawMessage(`{"packageName": "test-package", "version": "3.0.0"}`)},
 		}}},
 	}
-	ipv := olmvariables.NewInstalledPackageVariable(packageName, bundles)
+	ipv := olmvariables.NewInstalledPackageVariable(packageName, bundles[0], bundles, bundles[1:], bundles[1])
 
 	id := deppy.IdentifierFromString(fmt.Sprintf("installed package %s", packageName))
 	if ipv.Identifier() != id {
 		t.Errorf("package name '%v' does not match expected '%v'", ipv.Identifier(), id)
 	}
 
+	if ipv.InstalledBundle() != bundles[0] {
+		t.Errorf("installed bundle '%v' does not match expected '%v'", ipv.InstalledBundle(), bundles[0])
+	}
+
 	for i, e := range ipv.Bundles() {
 		if e != bundles[i] {
 			t.Errorf("bundle[%v] '%v' does not match expected '%v'", i, e, bundles[i])
 		}
 	}
+
+	if len(ipv.AvailableUpgrades()) != 2 {
+		t.Fatalf("expected 2 available upgrades, got %d", len(ipv.AvailableUpgrades()))
+	}
+	for i, e := range ipv.AvailableUpgrades() {
+		if e != bundles[i+1] {
+			t.Errorf("availableUpgrades[%v] '%v' does not match expected '%v'", i, e, bundles[i+1])
+		}
+	}
+
+	if ipv.PendingUpgrade() != bundles[1] {
+		t.Errorf("pending upgrade '%v' does not match expected '%v'", ipv.PendingUpgrade(), bundles[1])
+	}
 }
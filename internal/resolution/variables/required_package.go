@@ -14,21 +14,40 @@ var _ deppy.Variable = &RequiredPackageVariable{}
 
 type RequiredPackageVariable struct {
 	*input.SimpleVariable
-	bundles []*catalogmetadata.Bundle
+	packageName         string
+	bundles             []*catalogmetadata.Bundle
+	unavailableCatalogs []catalogmetadata.UnavailableCatalog
+}
+
+func (r *RequiredPackageVariable) PackageName() string {
+	return r.packageName
 }
 
 func (r *RequiredPackageVariable) Bundles() []*catalogmetadata.Bundle {
 	return r.bundles
 }
 
-func NewRequiredPackageVariable(packageName string, bundles []*catalogmetadata.Bundle) *RequiredPackageVariable {
-	id := deppy.IdentifierFromString(fmt.Sprintf("required package %s", packageName))
+// UnavailableCatalogs returns the catalogs which could have provided
+// bundles for the package, but could not be read.
+func (r *RequiredPackageVariable) UnavailableCatalogs() []catalogmetadata.UnavailableCatalog {
+	return r.unavailableCatalogs
+}
+
+func NewRequiredPackageVariable(packageName string, bundles []*catalogmetadata.Bundle, unavailableCatalogs ...catalogmetadata.UnavailableCatalog) *RequiredPackageVariable {
+	id := RequiredPackageVariableID(packageName)
 	variableIDs := make([]deppy.Identifier, 0, len(bundles))
 	for _, bundle := range bundles {
 		variableIDs = append(variableIDs, BundleVariableID(bundle))
 	}
 	return &RequiredPackageVariable{
-		SimpleVariable: input.NewSimpleVariable(id, constraint.Mandatory(), constraint.Dependency(variableIDs...)),
-		bundles:        bundles,
+		SimpleVariable:      input.NewSimpleVariable(id, constraint.Mandatory(), constraint.Dependency(variableIDs...)),
+		packageName:         packageName,
+		bundles:             bundles,
+		unavailableCatalogs: unavailableCatalogs,
 	}
 }
+
+// RequiredPackageVariableID returns the ID of the required package variable of a given package.
+func RequiredPackageVariableID(packageName string) deppy.Identifier {
+	return deppy.IdentifierFromString(fmt.Sprintf("required package %s", packageName))
+}
@@ -0,0 +1,36 @@
+package variables
+
+import (
+	"sort"
+
+	"github.com/operator-framework/deppy/pkg/deppy"
+
+	"github.com/operator-framework/operator-controller/internal/catalogmetadata"
+)
+
+// UnavailableCatalogs returns the catalogs which could not be read while producing
+// the given variables, sorted by name. Variables produced while catalogs were
+// unavailable may be incomplete, so they should not outlive the current resolution.
+func UnavailableCatalogs(variables ...deppy.Variable) []catalogmetadata.UnavailableCatalog {
+	byName := map[string]catalogmetadata.UnavailableCatalog{}
+	for _, variable := range variables {
+		v, ok := variable.(interface {
+			UnavailableCatalogs() []catalogmetadata.UnavailableCatalog
+		})
+		if !ok {
+			continue
+		}
+		for _, catalog := range v.UnavailableCatalogs() {
+			byName[catalog.Name] = catalog
+		}
+	}
+
+	unavailableCatalogs := make([]catalogmetadata.UnavailableCatalog, 0, len(byName))
+	for _, catalog := range byName {
+		unavailableCatalogs = append(unavailableCatalogs, catalog)
+	}
+	sort.Slice(unavailableCatalogs, func(i, j int) bool {
+		return unavailableCatalogs[i].Name < unavailableCatalogs[j].Name
+	})
+	return unavailableCatalogs
+}
@@ -0,0 +1,25 @@
+package variables_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/operator-framework/operator-controller/internal/catalogmetadata"
+	olmvariables "github.com/operator-framework/operator-controller/internal/resolution/variables"
+)
+
+func TestUnavailableCatalogs(t *testing.T) {
+	catalogA := catalogmetadata.UnavailableCatalog{Name: "catalog-a", Err: errors.New("fake error")}
+	catalogB := catalogmetadata.UnavailableCatalog{Name: "catalog-b", Err: errors.New("fake error")}
+
+	requiredPackage := olmvariables.NewRequiredPackageVariable("test-package", nil, catalogB)
+	installedPackage := olmvariables.NewInstalledPackageVariable("test-package", nil, nil, nil, nil, catalogA, catalogB)
+	otherPackage := olmvariables.NewRequiredPackageVariable("other-package", nil)
+	bundle := olmvariables.NewBundleVariable(&catalogmetadata.Bundle{}, nil)
+
+	assert.Equal(t, []catalogmetadata.UnavailableCatalog{catalogA, catalogB}, olmvariables.UnavailableCatalogs(requiredPackage, installedPackage, otherPackage, bundle))
+	assert.Equal(t, []catalogmetadata.UnavailableCatalog{catalogB}, olmvariables.UnavailableCatalogs(requiredPackage))
+	assert.Empty(t, olmvariables.UnavailableCatalogs(otherPackage, bundle))
+}
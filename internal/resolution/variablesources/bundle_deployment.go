@@ -6,8 +6,13 @@ import (
 	"github.com/operator-framework/deppy/pkg/deppy"
 	"github.com/operator-framework/deppy/pkg/deppy/input"
 	rukpakv1alpha1 "github.com/operator-framework/rukpak/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	operatorsv1alpha1 "github.com/operator-framework/operator-controller/api/v1alpha1"
+	"github.com/operator-framework/operator-controller/internal/controllers/validators"
 )
 
 var _ input.VariableSource = &BundleDeploymentVariableSource{}
@@ -37,15 +42,48 @@ func (o *BundleDeploymentVariableSource) GetVariables(ctx context.Context) ([]de
 		return nil, err
 	}
 
+	operatorList := operatorsv1alpha1.OperatorList{}
+	if err := o.client.List(ctx, &operatorList); err != nil {
+		return nil, err
+	}
+	operatorsByUID := make(map[types.UID]*operatorsv1alpha1.Operator, len(operatorList.Items))
+	for i := range operatorList.Items {
+		operatorsByUID[operatorList.Items[i].UID] = &operatorList.Items[i]
+	}
+
 	processed := sets.Set[string]{}
-	for _, bundleDeployment := range bundleDeployments.Items {
+	for i := range bundleDeployments.Items {
+		bundleDeployment := &bundleDeployments.Items[i]
 		sourceImage := bundleDeployment.Spec.Template.Spec.Source.Image
 		if sourceImage != nil && sourceImage.Ref != "" {
 			if processed.Has(sourceImage.Ref) {
 				continue
 			}
 			processed.Insert(sourceImage.Ref)
-			ips, err := NewInstalledPackageVariableSource(o.catalogClient, bundleDeployment.Spec.Template.Spec.Source.Image.Ref)
+
+			operator := owningOperator(bundleDeployment, operatorsByUID)
+			// bundles installed directly from a bundle image are not part of any catalog.
+			// This also holds once the operator has moved off its bundle image:
+			// it is then resolved from its package and its BundleDeployment replaced.
+			if operator != nil && operator.Spec.BundleImage != "" || installedFromBundleImage(bundleDeployment) {
+				continue
+			}
+
+			var options []InstalledPackageVariableSourceOption
+			// the constraints of operators with an invalid spec are left out,
+			// they must not fail the resolution of the other operators
+			if operator != nil && validators.ValidateOperatorSpec(operator) == nil {
+				options = append(options,
+					WithUpgradeConstraintPolicy(operator.Spec.UpgradeConstraintPolicy),
+					UpgradeInChannel(operator.Spec.Channel),
+					UpgradeInVersionRange(operator.Spec.Version),
+					UpgradeFromCatalogs(operator.Spec.CatalogSelector),
+				)
+				if operator.Spec.UpgradeApproval == operatorsv1alpha1.UpgradeApprovalManual {
+					options = append(options, UpgradeToApprovedVersion(operator.Spec.ApprovedVersion))
+				}
+			}
+			ips, err := NewInstalledPackageVariableSource(o.catalogClient, bundleDeployment.Spec.Template.Spec.Source.Image.Ref, options...)
 			if err != nil {
 				return nil, err
 			}
@@ -55,3 +93,19 @@ func (o *BundleDeploymentVariableSource) GetVariables(ctx context.Context) ([]de
 
 	return variableSources.GetVariables(ctx)
 }
+
+// installedFromBundleImage returns true if the given BundleDeployment
+// was created for the .spec.bundleImage of its Operator.
+func installedFromBundleImage(bundleDeployment *rukpakv1alpha1.BundleDeployment) bool {
+	return bundleDeployment.GetAnnotations()[operatorsv1alpha1.BundleImageAnnotation] == "true"
+}
+
+// owningOperator returns the Operator which controls the given BundleDeployment
+// or nil if the BundleDeployment is not controlled by a known Operator.
+func owningOperator(bundleDeployment *rukpakv1alpha1.BundleDeployment, operatorsByUID map[types.UID]*operatorsv1alpha1.Operator) *operatorsv1alpha1.Operator {
+	ownerRef := metav1.GetControllerOf(bundleDeployment)
+	if ownerRef == nil || ownerRef.Kind != "Operator" || ownerRef.APIVersion != operatorsv1alpha1.GroupVersion.String() {
+		return nil
+	}
+	return operatorsByUID[ownerRef.UID]
+}
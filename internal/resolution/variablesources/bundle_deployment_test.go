@@ -10,6 +10,7 @@ import (
 	"github.com/operator-framework/operator-registry/alpha/declcfg"
 	"github.com/operator-framework/operator-registry/alpha/property"
 
+	operatorsv1alpha1 "github.com/operator-framework/operator-controller/api/v1alpha1"
 	"github.com/operator-framework/operator-controller/internal/catalogmetadata"
 	olmvariables "github.com/operator-framework/operator-controller/internal/resolution/variables"
 	"github.com/operator-framework/operator-controller/internal/resolution/variablesources"
@@ -18,6 +19,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/utils/pointer"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
@@ -28,6 +30,7 @@ import (
 func BundleDeploymentFakeClient(objects ...client.Object) client.Client {
 	scheme := runtime.NewScheme()
 	utilruntime.Must(rukpakv1alpha1.AddToScheme(scheme))
+	utilruntime.Must(operatorsv1alpha1.AddToScheme(scheme))
 	return fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build()
 }
 
@@ -136,6 +139,73 @@ var _ = Describe("BundleDeploymentVariableSource", func() {
 			deppy.IdentifierFromString("installed package prometheus"): 2,
 		})))
 	})
+	It("should allow any bundle of the package when the owning operator ignores upgrade constraints", func() {
+		op := operator("prometheus")
+		op.UID = "prometheus-uid"
+		op.Spec.UpgradeConstraintPolicy = operatorsv1alpha1.UpgradeConstraintPolicyIgnore
+		bd := bundleDeployment("prometheus", "quay.io/operatorhubio/prometheus@sha256:5b04c49d8d3eff6a338b56ec90bdf491d501fe301c9cdfb740e5bff6769a21ed")
+		bd.SetOwnerReferences([]metav1.OwnerReference{{
+			APIVersion: operatorsv1alpha1.GroupVersion.String(),
+			Kind:       "Operator",
+			Name:       op.Name,
+			UID:        op.UID,
+			Controller: pointer.Bool(true),
+		}})
+		cl := BundleDeploymentFakeClient(op, bd)
+
+		bdVariableSource := variablesources.NewBundleDeploymentVariableSource(cl, &fakeCatalogClient, &MockRequiredPackageSource{})
+		variables, err := bdVariableSource.GetVariables(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+
+		installedPackageVariable := filterVariables[*olmvariables.InstalledPackageVariable](variables)
+		Expect(installedPackageVariable).To(HaveLen(1))
+		// 0.37.0 is a downgrade from 0.47.0, which is only allowed because upgrade constraints are ignored
+		Expect(installedPackageVariable[0].Bundles()).To(WithTransform(func(bundles []*catalogmetadata.Bundle) []string {
+			var names []string
+			for _, bundle := range bundles {
+				names = append(names, bundle.Name)
+			}
+			return names
+		}, Equal([]string{"operatorhub/prometheus/0.37.0", "operatorhub/prometheus/0.47.0"})))
+	})
+	It("should not produce InstalledPackage variables for operators installed from a bundle image", func() {
+		op := operator("hotfix")
+		op.UID = "hotfix-uid"
+		op.Spec.BundleImage = "foo.io/hotfix/hotfix:v1.0.1"
+		bd := bundleDeployment("hotfix", op.Spec.BundleImage)
+		bd.SetOwnerReferences([]metav1.OwnerReference{{
+			APIVersion: operatorsv1alpha1.GroupVersion.String(),
+			Kind:       "Operator",
+			Name:       op.Name,
+			UID:        op.UID,
+			Controller: pointer.Bool(true),
+		}})
+		cl := BundleDeploymentFakeClient(op, bd)
+
+		bdVariableSource := variablesources.NewBundleDeploymentVariableSource(cl, &fakeCatalogClient, &MockRequiredPackageSource{})
+		variables, err := bdVariableSource.GetVariables(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(filterVariables[*olmvariables.InstalledPackageVariable](variables)).To(BeEmpty())
+	})
+	It("should not produce InstalledPackage variables for operators moving off their bundle image", func() {
+		op := operator("hotfix")
+		op.UID = "hotfix-uid"
+		bd := bundleDeployment("hotfix", "foo.io/hotfix/hotfix:v1.0.1")
+		bd.SetAnnotations(map[string]string{operatorsv1alpha1.BundleImageAnnotation: "true"})
+		bd.SetOwnerReferences([]metav1.OwnerReference{{
+			APIVersion: operatorsv1alpha1.GroupVersion.String(),
+			Kind:       "Operator",
+			Name:       op.Name,
+			UID:        op.UID,
+			Controller: pointer.Bool(true),
+		}})
+		cl := BundleDeploymentFakeClient(op, bd)
+
+		bdVariableSource := variablesources.NewBundleDeploymentVariableSource(cl, &fakeCatalogClient, &MockRequiredPackageSource{})
+		variables, err := bdVariableSource.GetVariables(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(filterVariables[*olmvariables.InstalledPackageVariable](variables)).To(BeEmpty())
+	})
 	It("should return an error if the bundleDeployment image doesn't match any operator resource", func() {
 		cl := BundleDeploymentFakeClient(bundleDeployment("prometheus", "quay.io/operatorhubio/prometheus@sha256:nonexistent"))
 
@@ -2,11 +2,15 @@ package variablesources
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sort"
 
+	"github.com/operator-framework/api/pkg/constraints"
 	"github.com/operator-framework/deppy/pkg/deppy"
 	"github.com/operator-framework/deppy/pkg/deppy/input"
+	"github.com/operator-framework/operator-registry/alpha/property"
+	"golang.org/x/exp/slices"
 	"k8s.io/apimachinery/pkg/util/sets"
 
 	"github.com/operator-framework/operator-controller/internal/catalogmetadata"
@@ -52,7 +56,7 @@ func (b *BundlesAndDepsVariableSource) GetVariables(ctx context.Context) ([]depp
 		}
 	}
 
-	allBundles, err := b.catalogClient.Bundles(ctx)
+	index, err := b.catalogClient.BundleIndex(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -73,7 +77,7 @@ func (b *BundlesAndDepsVariableSource) GetVariables(ctx context.Context) ([]depp
 		visited.Insert(id)
 
 		// get bundle dependencies
-		dependencies, err := b.filterBundleDependencies(allBundles, head)
+		dependencies, requirements, err := b.filterBundleDependencies(index, head)
 		if err != nil {
 			return nil, fmt.Errorf("could not determine dependencies for bundle with id '%s': %w", id, err)
 		}
@@ -82,38 +86,110 @@ func (b *BundlesAndDepsVariableSource) GetVariables(ctx context.Context) ([]depp
 		bundleQueue = append(bundleQueue, dependencies...)
 
 		// create variable
-		variables = append(variables, olmvariables.NewBundleVariable(head, dependencies))
+		variables = append(variables, olmvariables.NewBundleVariable(head, dependencies, requirements...))
 	}
 
 	return variables, nil
 }
 
-func (b *BundlesAndDepsVariableSource) filterBundleDependencies(allBundles []*catalogmetadata.Bundle, bundle *catalogmetadata.Bundle) ([]*catalogmetadata.Bundle, error) {
+// filterBundleDependencies returns the bundles the bundle can depend on, along with
+// the requirements of the bundle: the bundles satisfying each of its required
+// packages, gvks and constraints, one of which must be selected for each of them.
+func (b *BundlesAndDepsVariableSource) filterBundleDependencies(index *catalogmetadata.BundleIndex, bundle *catalogmetadata.Bundle) ([]*catalogmetadata.Bundle, [][]*catalogmetadata.Bundle, error) {
 	var dependencies []*catalogmetadata.Bundle
+	var requirements [][]*catalogmetadata.Bundle
 	added := sets.Set[deppy.Identifier]{}
+	addRequirement := func(requirement []*catalogmetadata.Bundle) {
+		requirements = append(requirements, sortDependencies(requirement))
+		for _, dependency := range requirement {
+			id := olmvariables.BundleVariableID(dependency)
+			if !added.Has(id) {
+				dependencies = append(dependencies, dependency)
+				added.Insert(id)
+			}
+		}
+	}
 
 	// gather required package dependencies
 	// todo(perdasilva): disambiguate between not found and actual errors
 	requiredPackages, _ := bundle.RequiredPackages()
 	for _, requiredPackage := range requiredPackages {
-		packageDependencyBundles := catalogfilter.Filter(allBundles, catalogfilter.And(catalogfilter.WithPackageName(requiredPackage.PackageName), catalogfilter.InBlangSemverRange(requiredPackage.SemverRange)))
+		packageDependencyBundles := catalogfilter.Filter(index.ByPackage(requiredPackage.PackageName), catalogfilter.InBlangSemverRange(requiredPackage.SemverRange))
 		if len(packageDependencyBundles) == 0 {
-			return nil, fmt.Errorf("could not find package dependencies for bundle '%s'", bundle.Name)
+			return nil, nil, fmt.Errorf("could not find package dependencies for bundle '%s': no bundle of package %q in version range %q", bundle.Name, requiredPackage.PackageName, requiredPackage.VersionRange)
 		}
-		for i := 0; i < len(packageDependencyBundles); i++ {
-			bundle := packageDependencyBundles[i]
-			id := olmvariables.BundleVariableID(bundle)
-			if !added.Has(id) {
-				dependencies = append(dependencies, bundle)
-				added.Insert(id)
+		addRequirement(packageDependencyBundles)
+	}
+
+	// gather required gvk dependencies, which can be provided by any other package
+	requiredGVKs, err := bundle.RequiredGVKs()
+	if err != nil {
+		return nil, nil, err
+	}
+	// a bundle whose provided gvks cannot be parsed does not satisfy its own
+	// requirements, the same way the index does not list it as a provider
+	providedGVKs, _ := bundle.ProvidedGVKs()
+	for _, requiredGVK := range requiredGVKs {
+		gvk := property.GVK{Group: requiredGVK.Group, Version: requiredGVK.Version, Kind: requiredGVK.Kind}
+		// the bundle satisfies its own requirement
+		if slices.Contains(providedGVKs, gvk) {
+			continue
+		}
+		gvkDependencyBundles := catalogfilter.Filter(index.ByGVK(gvk), catalogfilter.Not(catalogfilter.WithPackageName(bundle.Package)))
+		if len(gvkDependencyBundles) == 0 {
+			return nil, nil, fmt.Errorf("could not find a provider of gvk %s required by bundle '%s'", olmvariables.GVKString(gvk), bundle.Name)
+		}
+		addRequirement(gvkDependencyBundles)
+	}
+
+	// gather generic constraint dependencies
+	olmConstraints, err := bundle.Constraints()
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, olmConstraint := range olmConstraints {
+		predicate, err := catalogfilter.FromConstraint(olmConstraint)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid constraint %s of bundle '%s': %w", catalogmetadata.ConstraintString(olmConstraint), bundle.Name, err)
+		}
+		constraintDependencyBundles := catalogfilter.Filter(constraintCandidates(index, olmConstraint), catalogfilter.And(
+			catalogfilter.Not(catalogfilter.WithPackageName(bundle.Package)),
+			predicate,
+		))
+		if len(constraintDependencyBundles) == 0 {
+			msg := fmt.Sprintf("could not find a bundle satisfying constraint %s required by bundle '%s'", catalogmetadata.ConstraintString(olmConstraint), bundle.Name)
+			if olmConstraint.FailureMessage != "" {
+				msg += ": " + olmConstraint.FailureMessage
 			}
+			return nil, nil, errors.New(msg)
 		}
+		addRequirement(constraintDependencyBundles)
 	}
 
-	// sort bundles in version order
+	return sortDependencies(dependencies), requirements, nil
+}
+
+// sortDependencies sorts bundles in version order, preferring
+// the ones which are not deprecated.
+func sortDependencies(dependencies []*catalogmetadata.Bundle) []*catalogmetadata.Bundle {
+	sort.SliceStable(dependencies, func(i, j int) bool {
+		return catalogsort.ByVersionAndCatalogPriority(dependencies[i], dependencies[j])
+	})
 	sort.SliceStable(dependencies, func(i, j int) bool {
-		return catalogsort.ByVersion(dependencies[i], dependencies[j])
+		return catalogsort.ByDeprecated(dependencies[i], dependencies[j])
 	})
+	return dependencies
+}
 
-	return dependencies, nil
+// constraintCandidates narrows down the bundles which can satisfy a constraint
+// using the index when possible. Compound and CEL constraints can be satisfied
+// by any bundle.
+func constraintCandidates(index *catalogmetadata.BundleIndex, c constraints.Constraint) []*catalogmetadata.Bundle {
+	switch {
+	case c.GVK != nil:
+		return index.ByGVK(property.GVK{Group: c.GVK.Group, Version: c.GVK.Version, Kind: c.GVK.Kind})
+	case c.Package != nil:
+		return index.ByPackage(c.Package.PackageName)
+	}
+	return index.All()
 }
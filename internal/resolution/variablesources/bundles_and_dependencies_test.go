@@ -8,6 +8,7 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"github.com/operator-framework/deppy/pkg/deppy"
+	"github.com/operator-framework/deppy/pkg/deppy/solver"
 	"github.com/operator-framework/operator-registry/alpha/declcfg"
 	"github.com/operator-framework/operator-registry/alpha/property"
 
@@ -35,7 +36,7 @@ var _ = Describe("BundlesAndDepsVariableSource", func() {
 					Package: "test-package",
 					Properties: []property.Property{
 						{Type: property.TypePackage, Value: json.RawMessage(`{"packageName": "test-package", "version": "1.0.0"}`)},
-						{Type: property.TypeGVKRequired, Value: json.RawMessage(`[{"group":"foo.io","kind":"Foo","version":"v1"}]`)},
+						{Type: property.TypeGVKRequired, Value: json.RawMessage(`{"group":"foo.io","kind":"Foo","version":"v1"}`)},
 					},
 				},
 				InChannels: []*catalogmetadata.Channel{&channel},
@@ -96,7 +97,7 @@ var _ = Describe("BundlesAndDepsVariableSource", func() {
 					Package: "some-other-package",
 					Properties: []property.Property{
 						{Type: property.TypePackage, Value: json.RawMessage(`{"packageName": "some-other-package", "version": "1.0.0"}`)},
-						{Type: property.TypeGVK, Value: json.RawMessage(`[{"group":"foo.io","kind":"Foo","version":"v1"}]`)},
+						{Type: property.TypeGVK, Value: json.RawMessage(`{"group":"foo.io","kind":"Foo","version":"v1"}`)},
 					},
 				},
 				InChannels: []*catalogmetadata.Channel{&channel},
@@ -122,7 +123,7 @@ var _ = Describe("BundlesAndDepsVariableSource", func() {
 				Bundle: declcfg.Bundle{
 					Name: "bundle-9", Package: "another-package", Properties: []property.Property{
 						{Type: property.TypePackage, Value: json.RawMessage(`{"packageName": "another-package", "version": "1.0.0"}`)},
-						{Type: property.TypeGVK, Value: json.RawMessage(`[{"group":"foo.io","kind":"Foo","version":"v1"}]`)},
+						{Type: property.TypeGVK, Value: json.RawMessage(`{"group":"foo.io","kind":"Foo","version":"v1"}`)},
 					},
 				},
 				InChannels: []*catalogmetadata.Channel{&channel},
@@ -134,7 +135,7 @@ var _ = Describe("BundlesAndDepsVariableSource", func() {
 					Package: "bar-package",
 					Properties: []property.Property{
 						{Type: property.TypePackage, Value: json.RawMessage(`{"packageName": "bar-package", "version": "1.0.0"}`)},
-						{Type: property.TypeGVK, Value: json.RawMessage(`[{"group":"bar.io","kind":"Bar","version":"v1"}]`)},
+						{Type: property.TypeGVK, Value: json.RawMessage(`{"group":"bar.io","kind":"Bar","version":"v1"}`)},
 					},
 				},
 				InChannels: []*catalogmetadata.Channel{&channel},
@@ -146,7 +147,7 @@ var _ = Describe("BundlesAndDepsVariableSource", func() {
 					Package: "bar-package",
 					Properties: []property.Property{
 						{Type: property.TypePackage, Value: json.RawMessage(`{"packageName": "bar-package", "version": "2.0.0"}`)},
-						{Type: property.TypeGVK, Value: json.RawMessage(`[{"group":"bar.io","kind":"Bar","version":"v1"}]`)},
+						{Type: property.TypeGVK, Value: json.RawMessage(`{"group":"bar.io","kind":"Bar","version":"v1"}`)},
 					},
 				},
 				InChannels: []*catalogmetadata.Channel{&channel},
@@ -249,7 +250,7 @@ var _ = Describe("BundlesAndDepsVariableSource", func() {
 								Package: "test-package",
 								Properties: []property.Property{
 									{Type: property.TypePackage, Value: json.RawMessage(`{"packageName": "test-package", "version": "1.0.0"}`)},
-									{Type: property.TypeGVKRequired, Value: json.RawMessage(`[{"group":"foo.io","kind":"Foo","version":"v1"}]`)},
+									{Type: property.TypeGVKRequired, Value: json.RawMessage(`{"group":"foo.io","kind":"Foo","version":"v1"}`)},
 								},
 							},
 							InChannels: []*catalogmetadata.Channel{&channel},
@@ -312,9 +313,8 @@ var _ = Describe("BundlesAndDepsVariableSource", func() {
 				bundleVariables = append(bundleVariables, v)
 			}
 		}
-		// Note: When accounting for Required GVKs (currently not in use), we would expect additional
-		// dependencies (bundles 7, 8, 9, 10, 11) to appear here due to their GVKs being required by
-		// some of the packages.
+		// bundles 7, 8 and 9 provide the Foo gvk required by bundles 1 and 2,
+		// bundles 10 and 11 provide the Bar gvk required by bundle 8.
 		Expect(bundleVariables).To(WithTransform(CollectBundleVariableIDs, Equal([]string{
 			"fake-catalog-test-package-bundle-2",
 			"fake-catalog-test-package-bundle-1",
@@ -322,16 +322,181 @@ var _ = Describe("BundlesAndDepsVariableSource", func() {
 			"fake-catalog-test-package-2-bundle-16",
 			"fake-catalog-test-package-2-bundle-17",
 			"fake-catalog-some-package-bundle-5",
+			"fake-catalog-some-other-package-bundle-8",
 			"fake-catalog-some-package-bundle-4",
+			"fake-catalog-some-other-package-bundle-7",
+			"fake-catalog-another-package-bundle-9",
+			"fake-catalog-bar-package-bundle-11",
+			"fake-catalog-bar-package-bundle-10",
 		})))
 
 		// check dependencies for one of the bundles
 		bundle2 := VariableWithName("bundle-2")(bundleVariables)
-		// Note: As above, bundle-2 has GVK requirements satisfied by bundles 7, 8, and 9, but they
-		// will not appear in this list as we are not currently taking Required GVKs into account
-		Expect(bundle2.Dependencies()).To(HaveLen(2))
-		Expect(bundle2.Dependencies()[0].Name).To(Equal("bundle-5"))
-		Expect(bundle2.Dependencies()[1].Name).To(Equal("bundle-4"))
+		Expect(bundle2.Dependencies()).To(WithTransform(CollectBundleNames, Equal([]string{
+			"bundle-5", "bundle-8", "bundle-4", "bundle-7", "bundle-9",
+		})))
+
+		// required gvks are satisfied by any package providing them
+		bundle8 := VariableWithName("bundle-8")(bundleVariables)
+		Expect(bundle8.Dependencies()).To(WithTransform(CollectBundleNames, Equal([]string{
+			"bundle-11", "bundle-10", "bundle-9",
+		})))
+	})
+
+	It("should not look for providers of gvks the bundle provides itself", func() {
+		bundle := &catalogmetadata.Bundle{
+			CatalogName: "fake-catalog",
+			Bundle: declcfg.Bundle{
+				Name:    "self-sufficient",
+				Package: "self-sufficient-package",
+				Properties: []property.Property{
+					{Type: property.TypePackage, Value: json.RawMessage(`{"packageName": "self-sufficient-package", "version": "1.0.0"}`)},
+					{Type: property.TypeGVK, Value: json.RawMessage(`{"group":"self.io","kind":"Self","version":"v1"}`)},
+					{Type: property.TypeGVKRequired, Value: json.RawMessage(`{"group":"self.io","kind":"Self","version":"v1"}`)},
+				},
+			},
+		}
+		catalogClient := testutil.NewFakeCatalogClient([]*catalogmetadata.Bundle{bundle})
+		bdvs = variablesources.NewBundlesAndDepsVariableSource(&catalogClient, &MockRequiredPackageSource{
+			ResultSet: []deppy.Variable{olmvariables.NewRequiredPackageVariable("self-sufficient-package", []*catalogmetadata.Bundle{bundle})},
+		})
+
+		variables, err := bdvs.GetVariables(context.TODO())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(variables).To(HaveLen(2))
+		bundleVariable, ok := variables[1].(*olmvariables.BundleVariable)
+		Expect(ok).To(BeTrue())
+		Expect(bundleVariable.Dependencies()).To(BeEmpty())
+	})
+
+	It("should require a provider of each required gvk", func() {
+		newBundle := func(name string, properties ...property.Property) *catalogmetadata.Bundle {
+			return &catalogmetadata.Bundle{
+				CatalogName: "fake-catalog",
+				Bundle: declcfg.Bundle{
+					Name:    name,
+					Package: name + "-package",
+					Properties: append([]property.Property{
+						{Type: property.TypePackage, Value: json.RawMessage(`{"packageName": "` + name + `-package", "version": "1.0.0"}`)},
+					}, properties...),
+				},
+			}
+		}
+		fooGVK := json.RawMessage(`{"group":"foo.io","kind":"Foo","version":"v1"}`)
+		barGVK := json.RawMessage(`{"group":"bar.io","kind":"Bar","version":"v1"}`)
+		requiring := newBundle("requiring",
+			property.Property{Type: property.TypeGVKRequired, Value: fooGVK},
+			property.Property{Type: property.TypeGVKRequired, Value: barGVK},
+		)
+		catalogClient := testutil.NewFakeCatalogClient([]*catalogmetadata.Bundle{
+			requiring,
+			newBundle("foo-provider", property.Property{Type: property.TypeGVK, Value: fooGVK}),
+			newBundle("bar-provider", property.Property{Type: property.TypeGVK, Value: barGVK}),
+		})
+		bdvs = variablesources.NewBundlesAndDepsVariableSource(&catalogClient, &MockRequiredPackageSource{
+			ResultSet: []deppy.Variable{olmvariables.NewRequiredPackageVariable("requiring-package", []*catalogmetadata.Bundle{requiring})},
+		})
+
+		solution, err := solver.NewDeppySolver(bdvs).Solve(context.TODO())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(solution.Error()).NotTo(HaveOccurred())
+		// a provider of one of the gvks is not enough
+		Expect(solution.SelectedVariables()).To(HaveKey(deppy.Identifier("fake-catalog-foo-provider-package-foo-provider")))
+		Expect(solution.SelectedVariables()).To(HaveKey(deppy.Identifier("fake-catalog-bar-provider-package-bar-provider")))
+	})
+
+	It("should return error if no bundle provides a required gvk", func() {
+		bundle := &catalogmetadata.Bundle{
+			CatalogName: "fake-catalog",
+			Bundle: declcfg.Bundle{
+				Name:    "bundle-1",
+				Package: "test-package",
+				Properties: []property.Property{
+					{Type: property.TypePackage, Value: json.RawMessage(`{"packageName": "test-package", "version": "1.0.0"}`)},
+					{Type: property.TypeGVKRequired, Value: json.RawMessage(`{"group":"missing.io","kind":"Missing","version":"v1"}`)},
+				},
+			},
+		}
+		bdvs = variablesources.NewBundlesAndDepsVariableSource(&fakeCatalogClient, &MockRequiredPackageSource{
+			ResultSet: []deppy.Variable{olmvariables.NewRequiredPackageVariable("test-package", []*catalogmetadata.Bundle{bundle})},
+		})
+
+		_, err := bdvs.GetVariables(context.TODO())
+		Expect(err).To(MatchError("could not determine dependencies for bundle with id 'fake-catalog-test-package-bundle-1': could not find a provider of gvk missing.io/v1/Missing required by bundle 'bundle-1'"))
+	})
+
+	Context("with olm.constraint properties", func() {
+		var constrainedBundle func(constraint string) *catalogmetadata.Bundle
+
+		BeforeEach(func() {
+			constrainedBundle = func(constraint string) *catalogmetadata.Bundle {
+				return &catalogmetadata.Bundle{
+					CatalogName: "fake-catalog",
+					Bundle: declcfg.Bundle{
+						Name:    "constrained-bundle",
+						Package: "constrained-package",
+						Properties: []property.Property{
+							{Type: property.TypePackage, Value: json.RawMessage(`{"packageName": "constrained-package", "version": "1.0.0"}`)},
+							{Type: "olm.constraint", Value: json.RawMessage(constraint)},
+						},
+					},
+				}
+			}
+		})
+
+		It("should return bundle variables with the bundles satisfying the constraints as dependencies", func() {
+			bundle := constrainedBundle(`{"any": {"constraints": [
+				{"package": {"packageName": "some-package", "versionRange": ">=1.5.0"}},
+				{"cel": {"rule": "properties.exists(p, p.type == 'olm.package' && p.value.packageName == 'bar-package' && semver_compare(p.value.version, '1.0.0') == 0)"}}
+			]}}`)
+			bdvs = variablesources.NewBundlesAndDepsVariableSource(&fakeCatalogClient, &MockRequiredPackageSource{
+				ResultSet: []deppy.Variable{olmvariables.NewRequiredPackageVariable("constrained-package", []*catalogmetadata.Bundle{bundle})},
+			})
+
+			variables, err := bdvs.GetVariables(context.TODO())
+			Expect(err).NotTo(HaveOccurred())
+			var bundleVariables []*olmvariables.BundleVariable
+			for _, variable := range variables {
+				switch v := variable.(type) {
+				case *olmvariables.BundleVariable:
+					bundleVariables = append(bundleVariables, v)
+				}
+			}
+			constrained := VariableWithName("constrained-bundle")(bundleVariables)
+			Expect(constrained.Dependencies()).To(WithTransform(CollectBundleNames, Equal([]string{
+				"bundle-6", "bundle-5", "bundle-10",
+			})))
+		})
+
+		It("should not consider bundles of the same package as satisfying a constraint", func() {
+			bundle := constrainedBundle(`{"not": {"constraints": [{"cel": {"rule": "true"}}]}}`)
+			bdvs = variablesources.NewBundlesAndDepsVariableSource(&fakeCatalogClient, &MockRequiredPackageSource{
+				ResultSet: []deppy.Variable{olmvariables.NewRequiredPackageVariable("constrained-package", []*catalogmetadata.Bundle{bundle})},
+			})
+
+			_, err := bdvs.GetVariables(context.TODO())
+			Expect(err).To(MatchError(`could not determine dependencies for bundle with id 'fake-catalog-constrained-package-constrained-bundle': could not find a bundle satisfying constraint none of (cel rule "true") required by bundle 'constrained-bundle'`))
+		})
+
+		It("should return error including the failure message if no bundle satisfies a constraint", func() {
+			bundle := constrainedBundle(`{"failureMessage": "requires a bundle providing Missing", "gvk": {"group": "missing.io", "kind": "Missing", "version": "v1"}}`)
+			bdvs = variablesources.NewBundlesAndDepsVariableSource(&fakeCatalogClient, &MockRequiredPackageSource{
+				ResultSet: []deppy.Variable{olmvariables.NewRequiredPackageVariable("constrained-package", []*catalogmetadata.Bundle{bundle})},
+			})
+
+			_, err := bdvs.GetVariables(context.TODO())
+			Expect(err).To(MatchError("could not determine dependencies for bundle with id 'fake-catalog-constrained-package-constrained-bundle': could not find a bundle satisfying constraint missing.io/v1/Missing required by bundle 'constrained-bundle': requires a bundle providing Missing"))
+		})
+
+		It("should return error if a constraint is invalid", func() {
+			bundle := constrainedBundle(`{"cel": {"rule": "properties.size()"}}`)
+			bdvs = variablesources.NewBundlesAndDepsVariableSource(&fakeCatalogClient, &MockRequiredPackageSource{
+				ResultSet: []deppy.Variable{olmvariables.NewRequiredPackageVariable("constrained-package", []*catalogmetadata.Bundle{bundle})},
+			})
+
+			_, err := bdvs.GetVariables(context.TODO())
+			Expect(err).To(MatchError(`could not determine dependencies for bundle with id 'fake-catalog-constrained-package-constrained-bundle': invalid constraint cel rule "properties.size()" of bundle 'constrained-bundle': error compiling cel rule "properties.size()": cel expressions must have type Bool`))
+		})
 	})
 
 	It("should return error if dependencies not found", func() {
@@ -363,7 +528,7 @@ var _ = Describe("BundlesAndDepsVariableSource", func() {
 								Package: "test-package",
 								Properties: []property.Property{
 									{Type: property.TypePackage, Value: json.RawMessage(`{"packageName": "test-package", "version": "1.0.0"}`)},
-									{Type: property.TypeGVKRequired, Value: json.RawMessage(`[{"group":"foo.io","kind":"Foo","version":"v1"}]`)},
+									{Type: property.TypeGVKRequired, Value: json.RawMessage(`{"group":"foo.io","kind":"Foo","version":"v1"}`)},
 								},
 							},
 							InChannels: []*catalogmetadata.Channel{{Channel: declcfg.Channel{Name: "stable"}}},
@@ -415,3 +580,11 @@ func CollectBundleVariableIDs(vars []*olmvariables.BundleVariable) []string {
 	}
 	return ids
 }
+
+func CollectBundleNames(bundles []*catalogmetadata.Bundle) []string {
+	names := make([]string, 0, len(bundles))
+	for _, b := range bundles {
+		names = append(names, b.Name)
+	}
+	return names
+}
@@ -0,0 +1,42 @@
+package variablesources
+
+import (
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	operatorsv1alpha1 "github.com/operator-framework/operator-controller/api/v1alpha1"
+	"github.com/operator-framework/operator-controller/internal/catalogmetadata"
+	catalogfilter "github.com/operator-framework/operator-controller/internal/catalogmetadata/filter"
+)
+
+// catalogPredicates returns the predicates keeping the bundles
+// which come from the catalogs matched by the selector.
+func catalogPredicates(selector *operatorsv1alpha1.CatalogSelector) ([]catalogfilter.Predicate[catalogmetadata.Bundle], error) {
+	var predicates []catalogfilter.Predicate[catalogmetadata.Bundle]
+	if len(selector.Names) > 0 {
+		predicates = append(predicates, catalogfilter.InCatalogNames(selector.Names...))
+	}
+	if selector.LabelSelector != nil {
+		labelSelector, err := metav1.LabelSelectorAsSelector(selector.LabelSelector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid catalog label selector: %w", err)
+		}
+		predicates = append(predicates, catalogfilter.WithCatalogLabels(labelSelector))
+	}
+	return predicates, nil
+}
+
+// unavailableCatalogs returns the catalogs missing from the index which
+// would have been kept by the given catalog predicates.
+func unavailableCatalogs(index *catalogmetadata.BundleIndex, catalogPredicates []catalogfilter.Predicate[catalogmetadata.Bundle]) []catalogmetadata.UnavailableCatalog {
+	var unavailableCatalogs []catalogmetadata.UnavailableCatalog
+	for _, catalog := range index.UnavailableCatalogs() {
+		// catalog predicates only look at the catalog fields of the bundles
+		catalogBundle := &catalogmetadata.Bundle{CatalogName: catalog.Name, CatalogLabels: catalog.Labels}
+		if catalogfilter.And(catalogPredicates...)(catalogBundle) {
+			unavailableCatalogs = append(unavailableCatalogs, catalog)
+		}
+	}
+	return unavailableCatalogs
+}
@@ -0,0 +1,96 @@
+package variablesources
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/operator-framework/deppy/pkg/deppy"
+	"github.com/operator-framework/deppy/pkg/deppy/input"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/operator-framework/operator-controller/internal/catalogmetadata"
+	"github.com/operator-framework/operator-controller/internal/clusterversion"
+	olmvariables "github.com/operator-framework/operator-controller/internal/resolution/variables"
+)
+
+var _ input.VariableSource = &ClusterVersionConstraintsVariableSource{}
+
+// ClusterVersionConstraintsVariableSource produces variables which keep the bundles
+// that do not support the version of the cluster out of the solution:
+// 1. bundles requiring a later Kubernetes version than the one of the cluster
+// 2. bundles supporting OpenShift up to an earlier minor version than the one of the cluster
+// Bundles which are already installed are never excluded, as they are on the cluster anyway.
+// Like the CRDUniquenessConstraintsVariableSource, it works out which bundles to exclude from
+// the BundleVariables produced by its 'inputVariableSource'.
+type ClusterVersionConstraintsVariableSource struct {
+	clusterVersion      clusterversion.Provider
+	inputVariableSource input.VariableSource
+}
+
+func NewClusterVersionConstraintsVariableSource(clusterVersion clusterversion.Provider, inputVariableSource input.VariableSource) *ClusterVersionConstraintsVariableSource {
+	return &ClusterVersionConstraintsVariableSource{
+		clusterVersion:      clusterVersion,
+		inputVariableSource: inputVariableSource,
+	}
+}
+
+func (c *ClusterVersionConstraintsVariableSource) GetVariables(ctx context.Context) ([]deppy.Variable, error) {
+	variables, err := c.inputVariableSource.GetVariables(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	version, err := c.clusterVersion.ClusterVersion(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error determining cluster version: %w", err)
+	}
+	if version == nil {
+		return variables, nil
+	}
+
+	installedBundleIDs := sets.Set[deppy.Identifier]{}
+	for _, variable := range variables {
+		if v, ok := variable.(*olmvariables.InstalledPackageVariable); ok && v.InstalledBundle() != nil {
+			installedBundleIDs.Insert(olmvariables.BundleVariableID(v.InstalledBundle()))
+		}
+	}
+
+	var incompatibleVariables []deppy.Variable
+	for _, variable := range variables {
+		v, ok := variable.(*olmvariables.BundleVariable)
+		if !ok || installedBundleIDs.Has(v.Identifier()) {
+			continue
+		}
+		if reason := clusterVersionIncompatibility(version, v.Bundle()); reason != "" {
+			incompatibleVariables = append(incompatibleVariables, olmvariables.NewIncompatibleBundleVariable(v.Bundle(), reason))
+		}
+	}
+
+	return append(variables, incompatibleVariables...), nil
+}
+
+// clusterVersionIncompatibility tells why a bundle cannot be installed on a cluster
+// of the given version, or returns an empty string when it can. Bundles whose
+// supported versions cannot be determined are considered incompatible.
+func clusterVersionIncompatibility(version *clusterversion.Version, bundle *catalogmetadata.Bundle) string {
+	if version.Kubernetes != nil {
+		minKubeVersion, err := bundle.MinKubeVersion()
+		if err != nil {
+			return err.Error()
+		}
+		if minKubeVersion != nil && version.Kubernetes.LT(*minKubeVersion) {
+			return fmt.Sprintf("requires kubernetes %s or later, the cluster runs kubernetes %s", minKubeVersion, version.Kubernetes)
+		}
+	}
+	if version.OpenShift != nil {
+		maxOpenShiftVersion, err := bundle.MaxOpenShiftVersion()
+		if err != nil {
+			return err.Error()
+		}
+		if maxOpenShiftVersion != nil && (version.OpenShift.Major > maxOpenShiftVersion.Major ||
+			version.OpenShift.Major == maxOpenShiftVersion.Major && version.OpenShift.Minor > maxOpenShiftVersion.Minor) {
+			return fmt.Sprintf("supports openshift up to %d.%d, the cluster runs openshift %s", maxOpenShiftVersion.Major, maxOpenShiftVersion.Minor, version.OpenShift)
+		}
+	}
+	return ""
+}
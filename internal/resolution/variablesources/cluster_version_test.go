@@ -0,0 +1,137 @@
+package variablesources_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	bsemver "github.com/blang/semver/v4"
+	"github.com/operator-framework/deppy/pkg/deppy"
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/operator-framework/operator-registry/alpha/property"
+
+	"github.com/operator-framework/operator-controller/internal/catalogmetadata"
+	"github.com/operator-framework/operator-controller/internal/clusterversion"
+	olmvariables "github.com/operator-framework/operator-controller/internal/resolution/variables"
+	"github.com/operator-framework/operator-controller/internal/resolution/variablesources"
+)
+
+type failingClusterVersionProvider struct{}
+
+func (failingClusterVersionProvider) ClusterVersion(_ context.Context) (*clusterversion.Version, error) {
+	return nil, errors.New("fake error")
+}
+
+var _ = Describe("ClusterVersionConstraintsVariableSource", func() {
+	var (
+		inputVariables []deppy.Variable
+		newBundle      func(name, version string, properties ...property.Property) *catalogmetadata.Bundle
+	)
+
+	BeforeEach(func() {
+		newBundle = func(name, version string, properties ...property.Property) *catalogmetadata.Bundle {
+			return &catalogmetadata.Bundle{
+				CatalogName: "fake-catalog",
+				Bundle: declcfg.Bundle{
+					Name:    name,
+					Package: "test-package",
+					Properties: append(properties, property.Property{
+						Type:  property.TypePackage,
+						Value: json.RawMessage(`{"packageName": "test-package", "version": "` + version + `"}`),
+					}),
+				},
+			}
+		}
+
+		compatible := newBundle("compatible", "1.0.0")
+		tooNewForKubernetes := newBundle("too-new-for-kubernetes", "2.0.0",
+			property.Property{Type: property.TypeCSVMetadata, Value: json.RawMessage(`{"minKubeVersion": "1.28.0"}`)})
+		tooOldForOpenShift := newBundle("too-old-for-openshift", "3.0.0",
+			property.Property{Type: catalogmetadata.PropertyMaxOpenShiftVersion, Value: json.RawMessage(`"4.13"`)})
+		installedTooOld := newBundle("installed-too-old-for-openshift", "0.1.0",
+			property.Property{Type: catalogmetadata.PropertyMaxOpenShiftVersion, Value: json.RawMessage(`"4.12"`)})
+		upToCurrentMinor := newBundle("up-to-current-minor", "4.0.0",
+			property.Property{Type: catalogmetadata.PropertyMaxOpenShiftVersion, Value: json.RawMessage(`4.14`)})
+
+		inputVariables = []deppy.Variable{
+			olmvariables.NewInstalledPackageVariable("test-package", installedTooOld, []*catalogmetadata.Bundle{installedTooOld}, nil, nil),
+			olmvariables.NewBundleVariable(installedTooOld, nil),
+			olmvariables.NewBundleVariable(compatible, nil),
+			olmvariables.NewBundleVariable(tooNewForKubernetes, nil),
+			olmvariables.NewBundleVariable(tooOldForOpenShift, nil),
+			olmvariables.NewBundleVariable(upToCurrentMinor, nil),
+		}
+	})
+
+	It("should exclude the bundles which do not support the version of the cluster", func() {
+		cvcvs := variablesources.NewClusterVersionConstraintsVariableSource(
+			clusterversion.Static{Version: &clusterversion.Version{
+				Kubernetes: &bsemver.Version{Major: 1, Minor: 27, Patch: 3},
+				OpenShift:  &bsemver.Version{Major: 4, Minor: 14, Patch: 1},
+			}},
+			&MockRequiredPackageSource{ResultSet: inputVariables},
+		)
+
+		variables, err := cvcvs.GetVariables(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(variables[:len(inputVariables)]).To(Equal(inputVariables))
+
+		var reasons []string
+		for _, variable := range variables[len(inputVariables):] {
+			incompatible, ok := variable.(*olmvariables.IncompatibleBundleVariable)
+			Expect(ok).To(BeTrue())
+			reasons = append(reasons, incompatible.Bundle().Name+": "+incompatible.Reason())
+		}
+		Expect(reasons).To(Equal([]string{
+			"too-new-for-kubernetes: requires kubernetes 1.28.0 or later, the cluster runs kubernetes 1.27.3",
+			"too-old-for-openshift: supports openshift up to 4.13, the cluster runs openshift 4.14.1",
+		}))
+	})
+
+	It("should only check the versions of the cluster which are known", func() {
+		cvcvs := variablesources.NewClusterVersionConstraintsVariableSource(
+			clusterversion.Static{Version: &clusterversion.Version{
+				Kubernetes: &bsemver.Version{Major: 1, Minor: 28, Patch: 0},
+			}},
+			&MockRequiredPackageSource{ResultSet: inputVariables},
+		)
+
+		variables, err := cvcvs.GetVariables(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(variables).To(Equal(inputVariables))
+	})
+
+	It("should not exclude any bundle when the version of the cluster is unknown", func() {
+		cvcvs := variablesources.NewClusterVersionConstraintsVariableSource(
+			clusterversion.Static{},
+			&MockRequiredPackageSource{ResultSet: inputVariables},
+		)
+
+		variables, err := cvcvs.GetVariables(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(variables).To(Equal(inputVariables))
+	})
+
+	It("should return an error if the version of the cluster cannot be determined", func() {
+		cvcvs := variablesources.NewClusterVersionConstraintsVariableSource(
+			failingClusterVersionProvider{},
+			&MockRequiredPackageSource{ResultSet: inputVariables},
+		)
+
+		_, err := cvcvs.GetVariables(context.Background())
+		Expect(err).To(MatchError("error determining cluster version: fake error"))
+	})
+
+	It("should return an error if the input variable source returns an error", func() {
+		cvcvs := variablesources.NewClusterVersionConstraintsVariableSource(
+			clusterversion.Static{},
+			&MockRequiredPackageSource{Error: errors.New("fake error")},
+		)
+
+		_, err := cvcvs.GetVariables(context.Background())
+		Expect(err).To(MatchError("fake error"))
+	})
+})
@@ -6,7 +6,9 @@ import (
 
 	"github.com/operator-framework/deppy/pkg/deppy"
 	"github.com/operator-framework/deppy/pkg/deppy/input"
+	"github.com/operator-framework/operator-registry/alpha/property"
 	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	"github.com/operator-framework/operator-controller/internal/catalogmetadata"
 	olmvariables "github.com/operator-framework/operator-controller/internal/resolution/variables"
@@ -41,12 +43,11 @@ func (g *CRDUniquenessConstraintsVariableSource) GetVariables(ctx context.Contex
 		return nil, err
 	}
 
-	// todo(perdasilva): better handle cases where a provided gvk is not found
-	//                   not all packages will necessarily export a CRD
-
 	bundleIDs := sets.Set[deppy.Identifier]{}
 	packageOrder := []string{}
 	bundleOrder := map[string][]deppy.Identifier{}
+	gvkOrder := []property.GVK{}
+	gvkBundleOrder := map[property.GVK][]deppy.Identifier{}
 	for _, variable := range variables {
 		switch v := variable.(type) {
 		case *olmvariables.BundleVariable:
@@ -54,16 +55,31 @@ func (g *CRDUniquenessConstraintsVariableSource) GetVariables(ctx context.Contex
 			bundles = append(bundles, v.Dependencies()...)
 			for _, bundle := range bundles {
 				id := olmvariables.BundleVariableID(bundle)
+				if bundleIDs.Has(id) {
+					continue
+				}
+				bundleIDs.Insert(id)
+
 				// get bundleID package and update map
 				packageName := bundle.Package
-
 				if _, ok := bundleOrder[packageName]; !ok {
 					packageOrder = append(packageOrder, packageName)
 				}
+				bundleOrder[packageName] = append(bundleOrder[packageName], id)
 
-				if !bundleIDs.Has(id) {
-					bundleIDs.Insert(id)
-					bundleOrder[packageName] = append(bundleOrder[packageName], id)
+				// get bundleID provided gvks and update map. A bundle whose provided gvks
+				// cannot be parsed is left out of the gvk uniqueness constraints rather than
+				// failing the resolution of every operator, as the catalog index does.
+				providedGVKs, err := bundle.ProvidedGVKs()
+				if err != nil {
+					log.FromContext(ctx).Error(err, "skipping gvk uniqueness constraints of bundle", "bundle", bundle.Name, "catalog", bundle.CatalogName)
+					continue
+				}
+				for _, gvk := range providedGVKs {
+					if _, ok := gvkBundleOrder[gvk]; !ok {
+						gvkOrder = append(gvkOrder, gvk)
+					}
+					gvkBundleOrder[gvk] = append(gvkBundleOrder[gvk], id)
 				}
 			}
 		}
@@ -74,6 +90,9 @@ func (g *CRDUniquenessConstraintsVariableSource) GetVariables(ctx context.Contex
 		varID := deppy.IdentifierFromString(fmt.Sprintf("%s package uniqueness", packageName))
 		variables = append(variables, olmvariables.NewBundleUniquenessVariable(varID, bundleOrder[packageName]...))
 	}
+	for _, gvk := range gvkOrder {
+		variables = append(variables, olmvariables.NewGVKUniquenessVariable(gvk, gvkBundleOrder[gvk]...))
+	}
 
 	return variables, nil
 }
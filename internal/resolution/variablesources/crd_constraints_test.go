@@ -9,6 +9,7 @@ import (
 	. "github.com/onsi/gomega"
 
 	"github.com/operator-framework/deppy/pkg/deppy"
+	"github.com/operator-framework/deppy/pkg/deppy/constraint"
 	"github.com/operator-framework/operator-registry/alpha/declcfg"
 	"github.com/operator-framework/operator-registry/alpha/property"
 
@@ -25,8 +26,8 @@ var bundleSet = map[string]*catalogmetadata.Bundle{
 		Package: "test-package",
 		Properties: []property.Property{
 			{Type: property.TypePackage, Value: json.RawMessage(`{"packageName": "test-package", "version": "1.0.0"}`)},
-			{Type: property.TypeGVKRequired, Value: json.RawMessage(`[{"group":"foo.io","kind":"Foo","version":"v1"}]`)},
-			{Type: property.TypeGVK, Value: json.RawMessage(`[{"group":"bit.io","kind":"Bit","version":"v1"}]`)},
+			{Type: property.TypeGVKRequired, Value: json.RawMessage(`{"group":"foo.io","kind":"Foo","version":"v1"}`)},
+			{Type: property.TypeGVK, Value: json.RawMessage(`{"group":"bit.io","kind":"Bit","version":"v1"}`)},
 		}},
 		InChannels: []*catalogmetadata.Channel{&channel},
 	},
@@ -48,7 +49,7 @@ var bundleSet = map[string]*catalogmetadata.Bundle{
 		Package: "some-package",
 		Properties: []property.Property{
 			{Type: property.TypePackage, Value: json.RawMessage(`{"packageName": "some-package", "version": "1.0.0"}`)},
-			{Type: property.TypeGVK, Value: json.RawMessage(`[{"group":"fiz.io","kind":"Fiz","version":"v1"}]`)},
+			{Type: property.TypeGVK, Value: json.RawMessage(`{"group":"fiz.io","kind":"Fiz","version":"v1"}`)},
 		}},
 		InChannels: []*catalogmetadata.Channel{&channel},
 	},
@@ -57,7 +58,7 @@ var bundleSet = map[string]*catalogmetadata.Bundle{
 		Package: "some-package",
 		Properties: []property.Property{
 			{Type: property.TypePackage, Value: json.RawMessage(`{"packageName": "some-package", "version": "1.5.0"}`)},
-			{Type: property.TypeGVK, Value: json.RawMessage(`[{"group":"fiz.io","kind":"Fiz","version":"v1"}]`)},
+			{Type: property.TypeGVK, Value: json.RawMessage(`{"group":"fiz.io","kind":"Fiz","version":"v1"}`)},
 		}},
 		InChannels: []*catalogmetadata.Channel{&channel},
 	},
@@ -66,7 +67,7 @@ var bundleSet = map[string]*catalogmetadata.Bundle{
 		Package: "some-package",
 		Properties: []property.Property{
 			{Type: property.TypePackage, Value: json.RawMessage(`{"packageName": "some-package", "version": "2.0.0"}`)},
-			{Type: property.TypeGVK, Value: json.RawMessage(`[{"group":"fiz.io","kind":"Fiz","version":"v1"}]`)},
+			{Type: property.TypeGVK, Value: json.RawMessage(`{"group":"fiz.io","kind":"Fiz","version":"v1"}`)},
 		}},
 		InChannels: []*catalogmetadata.Channel{&channel},
 	},
@@ -75,7 +76,7 @@ var bundleSet = map[string]*catalogmetadata.Bundle{
 		Package: "some-other-package",
 		Properties: []property.Property{
 			{Type: property.TypePackage, Value: json.RawMessage(`{"packageName": "some-other-package", "version": "1.0.0"}`)},
-			{Type: property.TypeGVK, Value: json.RawMessage(`[{"group":"foo.io","kind":"Foo","version":"v1"}]`)},
+			{Type: property.TypeGVK, Value: json.RawMessage(`{"group":"foo.io","kind":"Foo","version":"v1"}`)},
 		}},
 		InChannels: []*catalogmetadata.Channel{&channel},
 	},
@@ -97,7 +98,7 @@ var bundleSet = map[string]*catalogmetadata.Bundle{
 		Package: "another-package",
 		Properties: []property.Property{
 			{Type: property.TypePackage, Value: json.RawMessage(`{"packageName": "another-package", "version": "1.0.0"}`)},
-			{Type: property.TypeGVK, Value: json.RawMessage(`[{"group":"foo.io","kind":"Foo","version":"v1"}]`)},
+			{Type: property.TypeGVK, Value: json.RawMessage(`{"group":"foo.io","kind":"Foo","version":"v1"}`)},
 		}},
 		InChannels: []*catalogmetadata.Channel{&channel},
 	},
@@ -106,7 +107,7 @@ var bundleSet = map[string]*catalogmetadata.Bundle{
 		Package: "bar-package",
 		Properties: []property.Property{
 			{Type: property.TypePackage, Value: json.RawMessage(`{"packageName": "bar-package", "version": "1.0.0"}`)},
-			{Type: property.TypeGVK, Value: json.RawMessage(`[{"group":"bar.io","kind":"Bar","version":"v1"}]`)},
+			{Type: property.TypeGVK, Value: json.RawMessage(`{"group":"bar.io","kind":"Bar","version":"v1"}`)},
 		}},
 		InChannels: []*catalogmetadata.Channel{&channel},
 	},
@@ -115,7 +116,7 @@ var bundleSet = map[string]*catalogmetadata.Bundle{
 		Package: "bar-package",
 		Properties: []property.Property{
 			{Type: property.TypePackage, Value: json.RawMessage(`{"packageName": "bar-package", "version": "2.0.0"}`)},
-			{Type: property.TypeGVK, Value: json.RawMessage(`[{"group":"bar.io","kind":"Bar","version":"v1"}]`)},
+			{Type: property.TypeGVK, Value: json.RawMessage(`{"group":"bar.io","kind":"Bar","version":"v1"}`)},
 		}},
 		InChannels: []*catalogmetadata.Channel{&channel},
 	},
@@ -126,7 +127,7 @@ var bundleSet = map[string]*catalogmetadata.Bundle{
 		Package: "test-package-2",
 		Properties: []property.Property{
 			{Type: property.TypePackage, Value: json.RawMessage(`{"packageName": "test-package-2", "version": "1.5.0"}`)},
-			{Type: property.TypeGVK, Value: json.RawMessage(`[{"group":"buz.io","kind":"Buz","version":"v1"}]`)},
+			{Type: property.TypeGVK, Value: json.RawMessage(`{"group":"buz.io","kind":"Buz","version":"v1"}`)},
 		}},
 		InChannels: []*catalogmetadata.Channel{&channel},
 	},
@@ -135,7 +136,7 @@ var bundleSet = map[string]*catalogmetadata.Bundle{
 		Package: "test-package-2",
 		Properties: []property.Property{
 			{Type: property.TypePackage, Value: json.RawMessage(`{"packageName": "test-package-2", "version": "2.0.1"}`)},
-			{Type: property.TypeGVK, Value: json.RawMessage(`[{"group":"buz.io","kind":"Buz","version":"v1"}]`)},
+			{Type: property.TypeGVK, Value: json.RawMessage(`{"group":"buz.io","kind":"Buz","version":"v1"}`)},
 		}},
 		InChannels: []*catalogmetadata.Channel{&channel},
 	},
@@ -144,7 +145,7 @@ var bundleSet = map[string]*catalogmetadata.Bundle{
 		Package: "test-package-2",
 		Properties: []property.Property{
 			{Type: property.TypePackage, Value: json.RawMessage(`{"packageName": "test-package-2", "version": "3.16.0"}`)},
-			{Type: property.TypeGVK, Value: json.RawMessage(`[{"group":"buz.io","kind":"Buz","version":"v1"}]`)},
+			{Type: property.TypeGVK, Value: json.RawMessage(`{"group":"buz.io","kind":"Buz","version":"v1"}`)},
 		}},
 		InChannels: []*catalogmetadata.Channel{&channel},
 	},
@@ -155,7 +156,7 @@ var bundleSet = map[string]*catalogmetadata.Bundle{
 		Package: "unrelated-package",
 		Properties: []property.Property{
 			{Type: property.TypePackage, Value: json.RawMessage(`{"packageName": "unrelated-package", "version": "2.0.0"}`)},
-			{Type: property.TypeGVK, Value: json.RawMessage(`[{"group":"buz.io","kind":"Buz","version":"v1alpha1"}]`)},
+			{Type: property.TypeGVK, Value: json.RawMessage(`{"group":"buz.io","kind":"Buz","version":"v1alpha1"}`)},
 		}},
 		InChannels: []*catalogmetadata.Channel{&channel},
 	},
@@ -164,7 +165,7 @@ var bundleSet = map[string]*catalogmetadata.Bundle{
 		Package: "unrelated-package-2",
 		Properties: []property.Property{
 			{Type: property.TypePackage, Value: json.RawMessage(`{"packageName": "unrelated-package-2", "version": "2.0.0"}`)},
-			{Type: property.TypeGVK, Value: json.RawMessage(`[{"group":"buz.io","kind":"Buz","version":"v1alpha1"}]`)},
+			{Type: property.TypeGVK, Value: json.RawMessage(`{"group":"buz.io","kind":"Buz","version":"v1alpha1"}`)},
 		}},
 		InChannels: []*catalogmetadata.Channel{&channel},
 	},
@@ -173,7 +174,7 @@ var bundleSet = map[string]*catalogmetadata.Bundle{
 		Package: "unrelated-package-2",
 		Properties: []property.Property{
 			{Type: property.TypePackage, Value: json.RawMessage(`{"packageName": "unrelated-package-2", "version": "3.0.0"}`)},
-			{Type: property.TypeGVK, Value: json.RawMessage(`[{"group":"buz.io","kind":"Buz","version":"v1alpha1"}]`)},
+			{Type: property.TypeGVK, Value: json.RawMessage(`{"group":"buz.io","kind":"Buz","version":"v1alpha1"}`)},
 		}},
 		InChannels: []*catalogmetadata.Channel{&channel},
 	},
@@ -272,18 +273,16 @@ var _ = Describe("CRDUniquenessConstraintsVariableSource", func() {
 		}
 		variables, err := crdConstraintVariableSource.GetVariables(ctx)
 		Expect(err).ToNot(HaveOccurred())
-		// Note: When accounting for GVK Uniqueness (which we are currently not doing), we
-		// would expect to have 26 variables from the 5 unique GVKs (Bar, Bit, Buz, Fiz, Foo).
-		Expect(variables).To(HaveLen(21))
-		var crdConstraintVariables []*olmvariables.BundleUniquenessVariable
+		// 15 input variables, 6 package uniqueness variables and
+		// 5 gvk uniqueness variables for Bar, Bit, Buz, Fiz and Foo.
+		Expect(variables).To(HaveLen(26))
+		var crdConstraintVariables []deppy.Variable
 		for _, variable := range variables {
 			switch v := variable.(type) {
-			case *olmvariables.BundleUniquenessVariable:
+			case *olmvariables.BundleUniquenessVariable, *olmvariables.GVKUniquenessVariable:
 				crdConstraintVariables = append(crdConstraintVariables, v)
 			}
 		}
-		// Note: As above, the 5 GVKs would appear here as GVK uniqueness constraints
-		// if GVK Uniqueness were being accounted for.
 		Expect(crdConstraintVariables).To(WithTransform(CollectGlobalConstraintVariableIDs, Equal([]string{
 			"test-package package uniqueness",
 			"some-package package uniqueness",
@@ -291,9 +290,59 @@ var _ = Describe("CRDUniquenessConstraintsVariableSource", func() {
 			"another-package package uniqueness",
 			"bar-package package uniqueness",
 			"test-package-2 package uniqueness",
+			"bit.io/v1/Bit gvk uniqueness",
+			"fiz.io/v1/Fiz gvk uniqueness",
+			"foo.io/v1/Foo gvk uniqueness",
+			"bar.io/v1/Bar gvk uniqueness",
+			"buz.io/v1/Buz gvk uniqueness",
 		})))
 	})
 
+	It("should only allow a single bundle per provided gvk", func() {
+		inputVariableSource.ResultSet = []deppy.Variable{
+			olmvariables.NewBundleVariable(bundleSet["bundle-1"], []*catalogmetadata.Bundle{bundleSet["bundle-8"]}),
+			olmvariables.NewBundleVariable(bundleSet["bundle-6"], nil),
+		}
+		variables, err := crdConstraintVariableSource.GetVariables(ctx)
+		Expect(err).ToNot(HaveOccurred())
+
+		var gvkVariables []*olmvariables.GVKUniquenessVariable
+		for _, variable := range variables {
+			if v, ok := variable.(*olmvariables.GVKUniquenessVariable); ok {
+				gvkVariables = append(gvkVariables, v)
+			}
+		}
+		Expect(gvkVariables).To(HaveLen(2))
+		Expect(gvkVariables[1].GVK()).To(Equal(property.GVK{Group: "foo.io", Version: "v1", Kind: "Foo"}))
+		Expect(gvkVariables[1].Constraints()).To(Equal([]deppy.Constraint{constraint.AtMost(1,
+			olmvariables.BundleVariableID(bundleSet["bundle-8"]),
+			olmvariables.BundleVariableID(bundleSet["bundle-6"]),
+		)}))
+	})
+
+	It("should leave bundles whose provided gvks cannot be read out of the gvk uniqueness constraints", func() {
+		badBundle := &catalogmetadata.Bundle{Bundle: declcfg.Bundle{
+			Name:    "bad-bundle",
+			Package: "bad-package",
+			Properties: []property.Property{
+				{Type: property.TypeGVK, Value: json.RawMessage(`[]`)},
+			},
+		}}
+		inputVariableSource.ResultSet = []deppy.Variable{
+			olmvariables.NewBundleVariable(badBundle, nil),
+			olmvariables.NewBundleVariable(bundleSet["bundle-6"], nil),
+		}
+		variables, err := crdConstraintVariableSource.GetVariables(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(CollectGlobalConstraintVariableIDs(variables)).To(Equal([]string{
+			olmvariables.BundleVariableID(badBundle).String(),
+			olmvariables.BundleVariableID(bundleSet["bundle-6"]).String(),
+			"bad-package package uniqueness",
+			"some-other-package package uniqueness",
+			"foo.io/v1/Foo gvk uniqueness",
+		}))
+	})
+
 	It("should return an error if input variable source returns an error", func() {
 		inputVariableSource = &MockInputVariableSource{Err: fmt.Errorf("error getting variables")}
 		crdConstraintVariableSource = variablesources.NewCRDUniquenessConstraintsVariableSource(inputVariableSource)
@@ -315,7 +364,7 @@ func (m *MockInputVariableSource) GetVariables(_ context.Context) ([]deppy.Varia
 	return m.ResultSet, nil
 }
 
-func CollectGlobalConstraintVariableIDs(vars []*olmvariables.BundleUniquenessVariable) []string {
+func CollectGlobalConstraintVariableIDs(vars []deppy.Variable) []string {
 	ids := make([]string, 0, len(vars))
 	for _, v := range vars {
 		ids = append(ids, v.Identifier().String())
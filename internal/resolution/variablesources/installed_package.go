@@ -6,9 +6,11 @@ import (
 	"sort"
 
 	mmsemver "github.com/Masterminds/semver/v3"
+	bsemver "github.com/blang/semver/v4"
 	"github.com/operator-framework/deppy/pkg/deppy"
 	"github.com/operator-framework/deppy/pkg/deppy/input"
 
+	operatorsv1alpha1 "github.com/operator-framework/operator-controller/api/v1alpha1"
 	"github.com/operator-framework/operator-controller/internal/catalogmetadata"
 	catalogfilter "github.com/operator-framework/operator-controller/internal/catalogmetadata/filter"
 	catalogsort "github.com/operator-framework/operator-controller/internal/catalogmetadata/sort"
@@ -18,59 +20,226 @@ import (
 
 var _ input.VariableSource = &InstalledPackageVariableSource{}
 
+type InstalledPackageVariableSourceOption func(*InstalledPackageVariableSource) error
+
+// WithUpgradeConstraintPolicy configures how successors of the installed bundle
+// are determined. With UpgradeConstraintPolicyIgnore any bundle of the installed
+// package is considered a successor, including downgrades and bundles which are
+// not reachable through the upgrade graph.
+func WithUpgradeConstraintPolicy(policy operatorsv1alpha1.UpgradeConstraintPolicy) InstalledPackageVariableSourceOption {
+	return func(r *InstalledPackageVariableSource) error {
+		if policy == operatorsv1alpha1.UpgradeConstraintPolicyIgnore {
+			r.successors = ignoreConstraintsSuccessors
+		}
+		return nil
+	}
+}
+
+// UpgradeInChannel limits the successors of the installed bundle
+// to the entries of the given channel.
+func UpgradeInChannel(channelName string) InstalledPackageVariableSourceOption {
+	return func(r *InstalledPackageVariableSource) error {
+		if channelName != "" {
+			r.predicates = append(r.predicates, catalogfilter.InChannel(channelName))
+		}
+		return nil
+	}
+}
+
+// UpgradeInVersionRange limits the successors of the installed bundle
+// to the bundles within the given semver range.
+func UpgradeInVersionRange(versionRange string) InstalledPackageVariableSourceOption {
+	return func(r *InstalledPackageVariableSource) error {
+		if versionRange != "" {
+			vr, err := mmsemver.NewConstraint(versionRange)
+			if err == nil {
+				r.predicates = append(r.predicates, catalogfilter.InMastermindsSemverRange(vr))
+				return nil
+			}
+
+			return fmt.Errorf("invalid version range '%s': %w", versionRange, err)
+		}
+		return nil
+	}
+}
+
+// UpgradeFromCatalogs limits the successors of the installed bundle
+// to the bundles from the catalogs matched by the selector.
+// A nil selector matches every catalog.
+func UpgradeFromCatalogs(selector *operatorsv1alpha1.CatalogSelector) InstalledPackageVariableSourceOption {
+	return func(r *InstalledPackageVariableSource) error {
+		if selector == nil {
+			return nil
+		}
+		predicates, err := catalogPredicates(selector)
+		if err != nil {
+			return err
+		}
+		r.catalogPredicates = append(r.catalogPredicates, predicates...)
+		return nil
+	}
+}
+
+// UpgradeToApprovedVersion limits the successors of the installed bundle to the
+// bundles of the approved version, for operators whose upgrades are approved manually.
+// The successor it would have been upgraded to otherwise is reported as pending upgrade.
+func UpgradeToApprovedVersion(approvedVersion string) InstalledPackageVariableSourceOption {
+	return func(r *InstalledPackageVariableSource) error {
+		if approvedVersion == "" {
+			return nil
+		}
+		version, err := bsemver.Parse(approvedVersion)
+		if err != nil {
+			return fmt.Errorf("invalid approved version '%s': %w", approvedVersion, err)
+		}
+		r.approvedVersion = &version
+		return nil
+	}
+}
+
 type InstalledPackageVariableSource struct {
-	catalogClient BundleProvider
-	successors    successorsFunc
-	bundleImage   string
+	catalogClient   BundleProvider
+	successors      successorsFunc
+	bundleImage     string
+	approvedVersion *bsemver.Version
+	predicates      []catalogfilter.Predicate[catalogmetadata.Bundle]
+	// catalogPredicates only look at the catalog of the bundles
+	catalogPredicates []catalogfilter.Predicate[catalogmetadata.Bundle]
+
+	// upgradeSuccessors follows the upgrade graph, no matter the upgrade
+	// constraint policy, to report the upgrades available for the installed bundle.
+	upgradeSuccessors successorsFunc
 }
 
 func (r *InstalledPackageVariableSource) GetVariables(ctx context.Context) ([]deppy.Variable, error) {
-	allBundles, err := r.catalogClient.Bundles(ctx)
+	index, err := r.catalogClient.BundleIndex(ctx)
 	if err != nil {
 		return nil, err
 	}
 
+	// resolution carries on with the catalogs which are available,
+	// the unavailable ones are reported along with the variable.
+	unavailableCatalogs := unavailableCatalogs(index, r.catalogPredicates)
+
 	// find corresponding bundle for the installed content
-	resultSet := catalogfilter.Filter(allBundles, catalogfilter.WithBundleImage(r.bundleImage))
+	resultSet := index.ByImage(r.bundleImage)
 	if len(resultSet) == 0 {
+		if len(unavailableCatalogs) > 0 {
+			return nil, fmt.Errorf("%w: %w", r.notFoundError(), &catalogmetadata.UnavailableCatalogsError{Catalogs: unavailableCatalogs})
+		}
 		return nil, r.notFoundError()
 	}
 
-	// TODO: fast follow - we should check whether we are already supporting the channel attribute in the operator spec.
-	//       if so, we should take the value from spec of the operator CR in the owner ref of the bundle deployment.
-	//       If that channel is set, we need to update the filter above to filter by channel as well.
 	sort.SliceStable(resultSet, func(i, j int) bool {
-		return catalogsort.ByVersion(resultSet[i], resultSet[j])
+		return catalogsort.ByVersionAndCatalogPriority(resultSet[i], resultSet[j])
 	})
 	installedBundle := resultSet[0]
 
-	upgradeEdges, err := r.successors(allBundles, installedBundle)
+	// only consider successors which match the channel and
+	// version constraints of the operator, if any.
+	packageBundles := index.ByPackage(installedBundle.Package)
+	candidates := catalogfilter.Filter(packageBundles, catalogfilter.And(
+		catalogfilter.And(r.predicates...),
+		catalogfilter.And(r.catalogPredicates...),
+	))
+	upgradeEdges, err := r.successors(candidates, installedBundle)
 	if err != nil {
 		return nil, err
 	}
 
+	availableUpgrades, err := r.upgradeSuccessors(packageBundles, installedBundle)
+	if err != nil {
+		return nil, err
+	}
+
+	// deprecated bundles which can be upgraded from to bundles which are not
+	// deprecated are the way through, rather than a dead end to stay away from.
+	deadEnds := map[*catalogmetadata.Bundle]bool{}
+	for _, bundle := range upgradeEdges {
+		if !bundle.IsDeprecated() {
+			continue
+		}
+		leadsAway, err := r.upgradesAwayFromDeprecation(candidates, bundle)
+		if err != nil {
+			return nil, err
+		}
+		deadEnds[bundle] = !leadsAway
+	}
+
 	// you can always upgrade to yourself, i.e. not upgrade
 	upgradeEdges = append(upgradeEdges, installedBundle)
+	deadEnds[installedBundle] = installedBundle.IsDeprecated()
+	// prefer staying on the installed bundle over upgrading to
+	// a deprecated dead end, and upgrading away from a deprecated one.
+	sort.SliceStable(upgradeEdges, func(i, j int) bool {
+		return !deadEnds[upgradeEdges[i]] && deadEnds[upgradeEdges[j]]
+	})
+
+	var pendingUpgrade *catalogmetadata.Bundle
+	if r.approvedVersion != nil {
+		if upgradeEdges[0] != installedBundle && !isVersion(upgradeEdges[0], *r.approvedVersion) {
+			pendingUpgrade = upgradeEdges[0]
+		}
+		upgradeEdges = catalogfilter.Filter(upgradeEdges, func(bundle *catalogmetadata.Bundle) bool {
+			return bundle == installedBundle || isVersion(bundle, *r.approvedVersion)
+		})
+	}
 	return []deppy.Variable{
-		variables.NewInstalledPackageVariable(installedBundle.Package, upgradeEdges),
+		variables.NewInstalledPackageVariable(installedBundle.Package, installedBundle, upgradeEdges, availableUpgrades, pendingUpgrade, unavailableCatalogs...),
 	}, nil
 }
 
+// upgradesAwayFromDeprecation returns true if a bundle which is not deprecated
+// can be reached by upgrading from the deprecated bundle, possibly through
+// other deprecated bundles.
+func (r *InstalledPackageVariableSource) upgradesAwayFromDeprecation(candidates []*catalogmetadata.Bundle, bundle *catalogmetadata.Bundle) (bool, error) {
+	visited := map[*catalogmetadata.Bundle]bool{bundle: true}
+	for queue := []*catalogmetadata.Bundle{bundle}; len(queue) > 0; queue = queue[1:] {
+		successors, err := r.successors(candidates, queue[0])
+		if err != nil {
+			return false, err
+		}
+		for _, successor := range successors {
+			if !successor.IsDeprecated() {
+				return true, nil
+			}
+			if !visited[successor] {
+				visited[successor] = true
+				queue = append(queue, successor)
+			}
+		}
+	}
+	return false, nil
+}
+
+// isVersion returns true if the bundle has the given version.
+func isVersion(bundle *catalogmetadata.Bundle, version bsemver.Version) bool {
+	bundleVersion, err := bundle.Version()
+	return err == nil && bundleVersion.Equals(version)
+}
+
 func (r *InstalledPackageVariableSource) notFoundError() error {
 	return fmt.Errorf("bundleImage %q not found", r.bundleImage)
 }
 
-func NewInstalledPackageVariableSource(catalogClient BundleProvider, bundleImage string) (*InstalledPackageVariableSource, error) {
+func NewInstalledPackageVariableSource(catalogClient BundleProvider, bundleImage string, options ...InstalledPackageVariableSourceOption) (*InstalledPackageVariableSource, error) {
 	successors := legacySemanticsSuccessors
 	if features.OperatorControllerFeatureGate.Enabled(features.ForceSemverUpgradeConstraints) {
 		successors = semverSuccessors
 	}
 
-	return &InstalledPackageVariableSource{
-		catalogClient: catalogClient,
-		bundleImage:   bundleImage,
-		successors:    successors,
-	}, nil
+	r := &InstalledPackageVariableSource{
+		catalogClient:     catalogClient,
+		bundleImage:       bundleImage,
+		successors:        successors,
+		upgradeSuccessors: successors,
+	}
+	for _, option := range options {
+		if err := option(r); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
 }
 
 // successorsFunc must return successors of a currently installed bundle
@@ -81,14 +250,23 @@ type successorsFunc func(allBundles []*catalogmetadata.Bundle, installedBundle *
 // legacySemanticsSuccessors returns successors based on legacy OLMv0 semantics
 // which rely on Replaces, Skips and skipRange.
 func legacySemanticsSuccessors(allBundles []*catalogmetadata.Bundle, installedBundle *catalogmetadata.Bundle) ([]*catalogmetadata.Bundle, error) {
-	// find the bundles that replace the bundle provided
-	// TODO: this algorithm does not yet consider skips and skipRange
+	installedBundleVersion, err := installedBundle.Version()
+	if err != nil {
+		return nil, err
+	}
+
+	// find the bundles that replace, skip or have a skipRange
+	// which includes the bundle provided
 	upgradeEdges := catalogfilter.Filter(allBundles, catalogfilter.And(
 		catalogfilter.WithPackageName(installedBundle.Package),
-		catalogfilter.Replaces(installedBundle.Name),
+		catalogfilter.Or(
+			catalogfilter.Replaces(installedBundle.Name),
+			catalogfilter.Skips(installedBundle.Name),
+			catalogfilter.SkipRangeIncludes(*installedBundleVersion),
+		),
 	))
 	sort.SliceStable(upgradeEdges, func(i, j int) bool {
-		return catalogsort.ByVersion(upgradeEdges[i], upgradeEdges[j])
+		return catalogsort.ByVersionAndCatalogPriority(upgradeEdges[i], upgradeEdges[j])
 	})
 
 	return upgradeEdges, nil
@@ -116,7 +294,24 @@ func semverSuccessors(allBundles []*catalogmetadata.Bundle, installedBundle *cat
 		catalogfilter.InMastermindsSemverRange(wantedVersionRangeConstraint),
 	))
 	sort.SliceStable(upgradeEdges, func(i, j int) bool {
-		return catalogsort.ByVersion(upgradeEdges[i], upgradeEdges[j])
+		return catalogsort.ByVersionAndCatalogPriority(upgradeEdges[i], upgradeEdges[j])
+	})
+
+	return upgradeEdges, nil
+}
+
+// ignoreConstraintsSuccessors returns every other bundle of the installed
+// package as a successor, regardless of version or upgrade edges.
+// This allows upgrades which are not part of the upgrade graph as well as downgrades.
+func ignoreConstraintsSuccessors(allBundles []*catalogmetadata.Bundle, installedBundle *catalogmetadata.Bundle) ([]*catalogmetadata.Bundle, error) {
+	upgradeEdges := catalogfilter.Filter(allBundles, catalogfilter.And(
+		catalogfilter.WithPackageName(installedBundle.Package),
+		catalogfilter.Not(func(bundle *catalogmetadata.Bundle) bool {
+			return bundle == installedBundle
+		}),
+	))
+	sort.SliceStable(upgradeEdges, func(i, j int) bool {
+		return catalogsort.ByVersionAndCatalogPriority(upgradeEdges[i], upgradeEdges[j])
 	})
 
 	return upgradeEdges, nil
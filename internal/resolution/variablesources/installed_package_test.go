@@ -12,6 +12,7 @@ import (
 	"github.com/stretchr/testify/require"
 	featuregatetesting "k8s.io/component-base/featuregate/testing"
 
+	operatorsv1alpha1 "github.com/operator-framework/operator-controller/api/v1alpha1"
 	"github.com/operator-framework/operator-controller/internal/catalogmetadata"
 	olmvariables "github.com/operator-framework/operator-controller/internal/resolution/variables"
 	"github.com/operator-framework/operator-controller/internal/resolution/variablesources"
@@ -71,10 +72,12 @@ func TestInstalledPackageVariableSource(t *testing.T) {
 			{
 				Name:     "test-package.v3.0.0",
 				Replaces: "test-package.v2.2.0",
+				Skips:    []string{"test-package.v2.1.0"},
 			},
 			{
-				Name:     "test-package.v4.0.0",
-				Replaces: "test-package.v3.0.0",
+				Name:      "test-package.v4.0.0",
+				Replaces:  "test-package.v3.0.0",
+				SkipRange: ">=2.1.0 <3.0.0",
 			},
 			{
 				Name:     "test-package.v5.0.0",
@@ -82,6 +85,19 @@ func TestInstalledPackageVariableSource(t *testing.T) {
 			},
 		},
 	}}
+	testPackageFastChannel := catalogmetadata.Channel{Channel: declcfg.Channel{
+		Name:    "fast",
+		Package: "test-package",
+		Entries: []declcfg.ChannelEntry{
+			{
+				Name: "test-package.v2.0.0",
+			},
+			{
+				Name:     "test-package.v4.1.0",
+				Replaces: "test-package.v2.0.0",
+			},
+		},
+	}}
 	bundleList := []*catalogmetadata.Bundle{
 		{Bundle: declcfg.Bundle{
 			Name:    "test-package.v0.0.1",
@@ -153,7 +169,7 @@ func TestInstalledPackageVariableSource(t *testing.T) {
 			Properties: []property.Property{
 				{Type: property.TypePackage, Value: json.RawMessage(`{"packageName": "test-package", "version": "2.0.0"}`)},
 			}},
-			InChannels: []*catalogmetadata.Channel{&testPackageChannel},
+			InChannels: []*catalogmetadata.Channel{&testPackageChannel, &testPackageFastChannel},
 		},
 		{Bundle: declcfg.Bundle{
 			Name:    "test-package.v2.1.0",
@@ -182,6 +198,15 @@ func TestInstalledPackageVariableSource(t *testing.T) {
 			}},
 			InChannels: []*catalogmetadata.Channel{&testPackageChannel},
 		},
+		{Bundle: declcfg.Bundle{
+			Name:    "test-package.v4.1.0",
+			Package: "test-package",
+			Image:   "registry.io/repo/test-package@v4.1.0",
+			Properties: []property.Property{
+				{Type: property.TypePackage, Value: json.RawMessage(`{"packageName": "test-package", "version": "4.1.0"}`)},
+			}},
+			InChannels: []*catalogmetadata.Channel{&testPackageFastChannel},
+		},
 		{Bundle: declcfg.Bundle{
 			Name:    "test-package.v5.0.0",
 			Package: "test-package",
@@ -267,11 +292,61 @@ func TestInstalledPackageVariableSource(t *testing.T) {
 		})
 	})
 
-	t.Run("with ForceSemverUpgradeConstraints feature gate disabled", func(t *testing.T) {
-		defer featuregatetesting.SetFeatureGateDuringTest(t, features.OperatorControllerFeatureGate, features.ForceSemverUpgradeConstraints, false)()
+	t.Run("with approved version", func(t *testing.T) {
+		defer featuregatetesting.SetFeatureGateDuringTest(t, features.OperatorControllerFeatureGate, features.ForceSemverUpgradeConstraints, true)()
+		const bundleImage = "registry.io/repo/test-package@v2.0.0"
+
+		t.Run("older than the newest successor", func(t *testing.T) {
+			ipvs, err := variablesources.NewInstalledPackageVariableSource(&fakeCatalogClient, bundleImage, variablesources.UpgradeToApprovedVersion("2.1.0"))
+			require.NoError(t, err)
+
+			variables, err := ipvs.GetVariables(context.TODO())
+			require.NoError(t, err)
+			require.Len(t, variables, 1)
+			packageVariable, ok := variables[0].(*olmvariables.InstalledPackageVariable)
+			require.True(t, ok)
+
+			// only the approved version is allowed, the newest successor is pending approval
+			bundles := packageVariable.Bundles()
+			require.Len(t, bundles, 2)
+			assert.Equal(t, "test-package.v2.1.0", bundles[0].Name)
+			assert.Equal(t, "test-package.v2.0.0", bundles[1].Name)
+			require.NotNil(t, packageVariable.PendingUpgrade())
+			assert.Equal(t, "test-package.v2.2.0", packageVariable.PendingUpgrade().Name)
+		})
+
+		t.Run("matching the newest successor", func(t *testing.T) {
+			ipvs, err := variablesources.NewInstalledPackageVariableSource(&fakeCatalogClient, bundleImage, variablesources.UpgradeToApprovedVersion("2.2.0"))
+			require.NoError(t, err)
+
+			variables, err := ipvs.GetVariables(context.TODO())
+			require.NoError(t, err)
+			require.Len(t, variables, 1)
+			packageVariable, ok := variables[0].(*olmvariables.InstalledPackageVariable)
+			require.True(t, ok)
+
+			bundles := packageVariable.Bundles()
+			require.Len(t, bundles, 2)
+			assert.Equal(t, "test-package.v2.2.0", bundles[0].Name)
+			assert.Equal(t, "test-package.v2.0.0", bundles[1].Name)
+			assert.Nil(t, packageVariable.PendingUpgrade())
+		})
+
+		t.Run("invalid", func(t *testing.T) {
+			_, err := variablesources.NewInstalledPackageVariableSource(&fakeCatalogClient, bundleImage, variablesources.UpgradeToApprovedVersion(">=2.1.0"))
+			assert.ErrorContains(t, err, "invalid approved version '>=2.1.0'")
+		})
+	})
 
+	t.Run("with invalid version range", func(t *testing.T) {
+		_, err := variablesources.NewInstalledPackageVariableSource(&fakeCatalogClient, "registry.io/repo/test-package@v2.0.0", variablesources.UpgradeInVersionRange("not a valid version"))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid version range 'not a valid version'")
+	})
+
+	t.Run("with UpgradeConstraintPolicy set to Ignore", func(t *testing.T) {
 		const bundleImage = "registry.io/repo/test-package@v2.0.0"
-		ipvs, err := variablesources.NewInstalledPackageVariableSource(&fakeCatalogClient, bundleImage)
+		ipvs, err := variablesources.NewInstalledPackageVariableSource(&fakeCatalogClient, bundleImage, variablesources.WithUpgradeConstraintPolicy(operatorsv1alpha1.UpgradeConstraintPolicyIgnore))
 		require.NoError(t, err)
 
 		variables, err := ipvs.GetVariables(context.TODO())
@@ -281,10 +356,195 @@ func TestInstalledPackageVariableSource(t *testing.T) {
 		assert.True(t, ok)
 		assert.Equal(t, deppy.IdentifierFromString("installed package test-package"), packageVariable.Identifier())
 
-		// ensure bundles are in version order (high to low)
+		// all bundles of the package are allowed, including downgrades,
+		// with the installed bundle at the end
 		bundles := packageVariable.Bundles()
-		require.Len(t, bundles, 2)
-		assert.Equal(t, "test-package.v2.1.0", packageVariable.Bundles()[0].Name)
-		assert.Equal(t, "test-package.v2.0.0", packageVariable.Bundles()[1].Name)
+		require.Len(t, bundles, 13)
+		assert.Equal(t, "test-package.v5.0.0", packageVariable.Bundles()[0].Name)
+		assert.Equal(t, "test-package.v0.0.1", packageVariable.Bundles()[11].Name)
+		assert.Equal(t, "test-package.v2.0.0", packageVariable.Bundles()[12].Name)
+
+		// available upgrades still follow the upgrade graph
+		var availableUpgrades []string
+		for _, bundle := range packageVariable.AvailableUpgrades() {
+			availableUpgrades = append(availableUpgrades, bundle.Name)
+		}
+		assert.Equal(t, []string{"test-package.v4.1.0", "test-package.v2.1.0"}, availableUpgrades)
+	})
+
+	t.Run("with ForceSemverUpgradeConstraints feature gate disabled", func(t *testing.T) {
+		defer featuregatetesting.SetFeatureGateDuringTest(t, features.OperatorControllerFeatureGate, features.ForceSemverUpgradeConstraints, false)()
+
+		t.Run("with replaces", func(t *testing.T) {
+			const bundleImage = "registry.io/repo/test-package@v2.0.0"
+			ipvs, err := variablesources.NewInstalledPackageVariableSource(&fakeCatalogClient, bundleImage)
+			require.NoError(t, err)
+
+			variables, err := ipvs.GetVariables(context.TODO())
+			require.NoError(t, err)
+			require.Len(t, variables, 1)
+			packageVariable, ok := variables[0].(*olmvariables.InstalledPackageVariable)
+			assert.True(t, ok)
+			assert.Equal(t, deppy.IdentifierFromString("installed package test-package"), packageVariable.Identifier())
+
+			// ensure bundles are in version order (high to low)
+			bundles := packageVariable.Bundles()
+			require.Len(t, bundles, 3)
+			assert.Equal(t, "test-package.v4.1.0", packageVariable.Bundles()[0].Name)
+			assert.Equal(t, "test-package.v2.1.0", packageVariable.Bundles()[1].Name)
+			assert.Equal(t, "test-package.v2.0.0", packageVariable.Bundles()[2].Name)
+		})
+
+		t.Run("with channel", func(t *testing.T) {
+			for _, tt := range []struct {
+				channel string
+				want    []string
+			}{
+				{
+					channel: "stable",
+					want:    []string{"test-package.v2.1.0", "test-package.v2.0.0"},
+				},
+				{
+					channel: "fast",
+					want:    []string{"test-package.v4.1.0", "test-package.v2.0.0"},
+				},
+			} {
+				t.Run(tt.channel, func(t *testing.T) {
+					const bundleImage = "registry.io/repo/test-package@v2.0.0"
+					ipvs, err := variablesources.NewInstalledPackageVariableSource(&fakeCatalogClient, bundleImage, variablesources.UpgradeInChannel(tt.channel))
+					require.NoError(t, err)
+
+					variables, err := ipvs.GetVariables(context.TODO())
+					require.NoError(t, err)
+					require.Len(t, variables, 1)
+					packageVariable, ok := variables[0].(*olmvariables.InstalledPackageVariable)
+					assert.True(t, ok)
+
+					var names []string
+					for _, bundle := range packageVariable.Bundles() {
+						names = append(names, bundle.Name)
+					}
+					assert.Equal(t, tt.want, names)
+				})
+			}
+		})
+
+		t.Run("with version range", func(t *testing.T) {
+			const bundleImage = "registry.io/repo/test-package@v2.1.0"
+			ipvs, err := variablesources.NewInstalledPackageVariableSource(&fakeCatalogClient, bundleImage, variablesources.UpgradeInVersionRange("<3.0.0"))
+			require.NoError(t, err)
+
+			variables, err := ipvs.GetVariables(context.TODO())
+			require.NoError(t, err)
+			require.Len(t, variables, 1)
+			packageVariable, ok := variables[0].(*olmvariables.InstalledPackageVariable)
+			assert.True(t, ok)
+
+			// v4.0.0 and v3.0.0 are upgrade edges, but outside of the version range
+			bundles := packageVariable.Bundles()
+			require.Len(t, bundles, 2)
+			assert.Equal(t, "test-package.v2.2.0", packageVariable.Bundles()[0].Name)
+			assert.Equal(t, "test-package.v2.1.0", packageVariable.Bundles()[1].Name)
+
+			// available upgrades are not limited by the version range
+			var availableUpgrades []string
+			for _, bundle := range packageVariable.AvailableUpgrades() {
+				availableUpgrades = append(availableUpgrades, bundle.Name)
+			}
+			assert.Equal(t, []string{"test-package.v4.0.0", "test-package.v3.0.0", "test-package.v2.2.0"}, availableUpgrades)
+		})
+
+		t.Run("with replaces, skips and skipRange", func(t *testing.T) {
+			const bundleImage = "registry.io/repo/test-package@v2.1.0"
+			ipvs, err := variablesources.NewInstalledPackageVariableSource(&fakeCatalogClient, bundleImage)
+			require.NoError(t, err)
+
+			variables, err := ipvs.GetVariables(context.TODO())
+			require.NoError(t, err)
+			require.Len(t, variables, 1)
+			packageVariable, ok := variables[0].(*olmvariables.InstalledPackageVariable)
+			assert.True(t, ok)
+			assert.Equal(t, deppy.IdentifierFromString("installed package test-package"), packageVariable.Identifier())
+
+			// v4.0.0 has a skipRange which includes v2.1.0,
+			// v3.0.0 skips v2.1.0 and v2.2.0 replaces v2.1.0
+			bundles := packageVariable.Bundles()
+			require.Len(t, bundles, 4)
+			assert.Equal(t, "test-package.v4.0.0", packageVariable.Bundles()[0].Name)
+			assert.Equal(t, "test-package.v3.0.0", packageVariable.Bundles()[1].Name)
+			assert.Equal(t, "test-package.v2.2.0", packageVariable.Bundles()[2].Name)
+			assert.Equal(t, "test-package.v2.1.0", packageVariable.Bundles()[3].Name)
+		})
+
+		t.Run("with deprecated bundles", func(t *testing.T) {
+			deprecatedChannel := catalogmetadata.Channel{Channel: declcfg.Channel{
+				Name:    "stable",
+				Package: "test-package",
+				Entries: []declcfg.ChannelEntry{
+					{Name: "test-package.v1.0.0"},
+					{Name: "test-package.v1.1.0", Replaces: "test-package.v1.0.0"},
+					{Name: "test-package.v1.2.0", Replaces: "test-package.v1.1.0"},
+				},
+			}}
+			newBundle := func(version string, deprecated bool) *catalogmetadata.Bundle {
+				bundle := &catalogmetadata.Bundle{
+					Bundle: declcfg.Bundle{
+						Name:    "test-package.v" + version,
+						Package: "test-package",
+						Image:   "registry.io/repo/test-package@v" + version,
+						Properties: []property.Property{
+							{Type: property.TypePackage, Value: json.RawMessage(`{"packageName": "test-package", "version": "` + version + `"}`)},
+						}},
+					InChannels: []*catalogmetadata.Channel{&deprecatedChannel},
+				}
+				if deprecated {
+					bundle.Deprecations = []catalogmetadata.DeprecationEntry{{
+						Reference: catalogmetadata.PackageScopedReference{Schema: declcfg.SchemaBundle, Name: bundle.Name},
+						Message:   bundle.Name + " is deprecated",
+					}}
+				}
+				return bundle
+			}
+			bundleNames := func(t *testing.T, catalogClient testutil.FakeCatalogClient, bundleImage string) []string {
+				t.Helper()
+				ipvs, err := variablesources.NewInstalledPackageVariableSource(&catalogClient, bundleImage)
+				require.NoError(t, err)
+				variables, err := ipvs.GetVariables(context.TODO())
+				require.NoError(t, err)
+				require.Len(t, variables, 1)
+				packageVariable, ok := variables[0].(*olmvariables.InstalledPackageVariable)
+				require.True(t, ok)
+				var names []string
+				for _, bundle := range packageVariable.Bundles() {
+					names = append(names, bundle.Name)
+				}
+				return names
+			}
+
+			t.Run("upgrade through a deprecated bundle", func(t *testing.T) {
+				catalogClient := testutil.NewFakeCatalogClient([]*catalogmetadata.Bundle{
+					newBundle("1.0.0", false),
+					newBundle("1.1.0", true),
+					newBundle("1.2.0", false),
+				})
+
+				// v1.1.0 is deprecated, but it is the way to v1.2.0
+				assert.Equal(t, []string{"test-package.v1.1.0", "test-package.v1.0.0"},
+					bundleNames(t, catalogClient, "registry.io/repo/test-package@v1.0.0"))
+				assert.Equal(t, []string{"test-package.v1.2.0", "test-package.v1.1.0"},
+					bundleNames(t, catalogClient, "registry.io/repo/test-package@v1.1.0"))
+			})
+
+			t.Run("no upgrade to a deprecated dead end", func(t *testing.T) {
+				catalogClient := testutil.NewFakeCatalogClient([]*catalogmetadata.Bundle{
+					newBundle("1.0.0", false),
+					newBundle("1.1.0", true),
+					newBundle("1.2.0", true),
+				})
+
+				assert.Equal(t, []string{"test-package.v1.0.0", "test-package.v1.1.0"},
+					bundleNames(t, catalogClient, "registry.io/repo/test-package@v1.0.0"))
+			})
+		})
 	})
 }
@@ -0,0 +1,76 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package variablesources
+
+import (
+	"context"
+	"sync"
+
+	"github.com/operator-framework/deppy/pkg/deppy"
+	"github.com/operator-framework/deppy/pkg/deppy/input"
+
+	olmvariables "github.com/operator-framework/operator-controller/internal/resolution/variables"
+)
+
+var _ input.VariableSource = &MemoizedVariableSource{}
+
+// MemoizedVariableSource remembers the variables produced by a variable source
+// and only asks the source for new variables when the key describing the inputs
+// of the source changes. Errors are never memoized, neither are variables produced
+// while catalogs were unavailable, so that those catalogs are read again next time.
+type MemoizedVariableSource struct {
+	keyFunc        func(ctx context.Context) (string, error)
+	variableSource input.VariableSource
+
+	mu        sync.Mutex
+	memoized  bool
+	key       string
+	variables []deppy.Variable
+}
+
+func NewMemoizedVariableSource(keyFunc func(ctx context.Context) (string, error), variableSource input.VariableSource) *MemoizedVariableSource {
+	return &MemoizedVariableSource{
+		keyFunc:        keyFunc,
+		variableSource: variableSource,
+	}
+}
+
+func (m *MemoizedVariableSource) GetVariables(ctx context.Context) ([]deppy.Variable, error) {
+	key, err := m.keyFunc(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.memoized && m.key == key {
+		return m.variables, nil
+	}
+
+	variables, err := m.variableSource.GetVariables(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(olmvariables.UnavailableCatalogs(variables...)) > 0 {
+		m.memoized = false
+		return variables, nil
+	}
+	m.memoized, m.key, m.variables = true, key, variables
+
+	return variables, nil
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package variablesources_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/deppy/pkg/deppy"
+
+	"github.com/operator-framework/operator-controller/internal/resolution/variablesources"
+)
+
+func TestMemoizedVariableSource(t *testing.T) {
+	ctx := context.Background()
+
+	key := "generation-1"
+	var keyErr error
+	keyFunc := func(context.Context) (string, error) {
+		return key, keyErr
+	}
+	source := &countingVariableSource{
+		mockVariableSource: mockVariableSource{fakeVariables: []deppy.Variable{mockVariable("fake-var-1")}},
+	}
+	memoizedSource := variablesources.NewMemoizedVariableSource(keyFunc, source)
+
+	t.Run("first call reads the source", func(t *testing.T) {
+		variables, err := memoizedSource.GetVariables(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []deppy.Variable{mockVariable("fake-var-1")}, variables)
+		assert.Equal(t, 1, source.calls)
+	})
+
+	t.Run("unchanged key reuses the variables", func(t *testing.T) {
+		source.fakeVariables = []deppy.Variable{mockVariable("fake-var-2")}
+
+		variables, err := memoizedSource.GetVariables(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []deppy.Variable{mockVariable("fake-var-1")}, variables)
+		assert.Equal(t, 1, source.calls)
+	})
+
+	t.Run("changed key reads the source again", func(t *testing.T) {
+		key = "generation-2"
+
+		variables, err := memoizedSource.GetVariables(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []deppy.Variable{mockVariable("fake-var-2")}, variables)
+		assert.Equal(t, 2, source.calls)
+	})
+
+	t.Run("source errors are not memoized", func(t *testing.T) {
+		key = "generation-3"
+		source.fakeError = errors.New("fake error from GetVariables")
+
+		variables, err := memoizedSource.GetVariables(ctx)
+		assert.EqualError(t, err, "fake error from GetVariables")
+		assert.Nil(t, variables)
+
+		source.fakeError = nil
+		variables, err = memoizedSource.GetVariables(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []deppy.Variable{mockVariable("fake-var-2")}, variables)
+		assert.Equal(t, 4, source.calls)
+	})
+
+	t.Run("key errors are returned", func(t *testing.T) {
+		keyErr = errors.New("fake error from the key")
+
+		variables, err := memoizedSource.GetVariables(ctx)
+		assert.EqualError(t, err, "fake error from the key")
+		assert.Nil(t, variables)
+		assert.Equal(t, 4, source.calls)
+	})
+}
+
+type countingVariableSource struct {
+	mockVariableSource
+	calls int
+}
+
+func (c *countingVariableSource) GetVariables(ctx context.Context) ([]deppy.Variable, error) {
+	c.calls++
+	return c.mockVariableSource.GetVariables(ctx)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 
 	operatorsv1alpha1 "github.com/operator-framework/operator-controller/api/v1alpha1"
+	"github.com/operator-framework/operator-controller/internal/controllers/validators"
 
 	"github.com/operator-framework/deppy/pkg/deppy"
 	"github.com/operator-framework/deppy/pkg/deppy/input"
@@ -39,11 +40,26 @@ func (o *OperatorVariableSource) GetVariables(ctx context.Context) ([]deppy.Vari
 
 	// build required package variable sources
 	for _, operator := range operatorList.Items {
+		// operators which are being deleted no longer require their package
+		if !operator.GetDeletionTimestamp().IsZero() {
+			continue
+		}
+		// operators installed from a bundle image skip catalog resolution
+		if operator.Spec.BundleImage != "" {
+			continue
+		}
+		// operators with an invalid spec are reported by their own reconcile,
+		// they must not fail the resolution of the other operators
+		if err := validators.ValidateOperatorSpec(&operator); err != nil {
+			continue
+		}
+
 		rps, err := NewRequiredPackageVariableSource(
 			o.catalogClient,
 			operator.Spec.PackageName,
 			InVersionRange(operator.Spec.Version),
 			InChannel(operator.Spec.Channel),
+			FromCatalogs(operator.Spec.CatalogSelector),
 		)
 		if err != nil {
 			return nil, err
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"time"
 
 	"github.com/operator-framework/deppy/pkg/deppy"
 	"github.com/operator-framework/operator-registry/alpha/declcfg"
@@ -134,6 +135,55 @@ var _ = Describe("OperatorVariableSource", func() {
 		})))
 	})
 
+	It("should not produce RequiredPackage variables for operators which are being deleted", func() {
+		deletedOperator := operator("packageA")
+		deletedOperator.Finalizers = []string{operatorsv1alpha1.CleanupFinalizer}
+		deletedOperator.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+		cl := FakeClient(operator("prometheus"), deletedOperator)
+		fakeCatalogClient := testutil.NewFakeCatalogClient(testBundleList)
+		opVariableSource := variablesources.NewOperatorVariableSource(cl, &fakeCatalogClient, &MockRequiredPackageSource{})
+		variables, err := opVariableSource.GetVariables(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+
+		packageRequiredVariables := filterVariables[*olmvariables.RequiredPackageVariable](variables)
+		Expect(packageRequiredVariables).To(HaveLen(1))
+		Expect(packageRequiredVariables[0].Identifier()).To(Equal(deppy.IdentifierFromString("required package prometheus")))
+	})
+
+	It("should not produce RequiredPackage variables for operators installed from a bundle image", func() {
+		hotfixOperator := operator("hotfix")
+		hotfixOperator.Spec.BundleImage = "foo.io/hotfix/hotfix:v1.0.1"
+		cl := FakeClient(operator("prometheus"), hotfixOperator)
+		fakeCatalogClient := testutil.NewFakeCatalogClient(testBundleList)
+		opVariableSource := variablesources.NewOperatorVariableSource(cl, &fakeCatalogClient, &MockRequiredPackageSource{})
+		variables, err := opVariableSource.GetVariables(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+
+		packageRequiredVariables := filterVariables[*olmvariables.RequiredPackageVariable](variables)
+		Expect(packageRequiredVariables).To(HaveLen(1))
+		Expect(packageRequiredVariables[0].Identifier()).To(Equal(deppy.IdentifierFromString("required package prometheus")))
+	})
+
+	It("should not produce RequiredPackage variables for operators with an invalid spec", func() {
+		invalidOperator := operator("packageA")
+		invalidOperator.Spec.CatalogSelector = &operatorsv1alpha1.CatalogSelector{
+			LabelSelector: &metav1.LabelSelector{
+				MatchExpressions: []metav1.LabelSelectorRequirement{
+					{Key: "tier", Operator: metav1.LabelSelectorOpIn},
+				},
+			},
+		}
+		cl := FakeClient(operator("prometheus"), invalidOperator)
+		fakeCatalogClient := testutil.NewFakeCatalogClient(testBundleList)
+		opVariableSource := variablesources.NewOperatorVariableSource(cl, &fakeCatalogClient, &MockRequiredPackageSource{})
+		variables, err := opVariableSource.GetVariables(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+
+		packageRequiredVariables := filterVariables[*olmvariables.RequiredPackageVariable](variables)
+		Expect(packageRequiredVariables).To(HaveLen(1))
+		Expect(packageRequiredVariables[0].Identifier()).To(Equal(deppy.IdentifierFromString("required package prometheus")))
+	})
+
 	It("should return an errors when they occur", func() {
 		cl := FakeClient(operator("prometheus"), operator("packageA"))
 		fakeCatalogClient := testutil.NewFakeCatalogClientWithError(errors.New("something bad happened"))
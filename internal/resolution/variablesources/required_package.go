@@ -9,6 +9,7 @@ import (
 	"github.com/operator-framework/deppy/pkg/deppy"
 	"github.com/operator-framework/deppy/pkg/deppy/input"
 
+	operatorsv1alpha1 "github.com/operator-framework/operator-controller/api/v1alpha1"
 	"github.com/operator-framework/operator-controller/internal/catalogmetadata"
 	catalogfilter "github.com/operator-framework/operator-controller/internal/catalogmetadata/filter"
 	catalogsort "github.com/operator-framework/operator-controller/internal/catalogmetadata/sort"
@@ -39,19 +40,38 @@ func InChannel(channelName string) RequiredPackageVariableSourceOption {
 	return func(r *RequiredPackageVariableSource) error {
 		if channelName != "" {
 			r.channelName = channelName
-			r.predicates = append(r.predicates, catalogfilter.InChannel(channelName))
 		}
 		return nil
 	}
 }
 
+// FromCatalogs restricts the bundles to the catalogs matched by the selector.
+// A nil selector matches every catalog.
+func FromCatalogs(selector *operatorsv1alpha1.CatalogSelector) RequiredPackageVariableSourceOption {
+	return func(r *RequiredPackageVariableSource) error {
+		if selector == nil {
+			return nil
+		}
+		predicates, err := catalogPredicates(selector)
+		if err != nil {
+			return err
+		}
+		r.catalogPredicates = append(r.catalogPredicates, predicates...)
+		r.catalogsSelected = true
+		return nil
+	}
+}
+
 type RequiredPackageVariableSource struct {
 	catalogClient BundleProvider
 
-	packageName  string
-	versionRange string
-	channelName  string
-	predicates   []catalogfilter.Predicate[catalogmetadata.Bundle]
+	packageName      string
+	versionRange     string
+	channelName      string
+	catalogsSelected bool
+	predicates       []catalogfilter.Predicate[catalogmetadata.Bundle]
+	// catalogPredicates only look at the catalog of the bundles
+	catalogPredicates []catalogfilter.Predicate[catalogmetadata.Bundle]
 }
 
 func NewRequiredPackageVariableSource(catalogClient BundleProvider, packageName string, options ...RequiredPackageVariableSourceOption) (*RequiredPackageVariableSource, error) {
@@ -62,7 +82,6 @@ func NewRequiredPackageVariableSource(catalogClient BundleProvider, packageName
 		catalogClient: catalogClient,
 
 		packageName: packageName,
-		predicates:  []catalogfilter.Predicate[catalogmetadata.Bundle]{catalogfilter.WithPackageName(packageName)},
 	}
 	for _, option := range options {
 		if err := option(r); err != nil {
@@ -73,24 +92,54 @@ func NewRequiredPackageVariableSource(catalogClient BundleProvider, packageName
 }
 
 func (r *RequiredPackageVariableSource) GetVariables(ctx context.Context) ([]deppy.Variable, error) {
-	resultSet, err := r.catalogClient.Bundles(ctx)
+	index, err := r.catalogClient.BundleIndex(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	resultSet = catalogfilter.Filter(resultSet, catalogfilter.And(r.predicates...))
+	// like OLMv0, fall back to the default channel of the
+	// package when no channel is requested.
+	resultSet := index.ByPackage(r.packageName)
+	if r.channelName != "" {
+		resultSet = index.ByChannel(r.packageName, r.channelName)
+	} else {
+		resultSet = catalogfilter.Filter(resultSet, catalogfilter.InDefaultChannel())
+	}
+	resultSet = catalogfilter.Filter(resultSet, catalogfilter.And(
+		catalogfilter.And(r.predicates...),
+		catalogfilter.And(r.catalogPredicates...),
+	))
+
+	// resolution carries on with the catalogs which are available,
+	// the unavailable ones are reported along with the variable.
+	unavailableCatalogs := unavailableCatalogs(index, r.catalogPredicates)
 	if len(resultSet) == 0 {
+		if len(unavailableCatalogs) > 0 {
+			return nil, fmt.Errorf("%w: %w", r.notFoundError(), &catalogmetadata.UnavailableCatalogsError{Catalogs: unavailableCatalogs})
+		}
 		return nil, r.notFoundError()
 	}
 	sort.SliceStable(resultSet, func(i, j int) bool {
-		return catalogsort.ByVersion(resultSet[i], resultSet[j])
+		return catalogsort.ByVersionAndCatalogPriority(resultSet[i], resultSet[j])
+	})
+	// prefer bundles which are not deprecated
+	sort.SliceStable(resultSet, func(i, j int) bool {
+		return catalogsort.ByDeprecated(resultSet[i], resultSet[j])
 	})
 	return []deppy.Variable{
-		olmvariables.NewRequiredPackageVariable(r.packageName, resultSet),
+		olmvariables.NewRequiredPackageVariable(r.packageName, resultSet, unavailableCatalogs...),
 	}, nil
 }
 
 func (r *RequiredPackageVariableSource) notFoundError() error {
+	err := r.packageNotFoundError()
+	if r.catalogsSelected {
+		return fmt.Errorf("%w in the selected catalogs", err)
+	}
+	return err
+}
+
+func (r *RequiredPackageVariableSource) packageNotFoundError() error {
 	if r.versionRange != "" && r.channelName != "" {
 		return fmt.Errorf("no package '%s' matching version '%s' found in channel '%s'", r.packageName, r.versionRange, r.channelName)
 	}
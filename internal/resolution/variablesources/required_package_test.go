@@ -11,7 +11,9 @@ import (
 	"github.com/operator-framework/deppy/pkg/deppy"
 	"github.com/operator-framework/operator-registry/alpha/declcfg"
 	"github.com/operator-framework/operator-registry/alpha/property"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	operatorsv1alpha1 "github.com/operator-framework/operator-controller/api/v1alpha1"
 	"github.com/operator-framework/operator-controller/internal/catalogmetadata"
 	olmvariables "github.com/operator-framework/operator-controller/internal/resolution/variables"
 	"github.com/operator-framework/operator-controller/internal/resolution/variablesources"
@@ -38,7 +40,9 @@ var _ = Describe("RequiredPackageVariableSource", func() {
 				Properties: []property.Property{
 					{Type: property.TypePackage, Value: json.RawMessage(`{"packageName": "test-package", "version": "1.0.0"}`)},
 				}},
-				InChannels: []*catalogmetadata.Channel{&channel},
+				InChannels:    []*catalogmetadata.Channel{&channel},
+				CatalogName:   "internal-catalog",
+				CatalogLabels: map[string]string{"tier": "internal"},
 			},
 			{Bundle: declcfg.Bundle{
 				Name:    "test-package.v3.0.0",
@@ -46,7 +50,9 @@ var _ = Describe("RequiredPackageVariableSource", func() {
 				Properties: []property.Property{
 					{Type: property.TypePackage, Value: json.RawMessage(`{"packageName": "test-package", "version": "3.0.0"}`)},
 				}},
-				InChannels: []*catalogmetadata.Channel{&channel},
+				InChannels:    []*catalogmetadata.Channel{&channel},
+				CatalogName:   "community-catalog",
+				CatalogLabels: map[string]string{"tier": "community"},
 			},
 			{Bundle: declcfg.Bundle{
 				Name:    "test-package.v2.0.0",
@@ -54,7 +60,9 @@ var _ = Describe("RequiredPackageVariableSource", func() {
 				Properties: []property.Property{
 					{Type: property.TypePackage, Value: json.RawMessage(`{"packageName": "test-package", "version": "2.0.0"}`)},
 				}},
-				InChannels: []*catalogmetadata.Channel{&channel},
+				InChannels:    []*catalogmetadata.Channel{&channel},
+				CatalogName:   "internal-catalog",
+				CatalogLabels: map[string]string{"tier": "internal"},
 			},
 			// add some bundles from a different package
 			{Bundle: declcfg.Bundle{
@@ -111,6 +119,179 @@ var _ = Describe("RequiredPackageVariableSource", func() {
 		Expect(reqPackageVar.Bundles()[0].Name).To(Equal("test-package.v1.0.0"))
 	})
 
+	It("should prefer bundles from catalogs with a higher priority", func() {
+		newBundle := func(catalogName string, priority int32) *catalogmetadata.Bundle {
+			return &catalogmetadata.Bundle{
+				Bundle: declcfg.Bundle{
+					Name:    "test-package.v1.0.0",
+					Package: "test-package",
+					Properties: []property.Property{
+						{Type: property.TypePackage, Value: json.RawMessage(`{"packageName": "test-package", "version": "1.0.0"}`)},
+					}},
+				CatalogName:     catalogName,
+				CatalogPriority: priority,
+			}
+		}
+		priorityCatalogClient := testutil.NewFakeCatalogClient([]*catalogmetadata.Bundle{
+			newBundle("catalog-b", 0),
+			newBundle("catalog-a", 0),
+			newBundle("catalog-c", 10),
+		})
+		rpvs, err := variablesources.NewRequiredPackageVariableSource(&priorityCatalogClient, packageName)
+		Expect(err).NotTo(HaveOccurred())
+
+		variables, err := rpvs.GetVariables(context.TODO())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(variables).To(HaveLen(1))
+		reqPackageVar, ok := variables[0].(*olmvariables.RequiredPackageVariable)
+		Expect(ok).To(BeTrue())
+		Expect(reqPackageVar.Bundles()).To(HaveLen(3))
+		Expect(reqPackageVar.Bundles()[0].CatalogName).To(Equal("catalog-c"))
+		Expect(reqPackageVar.Bundles()[1].CatalogName).To(Equal("catalog-a"))
+		Expect(reqPackageVar.Bundles()[2].CatalogName).To(Equal("catalog-b"))
+	})
+
+	It("should only consider the default channel of the package when no channel is given", func() {
+		newBundle := func(version, catalogName, defaultChannel string, channels ...string) *catalogmetadata.Bundle {
+			bundle := &catalogmetadata.Bundle{
+				Bundle: declcfg.Bundle{
+					Name:    "test-package.v" + version,
+					Package: "test-package",
+					Properties: []property.Property{
+						{Type: property.TypePackage, Value: json.RawMessage(`{"packageName": "test-package", "version": "` + version + `"}`)},
+					}},
+				CatalogName: catalogName,
+				CatalogPackage: &catalogmetadata.Package{
+					Package:     declcfg.Package{Name: "test-package", DefaultChannel: defaultChannel},
+					CatalogName: catalogName,
+				},
+			}
+			for _, channel := range channels {
+				bundle.InChannels = append(bundle.InChannels, &catalogmetadata.Channel{Channel: declcfg.Channel{Name: channel}})
+			}
+			return bundle
+		}
+		defaultChannelCatalogClient := testutil.NewFakeCatalogClient([]*catalogmetadata.Bundle{
+			newBundle("1.0.0", "catalog-a", "stable", "stable", "fast"),
+			newBundle("2.0.0", "catalog-a", "stable", "fast"),
+			// the default channel is the one of the catalog of each bundle
+			newBundle("3.0.0", "catalog-b", "fast", "fast"),
+			newBundle("4.0.0", "catalog-b", "fast", "candidate"),
+		})
+		rpvs, err := variablesources.NewRequiredPackageVariableSource(&defaultChannelCatalogClient, packageName)
+		Expect(err).NotTo(HaveOccurred())
+
+		variables, err := rpvs.GetVariables(context.TODO())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(variables).To(HaveLen(1))
+		reqPackageVar, ok := variables[0].(*olmvariables.RequiredPackageVariable)
+		Expect(ok).To(BeTrue())
+		Expect(CollectBundleNames(reqPackageVar.Bundles())).To(Equal([]string{
+			"test-package.v3.0.0", "test-package.v1.0.0",
+		}))
+
+		By("using the requested channel instead of the default one")
+		rpvs, err = variablesources.NewRequiredPackageVariableSource(&defaultChannelCatalogClient, packageName, variablesources.InChannel("fast"))
+		Expect(err).NotTo(HaveOccurred())
+
+		variables, err = rpvs.GetVariables(context.TODO())
+		Expect(err).NotTo(HaveOccurred())
+		reqPackageVar, ok = variables[0].(*olmvariables.RequiredPackageVariable)
+		Expect(ok).To(BeTrue())
+		Expect(CollectBundleNames(reqPackageVar.Bundles())).To(Equal([]string{
+			"test-package.v3.0.0", "test-package.v2.0.0", "test-package.v1.0.0",
+		}))
+	})
+
+	It("should prefer bundles which are not deprecated", func() {
+		newBundle := func(version string, deprecated bool) *catalogmetadata.Bundle {
+			bundle := &catalogmetadata.Bundle{
+				Bundle: declcfg.Bundle{
+					Name:    "test-package.v" + version,
+					Package: "test-package",
+					Properties: []property.Property{
+						{Type: property.TypePackage, Value: json.RawMessage(`{"packageName": "test-package", "version": "` + version + `"}`)},
+					}},
+			}
+			if deprecated {
+				bundle.Deprecations = []catalogmetadata.DeprecationEntry{{
+					Reference: catalogmetadata.PackageScopedReference{Schema: declcfg.SchemaBundle, Name: bundle.Name},
+					Message:   bundle.Name + " is deprecated",
+				}}
+			}
+			return bundle
+		}
+		deprecationCatalogClient := testutil.NewFakeCatalogClient([]*catalogmetadata.Bundle{
+			newBundle("1.0.0", false),
+			newBundle("3.0.0", true),
+			newBundle("2.0.0", false),
+		})
+		rpvs, err := variablesources.NewRequiredPackageVariableSource(&deprecationCatalogClient, packageName)
+		Expect(err).NotTo(HaveOccurred())
+
+		variables, err := rpvs.GetVariables(context.TODO())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(variables).To(HaveLen(1))
+		reqPackageVar, ok := variables[0].(*olmvariables.RequiredPackageVariable)
+		Expect(ok).To(BeTrue())
+		Expect(CollectBundleNames(reqPackageVar.Bundles())).To(Equal([]string{
+			"test-package.v2.0.0", "test-package.v1.0.0", "test-package.v3.0.0",
+		}))
+	})
+
+	It("should filter by catalog name", func() {
+		var err error
+		rpvs, err = variablesources.NewRequiredPackageVariableSource(&fakeCatalogClient, packageName, variablesources.FromCatalogs(&operatorsv1alpha1.CatalogSelector{
+			Names: []string{"community-catalog"},
+		}))
+		Expect(err).NotTo(HaveOccurred())
+
+		variables, err := rpvs.GetVariables(context.TODO())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(variables).To(HaveLen(1))
+		reqPackageVar, ok := variables[0].(*olmvariables.RequiredPackageVariable)
+		Expect(ok).To(BeTrue())
+		Expect(reqPackageVar.Bundles()).To(HaveLen(1))
+		Expect(reqPackageVar.Bundles()[0].Name).To(Equal("test-package.v3.0.0"))
+	})
+
+	It("should filter by catalog labels", func() {
+		var err error
+		rpvs, err = variablesources.NewRequiredPackageVariableSource(&fakeCatalogClient, packageName, variablesources.FromCatalogs(&operatorsv1alpha1.CatalogSelector{
+			LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"tier": "internal"}},
+		}))
+		Expect(err).NotTo(HaveOccurred())
+
+		variables, err := rpvs.GetVariables(context.TODO())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(variables).To(HaveLen(1))
+		reqPackageVar, ok := variables[0].(*olmvariables.RequiredPackageVariable)
+		Expect(ok).To(BeTrue())
+		Expect(reqPackageVar.Bundles()).To(HaveLen(2))
+		Expect(reqPackageVar.Bundles()[0].Name).To(Equal("test-package.v2.0.0"))
+		Expect(reqPackageVar.Bundles()[1].Name).To(Equal("test-package.v1.0.0"))
+	})
+
+	It("should return an error if package not found in the selected catalogs", func() {
+		var err error
+		rpvs, err = variablesources.NewRequiredPackageVariableSource(&fakeCatalogClient, packageName, variablesources.FromCatalogs(&operatorsv1alpha1.CatalogSelector{
+			Names:         []string{"community-catalog"},
+			LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"tier": "internal"}},
+		}))
+		Expect(err).NotTo(HaveOccurred())
+		_, err = rpvs.GetVariables(context.TODO())
+		Expect(err).To(MatchError("no package 'test-package' found in the selected catalogs"))
+	})
+
+	It("should fail with bad catalog label selector", func() {
+		_, err := variablesources.NewRequiredPackageVariableSource(&fakeCatalogClient, packageName, variablesources.FromCatalogs(&operatorsv1alpha1.CatalogSelector{
+			LabelSelector: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+				{Key: "tier", Operator: "Bogus"},
+			}},
+		}))
+		Expect(err).To(HaveOccurred())
+	})
+
 	It("should fail with bad semver range", func() {
 		_, err := variablesources.NewRequiredPackageVariableSource(&fakeCatalogClient, packageName, variablesources.InVersionRange("not a valid semver"))
 		Expect(err).To(HaveOccurred())
@@ -125,6 +306,22 @@ var _ = Describe("RequiredPackageVariableSource", func() {
 		Expect(err).To(MatchError("no package 'test-package' found"))
 	})
 
+	It("should record the unavailable catalogs matching the catalog selector", func() {
+		unavailableCatalogClient := testutil.NewFakeCatalogClientWithUnavailableCatalogs([]*catalogmetadata.Bundle{},
+			catalogmetadata.UnavailableCatalog{Name: "internal-catalog", Labels: map[string]string{"tier": "internal"}, Err: errors.New("fake error")},
+			catalogmetadata.UnavailableCatalog{Name: "community-catalog", Labels: map[string]string{"tier": "community"}, Err: errors.New("fake error")},
+		)
+		rpvs, err := variablesources.NewRequiredPackageVariableSource(&unavailableCatalogClient, packageName, variablesources.FromCatalogs(&operatorsv1alpha1.CatalogSelector{
+			LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"tier": "internal"}},
+		}))
+		Expect(err).NotTo(HaveOccurred())
+		_, err = rpvs.GetVariables(context.TODO())
+		Expect(err).To(MatchError(`no package 'test-package' found in the selected catalogs: skipped unavailable catalogs: catalog "internal-catalog" (fake error)`))
+		var unavailableErr *catalogmetadata.UnavailableCatalogsError
+		Expect(errors.As(err, &unavailableErr)).To(BeTrue())
+		Expect(unavailableErr.Catalogs).To(HaveLen(1))
+	})
+
 	It("should return an error if catalog client errors", func() {
 		testError := errors.New("something bad happened")
 		emptyCatalogClient := testutil.NewFakeCatalogClientWithError(testError)
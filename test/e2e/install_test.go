@@ -211,7 +211,7 @@ var _ = Describe("Operator Install", func() {
 					g.Expect(cond).ToNot(BeNil())
 					g.Expect(cond.Reason).To(Equal(operatorv1alpha1.ReasonResolutionFailed))
 					g.Expect(cond.Message).To(ContainSubstring("constraints not satisfiable"))
-					g.Expect(cond.Message).To(ContainSubstring("installed package prometheus requires at least one of test-catalog-prometheus-prometheus-operator.1.2.0, test-catalog-prometheus-prometheus-operator.1.0.1, test-catalog-prometheus-prometheus-operator.1.0.0;"))
+					g.Expect(cond.Message).To(ContainSubstring(fmt.Sprintf("operator %q has prometheus 1.0.0 installed, which can only be kept or upgraded to prometheus 1.2.0, prometheus 1.0.1;", operator.Name)))
 					g.Expect(operator.Status.ResolvedBundleResource).To(BeEmpty())
 				}).Should(Succeed())
 			})
@@ -7,8 +7,9 @@ import (
 )
 
 type FakeCatalogClient struct {
-	bundles []*catalogmetadata.Bundle
-	err     error
+	bundles             []*catalogmetadata.Bundle
+	unavailableCatalogs []catalogmetadata.UnavailableCatalog
+	err                 error
 }
 
 func NewFakeCatalogClient(b []*catalogmetadata.Bundle) FakeCatalogClient {
@@ -17,6 +18,13 @@ func NewFakeCatalogClient(b []*catalogmetadata.Bundle) FakeCatalogClient {
 	}
 }
 
+func NewFakeCatalogClientWithUnavailableCatalogs(b []*catalogmetadata.Bundle, u ...catalogmetadata.UnavailableCatalog) FakeCatalogClient {
+	return FakeCatalogClient{
+		bundles:             b,
+		unavailableCatalogs: u,
+	}
+}
+
 func NewFakeCatalogClientWithError(e error) FakeCatalogClient {
 	return FakeCatalogClient{
 		err: e,
@@ -27,5 +35,15 @@ func (c *FakeCatalogClient) Bundles(_ context.Context) ([]*catalogmetadata.Bundl
 	if c.err != nil {
 		return nil, c.err
 	}
+	if len(c.unavailableCatalogs) > 0 {
+		return nil, &catalogmetadata.UnavailableCatalogsError{Catalogs: c.unavailableCatalogs}
+	}
 	return c.bundles, nil
 }
+
+func (c *FakeCatalogClient) BundleIndex(_ context.Context) (*catalogmetadata.BundleIndex, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	return catalogmetadata.NewBundleIndex(c.bundles, c.unavailableCatalogs...), nil
+}